@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"sport-space/internal/adapter/logger"
+	"sport-space/internal/adapter/storage"
+	"sport-space/internal/adapter/storage/database"
+	"sport-space/internal/core/config"
+
+	"go.uber.org/zap"
+)
+
+// runMigrate команда `sportspace migrate [up | down [N] | status]`, по умолчанию up.
+func runMigrate(args []string) error {
+	ctx := context.Background()
+
+	cfg, err := config.Init()
+	if err != nil {
+		return fmt.Errorf("failed initialize config: %w", err)
+	}
+	lgr, err := logger.New(cfg.LogLevel)
+	if err != nil {
+		return fmt.Errorf("failed initialize logger: %w", err)
+	}
+
+	var db *database.Storage
+	switch cfg.Store.Driver {
+	case storage.DriverPostgres:
+		if cfg.Store.Database == nil {
+			return errors.New("database setting is empty")
+		}
+		dbCfg := *cfg.Store.Database
+		dbCfg.Migrate = false
+		db, err = database.New(ctx, dbCfg, database.SetLogger(lgr))
+	case storage.DriverSQLite:
+		if cfg.Store.SQLite == nil {
+			return errors.New("sqlite setting is empty")
+		}
+		dbCfg := *cfg.Store.SQLite
+		dbCfg.Migrate = false
+		db, err = database.NewSQLite(ctx, dbCfg, database.SetLogger(lgr))
+	default:
+		return fmt.Errorf("storage driver `%s` has no migrations", cfg.Store.Driver)
+	}
+	if err != nil {
+		return fmt.Errorf("failed initialize database: %w", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			lgr.Error("failed close database", zap.Error(err))
+		}
+	}()
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		count, err := db.Migrate(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d migrations\n", count)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of migrations `%s`", args[1])
+			}
+		}
+		count, err := db.MigrateDown(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("reverted %d migrations\n", count)
+	case "status":
+		states, err := db.Migrations(ctx)
+		if err != nil {
+			return err
+		}
+		for _, state := range *states {
+			applied := "pending"
+			if state.AppliedAt != nil {
+				applied = state.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d %-30s %s\n", state.Version, state.Name, applied)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command `%s`, use up, down [N] or status", command)
+	}
+	return nil
+}
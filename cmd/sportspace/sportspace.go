@@ -2,15 +2,29 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"os"
 
 	"sport-space/internal/adapter/api/rest"
+	"sport-space/internal/adapter/blob"
+	"sport-space/internal/adapter/cache"
+	"sport-space/internal/adapter/channel"
+	"sport-space/internal/adapter/lifecycle"
 	"sport-space/internal/adapter/logger"
+	"sport-space/internal/adapter/metrics"
+	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/ratelimit"
 	"sport-space/internal/adapter/sender"
 	"sport-space/internal/adapter/storage"
+	"sport-space/internal/adapter/storage/cached"
+	"sport-space/internal/adapter/tracing"
 	"sport-space/internal/core/config"
 	"sport-space/internal/core/sportspace"
+
+	"go.uber.org/zap"
 )
 
 var (
@@ -23,6 +37,12 @@ func main() {
 	fmt.Println("Build verson: " + BuildData(buildVersion))
 	fmt.Println("Build date: " + BuildData(buildDate))
 	fmt.Println("Build commit: " + BuildData(buildCommit))
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 	if err := run(); err != nil {
 		log.Fatal(err)
 	}
@@ -42,22 +62,97 @@ func run() error {
 		return fmt.Errorf("failed initialize logger: %w", err)
 	}
 
-	store, err := storage.New(ctx, cfg.Store)
+	lc := lifecycle.New(lifecycle.SetLogger(lgr))
+	server, err := start(ctx, cfg, lgr, lc)
 	if err != nil {
-		return fmt.Errorf("failed initialize storage: %w", err)
+		// уже запущенные компоненты тоже останавливаются
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer stopCancel()
+		return errors.Join(err, lc.Stop(stopCtx))
 	}
+	return lc.Run(server.Run, cfg.ShutdownTimeout)
+}
 
-	sender, err := sender.New(cfg.Sender, sender.SetLogger(lgr))
+// start создает компоненты сервиса и регистрирует их остановку в lc в порядке запуска.
+func start(ctx context.Context, cfg *config.Config, lgr *zap.Logger, lc *lifecycle.Lifecycle) (*rest.Server, error) {
+	// трассировка останавливается последней, чтобы отправить спаны остальных компонентов
+	tp, err := tracing.New(ctx, cfg.Tracing)
 	if err != nil {
-		return fmt.Errorf("failed initialize sender: %w", err)
+		return nil, fmt.Errorf("failed initialize tracing: %w", err)
+	}
+	lc.OnStop("tracing", tp.Shutdown)
+
+	m := metrics.New(metrics.SetLogger(lgr))
+	store, err := storage.New(ctx, cfg.Store, lgr, m)
+	if err != nil {
+		return nil, fmt.Errorf("failed initialize storage: %w", err)
+	}
+	onClose(lc, "storage", store)
+
+	readCache, err := cache.New(ctx, cfg.Cache)
+	if err != nil {
+		return nil, fmt.Errorf("failed initialize cache: %w", err)
+	}
+	onClose(lc, "cache", readCache)
+	store = cached.New(store, readCache, cached.SetTTL(cfg.Cache.TTL), cached.SetLogger(lgr))
+
+	limiter, err := ratelimit.New(ctx, cfg.RateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed initialize rate limiter: %w", err)
+	}
+	onClose(lc, "rate limiter", limiter)
+
+	sender, err := sender.New(cfg.Sender, store, sender.SetLogger(lgr), sender.SetMetrics(m))
+	if err != nil {
+		return nil, fmt.Errorf("failed initialize sender: %w", err)
+	}
+	lc.OnStop("sender", sender.Shutdown)
+
+	files, err := blob.New(ctx, cfg.Blob, cfg.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed initialize file storage: %w", err)
+	}
+
+	channels, err := channel.New(cfg.Channel, channel.SetLogger(lgr))
+	if err != nil {
+		return nil, fmt.Errorf("failed initialize otp channels: %w", err)
 	}
 
 	sspace, err := sportspace.New(store, sender,
 		sportspace.SetLogger(lgr),
+		sportspace.SetMetrics(m),
+		sportspace.SetCodeChannel(models.ChannelSMS, channels.SMS),
+		sportspace.SetCodeChannel(models.ChannelTelegram, channels.Telegram),
 		sportspace.SetOTPLength(cfg.Sport.OTPLength),
+		sportspace.SetRosterConflictPolicy(cfg.Sport.RosterConflictPolicy),
+		sportspace.SetLateSubstitutionsLimit(cfg.Sport.LateSubstitutionsLimit),
+		sportspace.SetBlob(files),
+		sportspace.SetUploadQuota(cfg.Sport.UploadQuota),
+		sportspace.SetUploadSessionTTL(cfg.Sport.UploadSessionTTL),
+		sportspace.SetTrashRetention(cfg.Sport.TrashRetention),
+		sportspace.SetIdempotencyTTL(cfg.Sport.IdempotencyTTL),
+		sportspace.SetIdempotencyLease(cfg.Sport.IdempotencyLease),
 	)
 	if err != nil {
-		return fmt.Errorf("failed initialize sportspace service: %w", err)
+		return nil, fmt.Errorf("failed initialize sportspace service: %w", err)
+	}
+	m.RegisterStats(sspace)
+	lc.Go(ctx, "upload gc", func(ctx context.Context) {
+		sspace.RunUploadGC(ctx, cfg.Sport.UploadGCInterval, cfg.Sport.UploadGCGrace)
+	})
+	lc.Go(ctx, "trash purge", func(ctx context.Context) {
+		sspace.RunTrashPurge(ctx, cfg.Sport.TrashPurgeInterval)
+	})
+	lc.Go(ctx, "idempotency purge", func(ctx context.Context) {
+		sspace.RunIdempotencyPurge(ctx, cfg.Sport.IdempotencyPurgeInterval)
+	})
+
+	// просмотр перехваченных писем без авторизации, только при явном MAIL_DEV_MAILBOX
+	mailbox := sender.Mailbox()
+	if !cfg.Sender.DevMailbox {
+		mailbox = nil
+	} else if mailbox == nil {
+		return nil, errors.New("MAIL_DEV_MAILBOX requires MAIL_TRANSPORT=memory")
 	}
 
 	server, err := rest.New(
@@ -65,18 +160,34 @@ func run() error {
 		rest.SetLogger(lgr),
 		rest.SetAddress(cfg.Address),
 		rest.SetSecretKey(cfg.SecretKey),
-		rest.SetUploadPath(cfg.UploadPath),
+		rest.SetBlob(files),
+		rest.SetUploadLimits(cfg.Rest.UploadMaxSize, cfg.Rest.UploadDocumentMaxSize, cfg.Rest.UploadChunkMaxSize),
+		rest.SetPublicMaxAge(cfg.Rest.PublicMaxAge),
+		rest.SetRateLimiter(limiter, cfg.Rest.RateLimits),
+		rest.SetTrustedProxies(cfg.Rest.TrustedProxies),
+		rest.SetMetrics(m, cfg.Rest.MetricsToken),
 		rest.SetBaseURL(cfg.BaseURL),
+		rest.SetOutbox(sender),
+		rest.SetAdminEmails(cfg.Rest.AdminEmails),
+		rest.SetMailbox(mailbox),
+		rest.SetTelegram(channels.Telegram, cfg.Channel.TelegramWebhookSecret),
 		rest.SetTLSConfig(cfg.Rest.TLSEnable, cfg.Rest.TLSCert, cfg.Rest.TLSKey, cfg.Rest.TLSHosts, cfg.Rest.TLSDirCache),
 	)
 	if err != nil {
-		return fmt.Errorf("failed initalize rest api: %w", err)
+		return nil, fmt.Errorf("failed initalize rest api: %w", err)
 	}
+	lc.OnStop("http server", server.Shutdown)
+
+	return server, nil
+}
 
-	if err := server.Run(); err != nil {
-		return err
+// onClose закрывает компонент при остановке, если у него есть Close.
+func onClose(lc *lifecycle.Lifecycle, name string, c any) {
+	if closer, ok := c.(io.Closer); ok {
+		lc.OnStop(name, func(context.Context) error {
+			return closer.Close()
+		})
 	}
-	return err
 }
 
 func BuildData(data string) string {
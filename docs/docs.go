@@ -15,6 +15,134 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/outbox": {
+            "get": {
+                "description": "письма в outbox по статусу, по умолчанию не доставленные (dead)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "очередь писем",
+                "parameters": [
+                    {
+                        "enum": [
+                            "pending",
+                            "sent",
+                            "dead"
+                        ],
+                        "type": "string",
+                        "description": "status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit size",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tGetOutboxResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/outbox/{message_id}/requeue": {
+            "post": {
+                "description": "вернуть письмо в очередь со сброшенным счетчиком попыток",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "повторить отправку письма",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "message id",
+                        "name": "message_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tOutboxMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "409": {
+                        "description": "mail_expired",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            }
+        },
         "/auth/login": {
             "post": {
                 "description": "authorization",
@@ -47,19 +175,28 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "login_invalid",
                         "schema": {
-                            "$ref": "#/definitions/rest.tLoginResponse"
+                            "$ref": "#/definitions/rest.tProblem"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "login_failed",
                         "schema": {
-                            "$ref": "#/definitions/rest.tLoginResponse"
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "429": {
+                        "description": "too_many_requests",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
             }
@@ -82,10 +219,16 @@ const docTemplate = `{
                         "description": "OK"
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
             }
@@ -119,28 +262,43 @@ const docTemplate = `{
                         "description": "OK"
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "429": {
+                        "description": "too_many_requests",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
             }
         },
-        "/tournaments": {
+        "/dev/mails": {
             "get": {
-                "description": "все турниры",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "письма, отправленные через транспорт memory, новые первыми. Доступно только в режиме разработки",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "guest"
+                    "dev"
                 ],
-                "summary": "все турниры",
+                "summary": "перехваченные письма",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "recipient email",
+                        "name": "to",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "page number",
@@ -158,28 +316,84 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.tGetTorunamentsResponse"
+                            "$ref": "#/definitions/rest.tGetMailsResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "удалить письма, перехваченные транспортом memory",
+                "tags": [
+                    "dev"
+                ],
+                "summary": "очистить перехваченные письма",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/telegram/webhook": {
+            "post": {
+                "description": "обновления от Telegram Bot API. Контакт, которым пользователь поделился в боте, привязывает чат к номеру телефона, на остальные сообщения бот просит поделиться номером",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "webhook Telegram бота",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "webhook secret",
+                        "name": "X-Telegram-Bot-Api-Secret-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "update",
+                        "name": "update",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest.tTelegramUpdate"
                         }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
                     },
-                    "400": {
-                        "description": "Bad Request"
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
             }
         },
-        "/user/players": {
+        "/tournaments": {
             "get": {
-                "description": "Все игроки",
+                "description": "все турниры",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user players"
+                    "guest"
                 ],
-                "summary": "Все игроки",
+                "summary": "все турниры",
                 "parameters": [
                     {
                         "type": "integer",
@@ -198,265 +412,278 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.tGetPlayersResponse"
+                            "$ref": "#/definitions/rest.tGetTorunamentsResponse"
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "public, max-age=\u003cHTTP_PUBLIC_MAX_AGE\u003e"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
-            },
-            "post": {
-                "description": "Добавить игрока",
+            }
+        },
+        "/user/notifications": {
+            "get": {
+                "description": "настройки email уведомлений пользователя",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user players"
-                ],
-                "summary": "Добавить игрока",
-                "parameters": [
-                    {
-                        "description": "player",
-                        "name": "player",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/rest.tNewPlayerRequest"
-                        }
-                    }
+                    "user"
                 ],
+                "summary": "настройки уведомлений",
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.tPlayerResponse"
+                            "$ref": "#/definitions/rest.tNotificationSettings"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request"
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
-            }
-        },
-        "/user/players/batch": {
-            "post": {
-                "description": "Добавить/Обновить игроков",
+            },
+            "put": {
+                "description": "обновить настройки email уведомлений пользователя",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user players"
+                    "user"
                 ],
-                "summary": "Добавить/Обновить игроков",
+                "summary": "обновить настройки уведомлений",
                 "parameters": [
                     {
-                        "description": "players",
-                        "name": "players",
+                        "description": "settings",
+                        "name": "settings",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/rest.tNewPlayerBatchRequest"
-                            }
+                            "$ref": "#/definitions/rest.tNotificationSettings"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.tNewPlayerBatchResponse"
+                            "$ref": "#/definitions/rest.tNotificationSettings"
                         }
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
-                    "409": {
-                        "description": "Conflict"
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
             }
         },
-        "/user/players/{player_id}": {
-            "put": {
-                "description": "обновить игрока",
-                "produces": [
+        "/user/players": {
+            "get": {
+                "description": "Все игроки",
+                "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "user players"
                 ],
-                "summary": "обновить игрока",
+                "summary": "Все игроки",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "player id",
-                        "name": "player_id",
-                        "in": "path",
-                        "required": true
+                        "description": "page number",
+                        "name": "page",
+                        "in": "query"
                     },
                     {
-                        "description": "player",
-                        "name": "id",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/rest.tUpdatePlayerRequest"
-                        }
+                        "type": "integer",
+                        "description": "limit size",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.tPlayerResponse"
+                            "$ref": "#/definitions/rest.tGetPlayersResponse"
                         }
                     },
-                    "204": {
-                        "description": "No Content"
-                    },
                     "400": {
-                        "description": "Bad Request"
-                    },
-                    "500": {
-                        "description": "Internal Server Error"
-                    }
-                }
-            }
-        },
-        "/user/profile": {
-            "get": {
-                "description": "user info",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "user"
-                ],
-                "summary": "user info",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/rest.tUserResponse"
+                            "$ref": "#/definitions/rest.tProblem"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized"
-                    },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
-            }
-        },
-        "/user/teams": {
-            "get": {
-                "description": "команды пользователя",
+            },
+            "post": {
+                "description": "Добавить игрока",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user team"
+                    "user players"
                 ],
-                "summary": "команды пользователя",
+                "summary": "Добавить игрока",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "limit size",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "player",
+                        "name": "player",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest.tNewPlayerRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/rest.tGetTeamsResponse"
+                            "$ref": "#/definitions/rest.tPlayerResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
-            },
+            }
+        },
+        "/user/players/batch": {
             "post": {
-                "description": "создать команду",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Добавить/Обновить игроков",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user team"
+                    "user players"
                 ],
-                "summary": "создать команду",
+                "summary": "Добавить/Обновить игроков",
                 "parameters": [
                     {
-                        "description": "team",
-                        "name": "tournamet",
+                        "description": "players",
+                        "name": "players",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/rest.tCreateTeam"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/rest.tNewPlayerBatchRequest"
+                            }
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ключ повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/rest.tTeam"
+                            "$ref": "#/definitions/rest.tNewPlayerBatchResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "422": {
+                        "description": "idempotency_key_reused",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
             }
         },
-        "/user/teams/{team_id}": {
+        "/user/players/{player_id}": {
             "get": {
-                "description": "информация команды пользователя",
+                "description": "Игрок пользователя, ETag нужен для обновления",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user team"
+                    "user players"
                 ],
-                "summary": "информация команды пользователя",
+                "summary": "Игрок",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "team id",
-                        "name": "team_id",
+                        "description": "player id",
+                        "name": "player_id",
                         "in": "path",
                         "required": true
                     }
@@ -465,44 +692,57 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.tGetTeamResponse"
+                            "$ref": "#/definitions/rest.tPlayerResponse"
                         }
                     },
                     "204": {
                         "description": "No Content"
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
             },
             "put": {
-                "description": "обновление команды пользователя",
+                "description": "обновить игрока",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user team"
+                    "user players"
                 ],
-                "summary": "обновление команды пользователя",
+                "summary": "обновить игрока",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "team id",
-                        "name": "team_id",
+                        "description": "player id",
+                        "name": "player_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "team",
-                        "name": "team",
+                        "type": "string",
+                        "description": "ETag игрока или *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "player",
+                        "name": "id",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/rest.tUpdTeamRequest"
+                            "$ref": "#/definitions/rest.tUpdatePlayerRequest"
                         }
                     }
                 ],
@@ -510,36 +750,52 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.tUpdTeamResponse"
+                            "$ref": "#/definitions/rest.tPlayerResponse"
                         }
                     },
                     "204": {
                         "description": "No Content"
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
-            }
-        },
-        "/user/teams/{team_id}/applications": {
-            "get": {
-                "description": "заявки команды",
+            },
+            "delete": {
+                "description": "Удалить игрока в корзину, из составов он пропадает до восстановления",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user team"
+                    "user players"
                 ],
-                "summary": "заявки команды",
+                "summary": "Удалить игрока",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "team id",
-                        "name": "team_id",
+                        "description": "player id",
+                        "name": "player_id",
                         "in": "path",
                         "required": true
                     }
@@ -548,135 +804,131 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.tGetApplicationsTeamResponse"
+                            "$ref": "#/definitions/rest.tTrashItem"
                         }
                     },
+                    "204": {
+                        "description": "игрок не найден"
+                    },
                     "400": {
-                        "description": "команда не найдена"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "409": {
+                        "description": "игрок в принятой заявке идущего турнира",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
-            },
+            }
+        },
+        "/user/players/{player_id}/restore": {
             "post": {
-                "description": "подать заявку",
+                "description": "Восстановить игрока из корзины",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user team"
+                    "user players"
                 ],
-                "summary": "подать заявку",
+                "summary": "Восстановить игрока",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "team id",
-                        "name": "team_id",
+                        "description": "player id",
+                        "name": "player_id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "application",
-                        "name": "application",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/rest.tNewApplicationRequest"
-                        }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "заявка создана",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.tNewApplicationResponse"
+                            "$ref": "#/definitions/rest.tPlayerResponse"
                         }
                     },
-                    "400": {
-                        "description": "не корректный запрос"
+                    "204": {
+                        "description": "игрока нет в корзине или срок хранения истек"
                     },
-                    "409": {
-                        "description": "заявка\tуже\tбыла создана ранее"
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
             }
         },
-        "/user/teams/{team_id}/applications/{application_id}": {
+        "/user/profile": {
             "get": {
-                "description": "заявка команды",
+                "description": "user info",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user team"
-                ],
-                "summary": "заявка команды",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "team id",
-                        "name": "team_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "application id",
-                        "name": "application_id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "user"
                 ],
+                "summary": "user info",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.tGetApplicationResponse"
+                            "$ref": "#/definitions/rest.tUserResponse"
                         }
                     },
-                    "400": {
-                        "description": "не корректный запрос"
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
             },
             "put": {
-                "description": "изменить заявку",
+                "description": "обновить язык писем пользователя (ru, en)",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user team"
+                    "user"
                 ],
-                "summary": "изменить заявку",
+                "summary": "обновить профиль",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "team id",
-                        "name": "team_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "application\tid",
-                        "name": "application_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "application status",
-                        "name": "application",
+                        "description": "profile",
+                        "name": "profile",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/rest.tUpdApplicationStatusRequest"
+                            "$ref": "#/definitions/rest.tUpdUserRequest"
                         }
                     }
                 ],
@@ -684,37 +936,40 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.tUpdApplicationResponse"
+                            "$ref": "#/definitions/rest.tUserResponse"
                         }
                     },
-                    "204": {
-                        "description": "заявка не найдена"
-                    },
                     "400": {
-                        "description": "не найден или не корректный запрос"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
-                    "403": {
-                        "description": "не может изменить"
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
             }
         },
-        "/user/tournaments": {
+        "/user/teams": {
             "get": {
-                "description": "турниры пользователя",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "команды пользователя",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user tournament"
+                    "user team"
                 ],
-                "summary": "турниры пользователя",
+                "summary": "команды пользователя",
                 "parameters": [
                     {
                         "type": "integer",
@@ -733,22 +988,25 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.tGetTorunamentsResponse"
+                            "$ref": "#/definitions/rest.tGetTeamsResponse"
                         }
                     },
-                    "204": {
-                        "description": "No Content"
-                    },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
             },
             "post": {
-                "description": "создать турнир",
+                "description": "создать команду",
                 "consumes": [
                     "application/json"
                 ],
@@ -756,51 +1014,75 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user tournament"
+                    "user team"
                 ],
-                "summary": "создать турнир",
+                "summary": "создать команду",
                 "parameters": [
                     {
-                        "description": "tournament",
+                        "description": "team",
                         "name": "tournamet",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/rest.tCreateTournamentRequest"
+                            "$ref": "#/definitions/rest.tCreateTeam"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ключ повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/rest.tTournamentResponse"
+                            "$ref": "#/definitions/rest.tTeam"
                         }
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "409": {
+                        "description": "idempotency_in_progress",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "422": {
+                        "description": "idempotency_key_reused",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
             }
         },
-        "/user/tournaments/{tournament_id}": {
+        "/user/teams/{team_id}": {
             "get": {
-                "description": "информация турнира пользователя",
+                "description": "информация команды пользователя",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user tournament"
+                    "user team"
                 ],
-                "summary": "информация турнира пользователя",
+                "summary": "информация команды пользователя",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "tournament id",
-                        "name": "tournament_id",
+                        "description": "team id",
+                        "name": "team_id",
                         "in": "path",
                         "required": true
                     }
@@ -809,81 +1091,110 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.tTournamentResponse"
+                            "$ref": "#/definitions/rest.tGetTeamResponse"
                         }
                     },
                     "204": {
                         "description": "No Content"
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
             },
             "put": {
-                "description": "Обновить турнир",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "обновление команды пользователя",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user tournament"
+                    "user team"
                 ],
-                "summary": "Обновить турнир",
+                "summary": "обновление команды пользователя",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "tournament id",
-                        "name": "tournament_id",
+                        "description": "team id",
+                        "name": "team_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "tournament",
-                        "name": "tournamet",
+                        "type": "string",
+                        "description": "ETag команды или *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "team",
+                        "name": "team",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/rest.tUpdTournamentRequest"
+                            "$ref": "#/definitions/rest.tUpdTeamRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tUpdTeamResponse"
+                        }
                     },
                     "204": {
                         "description": "No Content"
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
-            }
-        },
-        "/user/tournaments/{tournament_id}/applications": {
-            "get": {
-                "description": "заявки на турнир",
+            },
+            "delete": {
+                "description": "Удалить команду в корзину вместе с заявками, восстановить можно до purgeAt",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user tournament"
+                    "user team"
                 ],
-                "summary": "заявки на турнир",
+                "summary": "Удалить команду",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "tournament id",
-                        "name": "tournament_id",
+                        "description": "team id",
+                        "name": "team_id",
                         "in": "path",
                         "required": true
                     }
@@ -892,40 +1203,48 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.tGetTournamentApplicationsResponse"
+                            "$ref": "#/definitions/rest.tTrashItem"
                         }
                     },
+                    "204": {
+                        "description": "команда не найдена"
+                    },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "409": {
+                        "description": "у команды принятая заявка в идущий турнир",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
             }
         },
-        "/user/tournaments/{tournament_id}/applications/{application_id}": {
+        "/user/teams/{team_id}/applications": {
             "get": {
-                "description": "заявка турнира",
+                "description": "заявки команды",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user tournament"
+                    "user team"
                 ],
-                "summary": "заявка турнира",
+                "summary": "заявки команды",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "tournament id",
-                        "name": "tournament_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "application id",
-                        "name": "application_id",
+                        "description": "team id",
+                        "name": "team_id",
                         "in": "path",
                         "required": true
                     }
@@ -934,38 +1253,37 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.tGetTorunamentApplicationResponse"
+                            "$ref": "#/definitions/rest.tGetApplicationsTeamResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "команда не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
             },
-            "put": {
-                "description": "изменить заявку",
+            "post": {
+                "description": "подать заявку",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user tournament"
+                    "user team"
                 ],
-                "summary": "изменить заявку",
+                "summary": "подать заявку",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "tournament id",
-                        "name": "tournament_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "application id",
-                        "name": "application_id",
+                        "description": "team id",
+                        "name": "team_id",
                         "in": "path",
                         "required": true
                     },
@@ -975,31 +1293,969 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/rest.tUpdTournamentApplicationRequest"
+                            "$ref": "#/definitions/rest.tNewApplicationRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ключ повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "заявка создана",
                         "schema": {
-                            "$ref": "#/definitions/rest.tApplication"
+                            "$ref": "#/definitions/rest.tNewApplicationResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "не корректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "409": {
+                        "description": "заявка уже была создана ранее или игроки заявлены в другой команде",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "422": {
+                        "description": "idempotency_key_reused",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
-                    }
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/teams/{team_id}/applications/{application_id}": {
+            "get": {
+                "description": "заявка команды",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user team"
+                ],
+                "summary": "заявка команды",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "team id",
+                        "name": "team_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "application_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tGetApplicationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "не корректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "изменить заявку",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user team"
+                ],
+                "summary": "изменить заявку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "team id",
+                        "name": "team_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "application\tid",
+                        "name": "application_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag заявки или *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "application status",
+                        "name": "application",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest.tUpdApplicationStatusRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tUpdApplicationResponse"
+                        }
+                    },
+                    "204": {
+                        "description": "заявка не найдена"
+                    },
+                    "400": {
+                        "description": "не найден или не корректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "403": {
+                        "description": "не может изменить",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "409": {
+                        "description": "игроки заявлены в другой команде",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "412": {
+                        "description": "заявка изменена после получения ETag",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "428": {
+                        "description": "нет заголовка If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/teams/{team_id}/applications/{application_id}/changes": {
+            "get": {
+                "description": "запросы на замену игроков заявки команды",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user team"
+                ],
+                "summary": "запросы на замену игроков заявки команды",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "team id",
+                        "name": "team_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "application_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tGetRosterChangesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "не корректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "запрос на замену игрока в принятой заявке после заморозки состава",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user team"
+                ],
+                "summary": "запрос на замену игрока",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "team id",
+                        "name": "team_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "application_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "roster change",
+                        "name": "change",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest.tNewRosterChangeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tRosterChange"
+                        }
+                    },
+                    "204": {
+                        "description": "заявка не найдена"
+                    },
+                    "400": {
+                        "description": "не корректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "403": {
+                        "description": "заявка не принята или турнир завершен",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "409": {
+                        "description": "состав не заморожен, лимит замен исчерпан или игрок уже заявлен",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/teams/{team_id}/restore": {
+            "post": {
+                "description": "Восстановить команду из корзины вместе с заявками, удаленными с ней",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user team"
+                ],
+                "summary": "Восстановить команду",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "team id",
+                        "name": "team_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tTeam"
+                        }
+                    },
+                    "204": {
+                        "description": "команды нет в корзине или срок хранения истек"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/tournaments": {
+            "get": {
+                "description": "турниры пользователя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user tournament"
+                ],
+                "summary": "турниры пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit size",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tGetTorunamentsResponse"
+                        }
+                    },
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "создать турнир",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user tournament"
+                ],
+                "summary": "создать турнир",
+                "parameters": [
+                    {
+                        "description": "tournament",
+                        "name": "tournamet",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest.tTournamentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tTournamentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/tournaments/{tournament_id}": {
+            "get": {
+                "description": "информация турнира пользователя",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user tournament"
+                ],
+                "summary": "информация турнира пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tournament id",
+                        "name": "tournament_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tTournamentResponse"
+                        }
+                    },
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновить турнир",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user tournament"
+                ],
+                "summary": "Обновить турнир",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tournament id",
+                        "name": "tournament_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag турнира или *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "tournament",
+                        "name": "tournamet",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest.tTournamentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удалить турнир в корзину вместе с заявками, восстановить можно до purgeAt",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user tournament"
+                ],
+                "summary": "Удалить турнир",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tournament id",
+                        "name": "tournament_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tTrashItem"
+                        }
+                    },
+                    "204": {
+                        "description": "турнир не найден"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "409": {
+                        "description": "турнир идет и в нем есть принятые заявки",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/tournaments/{tournament_id}/applications": {
+            "get": {
+                "description": "заявки на турнир",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user tournament"
+                ],
+                "summary": "заявки на турнир",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tournament id",
+                        "name": "tournament_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tGetTournamentApplicationsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/tournaments/{tournament_id}/applications/{application_id}": {
+            "get": {
+                "description": "заявка турнира",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user tournament"
+                ],
+                "summary": "заявка турнира",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tournament id",
+                        "name": "tournament_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "application_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tGetTorunamentApplicationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "изменить заявку",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user tournament"
+                ],
+                "summary": "изменить заявку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tournament id",
+                        "name": "tournament_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "application_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag заявки или *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "application",
+                        "name": "application",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest.tUpdTournamentApplicationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tApplication"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/tournaments/{tournament_id}/applications/{application_id}/changes": {
+            "get": {
+                "description": "запросы на замену игроков заявки турнира",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user tournament"
+                ],
+                "summary": "запросы на замену игроков заявки турнира",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tournament id",
+                        "name": "tournament_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "application_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tGetRosterChangesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "не корректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/tournaments/{tournament_id}/applications/{application_id}/changes/{change_id}": {
+            "put": {
+                "description": "одобрить или отклонить запрос на замену игрока",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user tournament"
+                ],
+                "summary": "рассмотреть запрос на замену игрока",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tournament id",
+                        "name": "tournament_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "application_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "change id",
+                        "name": "change_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "roster change status",
+                        "name": "change",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest.tUpdRosterChangeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tRosterChange"
+                        }
+                    },
+                    "400": {
+                        "description": "не найден или не корректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "403": {
+                        "description": "запрос уже рассмотрен",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "409": {
+                        "description": "замена больше не применима",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/tournaments/{tournament_id}/restore": {
+            "post": {
+                "description": "Восстановить турнир из корзины вместе с заявками, удаленными с ним",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user tournament"
+                ],
+                "summary": "Восстановить турнир",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tournament id",
+                        "name": "tournament_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tTournamentResponse"
+                        }
+                    },
+                    "204": {
+                        "description": "турнира нет в корзине или срок хранения истек"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/trash": {
+            "get": {
+                "description": "Удаленные турниры, команды и игроки пользователя, которые еще можно восстановить",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user trash"
+                ],
+                "summary": "Корзина",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tTrashResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
                 }
             }
         },
         "/user/upload": {
             "post": {
-                "description": "загрузка файла",
+                "description": "загрузка логотипа или фото (JPEG, PNG, GIF, WebP) размером до UPLOAD_MAX_SIZE. Формат определяется по содержимому, метаданные удаляются, изображение сохраняется в вариантах thumbnail, medium и original с общим путем ` + "`" + `\u003cкаталог\u003e/\u003cвариант\u003e.\u003cext\u003e` + "`" + `. В logoUrl и photoUrl можно указывать только url своих загрузок. Загрузки, на которые ничего не ссылается, периодически удаляются",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
@@ -1007,7 +2263,7 @@ const docTemplate = `{
                 "tags": [
                     "user"
                 ],
-                "summary": "загрузка файла",
+                "summary": "загрузка изображения",
                 "parameters": [
                     {
                         "type": "file",
@@ -1025,10 +2281,269 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "403": {
+                        "description": "upload quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "413": {
+                        "description": "file is too large",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/upload/sessions": {
+            "post": {
+                "description": "загрузка документа (PDF, JPEG, PNG), например медицинской справки, частями до chunkMaxSize байт. Части отправляются PUT /user/upload/sessions/{sid}, после последней части документ доступен в upload.url, его можно указать в medicalCertificateUrl игрока. Незавершенные загрузки удаляются через UPLOAD_SESSION_TTL",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "начало загрузки документа",
+                "parameters": [
+                    {
+                        "description": "имя и размер файла",
+                        "name": "params",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest.tNewUploadSessionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tUploadSessionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "403": {
+                        "description": "upload quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "413": {
+                        "description": "document is too large",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/upload/sessions/{sid}": {
+            "get": {
+                "description": "сколько байт получено, после обрыва соединения загрузка продолжается с offset",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "состояние загрузки документа",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "upload session id",
+                        "name": "sid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tUploadSessionResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "тело запроса - байты документа с позиции start по end включительно из заголовка ` + "`" + `Content-Range: bytes \u003cstart\u003e-\u003cend\u003e/\u003csize\u003e` + "`" + `, start должен быть равен offset сессии. При несовпадении возвращается 409 upload_offset с offset сессии. Повтор последней части возвращает собранный документ",
+                "consumes": [
+                    "application/octet-stream"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "часть документа",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "upload session id",
+                        "name": "sid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "bytes \u003cstart\u003e-\u003cend\u003e/\u003csize\u003e",
+                        "name": "Content-Range",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tUploadSessionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "403": {
+                        "description": "upload quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "409": {
+                        "description": "upload_offset, продолжить с offset",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "410": {
+                        "description": "upload session expired",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "413": {
+                        "description": "chunk is too large",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "удаляет полученные части, уже собранный документ остается",
+                "tags": [
+                    "user"
+                ],
+                "summary": "отмена загрузки документа",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "upload session id",
+                        "name": "sid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.tProblem"
+                        }
                     }
                 }
             }
@@ -1077,6 +2592,9 @@ const docTemplate = `{
         },
         "rest.tAuthorization": {
             "type": "object",
+            "required": [
+                "otp"
+            ],
             "properties": {
                 "email": {
                     "type": "string"
@@ -1084,6 +2602,10 @@ const docTemplate = `{
                 "otp": {
                     "description": "Password string ` + "`" + `json:\"password\"` + "`" + `",
                     "type": "string"
+                },
+                "phone": {
+                    "description": "Phone вход по номеру телефона вместо email.",
+                    "type": "string"
                 }
             }
         },
@@ -1101,41 +2623,20 @@ const docTemplate = `{
                 }
             }
         },
-        "rest.tCreateTournamentRequest": {
+        "rest.tFieldError": {
             "type": "object",
-            "required": [
-                "endDate",
-                "startDate",
-                "title"
-            ],
             "properties": {
-                "description": {
-                    "type": "string"
-                },
-                "endDate": {
-                    "type": "string",
-                    "example": "2024-12-31T06:00:00+03:00"
-                },
-                "logoUrl": {
-                    "type": "string"
-                },
-                "organization": {
-                    "type": "string"
-                },
-                "registerEndDate": {
+                "code": {
                     "type": "string",
-                    "example": "2024-12-31T06:00:00+03:00"
+                    "example": "required"
                 },
-                "registerStartDate": {
+                "field": {
                     "type": "string",
-                    "example": "2024-12-31T06:00:00+03:00"
+                    "example": "title"
                 },
-                "startDate": {
+                "message": {
                     "type": "string",
-                    "example": "2024-12-31T06:00:00+03:00"
-                },
-                "title": {
-                    "type": "string"
+                    "example": "Обязательное поле"
                 }
             }
         },
@@ -1173,17 +2674,56 @@ const docTemplate = `{
                 }
             }
         },
+        "rest.tGetMailsResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.tMail"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/rest.pagination"
+                }
+            }
+        },
+        "rest.tGetOutboxResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.tOutboxMessage"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/rest.pagination"
+                }
+            }
+        },
         "rest.tGetPlayersResponse": {
             "type": "object",
             "properties": {
                 "data": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/rest.tPlayerResponse"
+                        "$ref": "#/definitions/rest.tPlayerResponse"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/rest.pagination"
+                }
+            }
+        },
+        "rest.tGetRosterChangesResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.tRosterChange"
                     }
-                },
-                "pagination": {
-                    "$ref": "#/definitions/rest.pagination"
                 }
             }
         },
@@ -1283,23 +2823,53 @@ const docTemplate = `{
                 },
                 "url": {
                     "type": "string"
+                },
+                "variants": {
+                    "description": "Variants адреса вариантов изображения: thumbnail, medium, original.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 }
             }
         },
         "rest.tLoginResponse": {
             "type": "object",
             "properties": {
-                "error": {
-                    "type": "string"
-                },
                 "userID": {
                     "type": "integer",
                     "example": 1
                 }
             }
         },
+        "rest.tMail": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "html": {
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
         "rest.tNewApplicationRequest": {
             "type": "object",
+            "required": [
+                "tournamentId"
+            ],
             "properties": {
                 "playerIds": {
                     "type": "array",
@@ -1332,138 +2902,441 @@ const docTemplate = `{
                 },
                 "tournamentTitle": {
                     "type": "string"
+                },
+                "warnings": {
+                    "description": "Warnings игроки, заявленные в другой команде, при ROSTER_CONFLICT_POLICY=warn.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.tRosterConflict"
+                    }
+                }
+            }
+        },
+        "rest.tNewPlayerBatchRequest": {
+            "type": "object",
+            "required": [
+                "firstName",
+                "lastName"
+            ],
+            "properties": {
+                "bDay": {
+                    "type": "string",
+                    "example": "2024-12-31T06:00:00+03:00"
+                },
+                "firstName": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastName": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "medicalCertificateUrl": {
+                    "type": "string"
+                },
+                "photoUrl": {
+                    "type": "string"
+                },
+                "secondName": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "rest.tNewPlayerBatchResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "Pagination pagination         ` + "`" + `json:\"pagination\"` + "`" + `",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.tPlayerBatchResponse"
+                    }
+                }
+            }
+        },
+        "rest.tNewPlayerRequest": {
+            "type": "object",
+            "required": [
+                "firstName",
+                "lastName"
+            ],
+            "properties": {
+                "bDay": {
+                    "type": "string",
+                    "example": "2024-12-31T06:00:00+03:00"
+                },
+                "firstName": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "lastName": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "medicalCertificateUrl": {
+                    "type": "string"
+                },
+                "photoUrl": {
+                    "type": "string"
+                },
+                "secondName": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "rest.tNewRosterChangeRequest": {
+            "type": "object",
+            "required": [
+                "playerInId",
+                "playerOutId"
+            ],
+            "properties": {
+                "playerInId": {
+                    "type": "integer"
+                },
+                "playerOutId": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 1000
+                }
+            }
+        },
+        "rest.tNewUploadSessionRequest": {
+            "type": "object",
+            "properties": {
+                "filename": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "rest.tNotificationSettings": {
+            "type": "object",
+            "properties": {
+                "applicationCanceled": {
+                    "description": "ApplicationCanceled отмена заявки на турниры пользователя.",
+                    "type": "boolean"
+                },
+                "applicationStatus": {
+                    "description": "ApplicationStatus решение организатора по заявкам команд пользователя.",
+                    "type": "boolean"
+                },
+                "applicationSubmitted": {
+                    "description": "ApplicationSubmitted подача заявки на турниры пользователя.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "rest.tOutboxMessage": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string",
+                    "example": "2024-12-31T06:00:00+03:00"
+                },
+                "expiresAt": {
+                    "description": "ExpiresAt срок отправки, после него письмо переносится в dead.",
+                    "type": "string",
+                    "example": "2024-12-31T06:00:00+03:00"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "nextAttemptAt": {
+                    "type": "string",
+                    "example": "2024-12-31T06:00:00+03:00"
+                },
+                "sentAt": {
+                    "type": "string",
+                    "example": "2024-12-31T06:00:00+03:00"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "sent",
+                        "dead"
+                    ]
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "template": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "rest.tPlayerBatchResponse": {
+            "type": "object",
+            "properties": {
+                "bDay": {
+                    "type": "string",
+                    "example": "2024-12-31T06:00:00+03:00"
+                },
+                "firstName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastName": {
+                    "type": "string"
+                },
+                "medicalCertificateUrl": {
+                    "type": "string"
+                },
+                "photoUrl": {
+                    "type": "string"
+                },
+                "secondName": {
+                    "type": "string"
+                }
+            }
+        },
+        "rest.tPlayerResponse": {
+            "type": "object",
+            "properties": {
+                "bDay": {
+                    "type": "string",
+                    "example": "2024-12-31T06:00:00+03:00"
+                },
+                "firstName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastName": {
+                    "type": "string"
+                },
+                "medicalCertificateUrl": {
+                    "type": "string"
+                },
+                "photoUrl": {
+                    "type": "string"
+                },
+                "secondName": {
+                    "type": "string"
+                }
+            }
+        },
+        "rest.tProblem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Code машиночитаемый код ошибки, текст title зависит от Accept-Language.",
+                    "type": "string",
+                    "example": "validation_failed"
+                },
+                "conflicts": {
+                    "description": "Conflicts игроки, заявленные в другой команде, при code=roster_conflict.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.tRosterConflict"
+                    }
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "errors": {
+                    "description": "Errors ошибки отдельных полей при code=validation_failed.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.tFieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/v1/user/teams"
+                },
+                "maxSize": {
+                    "description": "MaxSize лимит в байтах при code=too_large.",
+                    "type": "integer"
+                },
+                "offset": {
+                    "description": "Offset сколько байт загрузки уже получено при code=upload_offset.",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "Status дублирует статус ответа.",
+                    "type": "integer",
+                    "example": 400
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Ошибка в данных запроса"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "urn:sportspace:problem:validation_failed"
+                }
+            }
+        },
+        "rest.tRequestOTP": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "description": "Channel канал для нового пользователя с номером телефона: sms (по умолчанию) или telegram.",
+                    "type": "string",
+                    "enum": [
+                        "sms",
+                        "telegram"
+                    ]
+                },
+                "email": {
+                    "type": "string"
+                },
+                "language": {
+                    "description": "Language язык писем для нового пользователя, по умолчанию берется из Accept-Language.",
+                    "type": "string"
+                },
+                "phone": {
+                    "description": "Phone номер телефона вместо email, код придет в канал, выбранный при регистрации.",
+                    "type": "string"
                 }
             }
         },
-        "rest.tNewPlayerBatchRequest": {
+        "rest.tRosterChange": {
             "type": "object",
             "properties": {
-                "bDay": {
+                "applicationId": {
+                    "type": "integer"
+                },
+                "createdAt": {
                     "type": "string",
                     "example": "2024-12-31T06:00:00+03:00"
                 },
-                "firstName": {
-                    "type": "string"
-                },
                 "id": {
                     "type": "integer"
                 },
-                "lastName": {
-                    "type": "string"
+                "playerInId": {
+                    "type": "integer"
                 },
-                "photoUrl": {
+                "playerOutId": {
+                    "type": "integer"
+                },
+                "reason": {
                     "type": "string"
                 },
-                "secondName": {
+                "status": {
                     "type": "string"
-                }
-            }
-        },
-        "rest.tNewPlayerBatchResponse": {
-            "type": "object",
-            "properties": {
-                "data": {
-                    "description": "Pagination pagination         ` + "`" + `json:\"pagination\"` + "`" + `",
+                },
+                "statusDate": {
+                    "type": "string",
+                    "example": "2024-12-31T06:00:00+03:00"
+                },
+                "warnings": {
+                    "description": "Warnings игроки, заявленные в другой команде, при ROSTER_CONFLICT_POLICY=warn.",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/rest.tPlayerBatchResponse"
+                        "$ref": "#/definitions/rest.tRosterConflict"
                     }
                 }
             }
         },
-        "rest.tNewPlayerRequest": {
+        "rest.tRosterConflict": {
             "type": "object",
             "properties": {
-                "bDay": {
-                    "type": "string",
-                    "example": "2024-12-31T06:00:00+03:00"
-                },
-                "firstName": {
-                    "type": "string"
-                },
-                "lastName": {
-                    "type": "string"
+                "applicationId": {
+                    "type": "integer"
                 },
-                "photoUrl": {
-                    "type": "string"
+                "playerId": {
+                    "type": "integer"
                 },
-                "secondName": {
-                    "type": "string"
+                "teamId": {
+                    "type": "integer"
                 }
             }
         },
-        "rest.tPlayerBatchResponse": {
+        "rest.tTeam": {
             "type": "object",
             "properties": {
-                "bDay": {
-                    "type": "string",
-                    "example": "2024-12-31T06:00:00+03:00"
-                },
-                "firstName": {
+                "createdAt": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "lastName": {
+                "logoUrl": {
                     "type": "string"
                 },
                 "photoUrl": {
                     "type": "string"
                 },
-                "secondName": {
+                "title": {
                     "type": "string"
                 }
             }
         },
-        "rest.tPlayerResponse": {
+        "rest.tTelegramChat": {
             "type": "object",
             "properties": {
-                "bDay": {
-                    "type": "string",
-                    "example": "2024-12-31T06:00:00+03:00"
-                },
-                "firstName": {
-                    "type": "string"
-                },
                 "id": {
                     "type": "integer"
-                },
-                "lastName": {
-                    "type": "string"
-                },
-                "photoUrl": {
+                }
+            }
+        },
+        "rest.tTelegramContact": {
+            "type": "object",
+            "properties": {
+                "phone_number": {
                     "type": "string"
                 },
-                "secondName": {
-                    "type": "string"
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "rest.tRequestOTP": {
+        "rest.tTelegramMessage": {
             "type": "object",
             "properties": {
-                "email": {
-                    "type": "string"
+                "chat": {
+                    "$ref": "#/definitions/rest.tTelegramChat"
+                },
+                "contact": {
+                    "$ref": "#/definitions/rest.tTelegramContact"
+                },
+                "from": {
+                    "$ref": "#/definitions/rest.tTelegramUser"
                 }
             }
         },
-        "rest.tTeam": {
+        "rest.tTelegramUpdate": {
             "type": "object",
             "properties": {
-                "createdAt": {
-                    "type": "string"
+                "message": {
+                    "$ref": "#/definitions/rest.tTelegramMessage"
                 },
+                "update_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "rest.tTelegramUser": {
+            "type": "object",
+            "properties": {
                 "id": {
                     "type": "integer"
                 },
-                "logoUrl": {
-                    "type": "string"
-                },
-                "photoUrl": {
-                    "type": "string"
-                },
-                "title": {
+                "language_code": {
                     "type": "string"
                 }
             }
@@ -1488,6 +3361,54 @@ const docTemplate = `{
                 }
             }
         },
+        "rest.tTournamentRequest": {
+            "type": "object",
+            "required": [
+                "endDate",
+                "startDate",
+                "title"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "endDate": {
+                    "type": "string",
+                    "example": "2024-12-31T06:00:00+03:00"
+                },
+                "logoUrl": {
+                    "type": "string"
+                },
+                "maxLateSubstitutions": {
+                    "description": "MaxLateSubstitutions лимит замен после заморозки состава, null - значение по умолчанию.",
+                    "type": "integer"
+                },
+                "organization": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "registerEndDate": {
+                    "type": "string",
+                    "example": "2024-12-31T06:00:00+03:00"
+                },
+                "registerStartDate": {
+                    "type": "string",
+                    "example": "2024-12-31T06:00:00+03:00"
+                },
+                "rosterFreezeDate": {
+                    "type": "string",
+                    "example": "2024-12-31T06:00:00+03:00"
+                },
+                "startDate": {
+                    "type": "string",
+                    "example": "2024-12-31T06:00:00+03:00"
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
         "rest.tTournamentResponse": {
             "type": "object",
             "properties": {
@@ -1504,6 +3425,9 @@ const docTemplate = `{
                 "logoUrl": {
                     "type": "string"
                 },
+                "maxLateSubstitutions": {
+                    "type": "integer"
+                },
                 "organization": {
                     "type": "string"
                 },
@@ -1518,6 +3442,10 @@ const docTemplate = `{
                     "type": "string",
                     "example": "2024-12-31T06:00:00+03:00"
                 },
+                "rosterFreezeDate": {
+                    "type": "string",
+                    "example": "2024-12-31T06:00:00+03:00"
+                },
                 "startDate": {
                     "type": "string",
                     "example": "2024-12-31T06:00:00+03:00"
@@ -1527,6 +3455,46 @@ const docTemplate = `{
                 }
             }
         },
+        "rest.tTrashItem": {
+            "type": "object",
+            "properties": {
+                "deletedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "purgeAt": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "rest.tTrashResponse": {
+            "type": "object",
+            "properties": {
+                "players": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.tTrashItem"
+                    }
+                },
+                "teams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.tTrashItem"
+                    }
+                },
+                "tournaments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.tTrashItem"
+                    }
+                }
+            }
+        },
         "rest.tUpdApplicationResponse": {
             "type": "object",
             "properties": {
@@ -1547,6 +3515,13 @@ const docTemplate = `{
                 },
                 "tournamentTitle": {
                     "type": "string"
+                },
+                "warnings": {
+                    "description": "Warnings игроки, заявленные в другой команде, при ROSTER_CONFLICT_POLICY=warn.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.tRosterConflict"
+                    }
                 }
             }
         },
@@ -1569,6 +3544,21 @@ const docTemplate = `{
                 }
             }
         },
+        "rest.tUpdRosterChangeRequest": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "approve",
+                        "decline"
+                    ]
+                }
+            }
+        },
         "rest.tUpdTeamRequest": {
             "type": "object",
             "properties": {
@@ -1617,6 +3607,9 @@ const docTemplate = `{
         },
         "rest.tUpdTournamentApplicationRequest": {
             "type": "object",
+            "required": [
+                "status"
+            ],
             "properties": {
                 "status": {
                     "type": "string",
@@ -1627,72 +3620,100 @@ const docTemplate = `{
                 }
             }
         },
-        "rest.tUpdTournamentRequest": {
+        "rest.tUpdUserRequest": {
             "type": "object",
             "required": [
-                "endDate",
-                "startDate"
+                "language"
             ],
             "properties": {
-                "description": {
+                "language": {
                     "type": "string"
-                },
-                "endDate": {
+                }
+            }
+        },
+        "rest.tUpdatePlayerRequest": {
+            "type": "object",
+            "required": [
+                "firstName",
+                "lastName"
+            ],
+            "properties": {
+                "bDay": {
                     "type": "string",
                     "example": "2024-12-31T06:00:00+03:00"
                 },
-                "logoUrl": {
-                    "type": "string"
-                },
-                "organization": {
-                    "type": "string"
-                },
-                "registerEndDate": {
+                "firstName": {
                     "type": "string",
-                    "example": "2024-12-31T06:00:00+03:00"
+                    "maxLength": 100
                 },
-                "registerStartDate": {
+                "lastName": {
                     "type": "string",
-                    "example": "2024-12-31T06:00:00+03:00"
+                    "maxLength": 100
                 },
-                "startDate": {
-                    "type": "string",
-                    "example": "2024-12-31T06:00:00+03:00"
+                "medicalCertificateUrl": {
+                    "type": "string"
                 },
-                "title": {
+                "photoUrl": {
                     "type": "string"
+                },
+                "secondName": {
+                    "type": "string",
+                    "maxLength": 100
                 }
             }
         },
-        "rest.tUpdatePlayerRequest": {
+        "rest.tUploadSessionResponse": {
             "type": "object",
             "properties": {
-                "bDay": {
-                    "type": "string",
-                    "example": "2024-12-31T06:00:00+03:00"
+                "chunkMaxSize": {
+                    "type": "integer"
                 },
-                "firstName": {
+                "expiresAt": {
                     "type": "string"
                 },
-                "lastName": {
+                "filename": {
                     "type": "string"
                 },
-                "photoUrl": {
+                "id": {
                     "type": "string"
                 },
-                "secondName": {
-                    "type": "string"
+                "offset": {
+                    "description": "Offset сколько байт получено, следующая часть начинается с него.",
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "upload": {
+                    "description": "Upload собранный документ, когда получены все части.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/rest.tHandlerUploadResponse"
+                        }
+                    ]
                 }
             }
         },
         "rest.tUserResponse": {
             "type": "object",
             "properties": {
+                "channel": {
+                    "type": "string"
+                },
                 "email": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "telegramLinked": {
+                    "type": "boolean"
                 }
             }
         }
@@ -1,9 +1,41 @@
 package rest
 
+import (
+	"time"
+
+	"sport-space/internal/adapter/ratelimit"
+)
+
 type Config struct {
 	TLSEnable   uint   `env:"TLS_ENABLE" envDefault:"0"`
 	TLSCert     string `env:"TLS_CERT" envDefault:""`
 	TLSKey      string `env:"TLS_KEY" envDefault:""`
 	TLSHosts    string `env:"TLS_HOSTS" envDefault:""`
 	TLSDirCache string `env:"TLS_DIR_CACHE"`
+	AdminEmails string `env:"ADMIN_EMAILS" envDefault:""`
+	// TrustedProxies прокси перед сервером через ";", им доверяется X-Forwarded-For
+	TrustedProxies string `env:"HTTP_TRUSTED_PROXIES" envDefault:""`
+	// лимиты загрузок в байтах
+	UploadMaxSize         int64 `env:"UPLOAD_MAX_SIZE" envDefault:"2097152"`
+	UploadDocumentMaxSize int64 `env:"UPLOAD_DOCUMENT_MAX_SIZE" envDefault:"20971520"`
+	UploadChunkMaxSize    int64 `env:"UPLOAD_CHUNK_MAX_SIZE" envDefault:"5242880"`
+	// PublicMaxAge сколько клиенты и CDN могут хранить ответы публичных методов
+	PublicMaxAge time.Duration `env:"HTTP_PUBLIC_MAX_AGE" envDefault:"30s"`
+	// MetricsToken Bearer токен для чтения /metrics, пустой - метрики доступны всем
+	MetricsToken string `env:"METRICS_TOKEN" envDefault:""`
+	RateLimits   RateLimits
+}
+
+// RateLimits лимиты запросов групп маршрутов вида 5/1m (запросов за период), 0 - без лимита.
+type RateLimits struct {
+	// Auth вход и отправка кода, по IP.
+	Auth ratelimit.Limit `env:"RATE_LIMIT_AUTH" envDefault:"10/1m"`
+	// OTP отправка кода, по email или телефону получателя, сверх лимита Auth.
+	OTP ratelimit.Limit `env:"RATE_LIMIT_OTP" envDefault:"5/10m"`
+	// User методы пользователя, по пользователю.
+	User ratelimit.Limit `env:"RATE_LIMIT_USER" envDefault:"300/1m"`
+	// Upload загрузки файлов, по пользователю, сверх лимита User.
+	Upload ratelimit.Limit `env:"RATE_LIMIT_UPLOAD" envDefault:"30/1m"`
+	// Public публичные методы без входа, по IP.
+	Public ratelimit.Limit `env:"RATE_LIMIT_PUBLIC" envDefault:"120/1m"`
 }
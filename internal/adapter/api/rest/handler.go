@@ -1,16 +1,17 @@
 package rest
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"sport-space/internal/adapter/errsport"
 	"sport-space/internal/adapter/models"
 	"sport-space/internal/adapter/storage/errstore"
-	"sport-space/internal/core/sportspace"
+	"sport-space/pkg/phone"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -28,34 +29,45 @@ func (s *Server) handlerPing(c *gin.Context) {
 //	@Produce		json
 //	@Param			email	body	tRequestOTP	true	"User email"
 //	@Success		200
-//	@Failure		400
-//	@Failure		500
+//	@Failure		400	{object}	tProblem
+//	@Failure		429	{object}	tProblem	"too_many_requests"
+//	@Failure		500	{object}	tProblem
 //	@Router			/auth/otp [post]
 func (s *Server) handlerAuthOTP(c *gin.Context) {
 	unauthorize(c)
 
-	bBody, statusCode := s.readBody(c)
-	if statusCode > 0 {
-		c.Writer.WriteHeader(statusCode)
+	jBody := tRequestOTP{}
+	if !s.bindJSON(c, &jBody) {
 		return
 	}
+	language := jBody.Language
+	if language == "" {
+		language = acceptLanguage(c.GetHeader("Accept-Language"))
+	}
 
-	jBody := tRequestOTP{}
-
-	err := json.Unmarshal(bBody, &jBody)
-	if err != nil {
-		s.log.Debug("failed parse body", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusBadRequest)
+	// лимит по получателю, чтобы с разных IP нельзя было засыпать кодами один адрес
+	target := "email:" + strings.ToLower(strings.TrimSpace(jBody.Email.String()))
+	if jBody.Phone != "" {
+		target = "phone:" + jBody.Phone.Normalize().String()
+	}
+	if !s.takeRateLimit(c, "auth-otp:"+target, s.rateLimits.OTP) {
 		return
 	}
-	if !jBody.Email.IsValid() {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+
+	if jBody.Phone != "" {
+		number := jBody.Phone.Normalize().String()
+		err := s.sport.NewOTPByPhone(c.Request.Context(), number, models.OTPChannel(jBody.Channel), language)
+		if err != nil {
+			s.writeError(c, err)
+			return
+		}
+		c.Writer.WriteHeader(http.StatusOK)
 		return
 	}
-	err = s.sport.NewOTP(c.Request.Context(), jBody.Email.String())
+
+	err := s.sport.NewOTP(c.Request.Context(), jBody.Email.String(), language)
 	if err != nil {
-		s.log.Error("failed send otp", zap.String("email", jBody.Email.String()), zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
@@ -70,44 +82,26 @@ func (s *Server) handlerAuthOTP(c *gin.Context) {
 //	@Produce		json
 //	@Param			email	body		tAuthorization	true	"User email and password"
 //	@Success		200		{object}	tLoginResponse
-//	@Failure		400		{object}	tLoginResponse
-//	@Failure		401		{object}	tLoginResponse
-//	@Failure		500
+//	@Failure		400		{object}	tProblem	"login_invalid"
+//	@Failure		401		{object}	tProblem	"login_failed"
+//	@Failure		429		{object}	tProblem	"too_many_requests"
+//	@Failure		500		{object}	tProblem
 //	@Router			/auth/login [post]
 func (s *Server) handlerLogin(c *gin.Context) {
 	unauthorize(c)
 
-	bBody, statusCode := s.readBody(c)
-	if statusCode > 0 {
-		c.Writer.WriteHeader(statusCode)
-		return
-	}
-
 	jBody := tAuthorization{}
-
-	err := json.Unmarshal(bBody, &jBody)
-	if err != nil {
-		s.log.Debug("failed parse body", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusBadRequest)
+	if !s.bindJSON(c, &jBody) {
 		return
 	}
 
-	user, err := s.login(c, jBody.Email, jBody.OTP)
+	user, err := s.login(c, jBody)
 	if err != nil {
-		if errors.Is(err, sportspace.ErrLoginNotValid) || errors.Is(err, sportspace.ErrPasswordNotValid) {
-			c.JSON(http.StatusBadRequest, tLoginResponse{
-				Error: err.Error(),
-			})
-			return
-		}
-
-		if errors.Is(err, sportspace.ErrPasswordNotEquale) || errors.Is(err, errstore.ErrNotFoundData) {
-			c.JSON(http.StatusUnauthorized, tLoginResponse{
-				Error: err.Error(),
-			})
+		if errors.Is(err, errstore.ErrNotFoundData) {
+			s.problem(c, http.StatusUnauthorized, codeLoginFailed)
 			return
 		}
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, tLoginResponse{
@@ -122,8 +116,8 @@ func (s *Server) handlerLogin(c *gin.Context) {
 //	@Accept			json
 //	@Produce		json
 //	@Success		200
-//	@Failure		400
-//	@Failure		500
+//	@Failure		400	{object}	tProblem
+//	@Failure		500	{object}	tProblem
 //	@Router			/auth/logout [get]
 func (s *Server) handlerLogout(c *gin.Context) {
 	unauthorize(c)
@@ -139,14 +133,14 @@ func (s *Server) handlerLogout(c *gin.Context) {
 //	@Param			page	query		int	false	"page number"
 //	@Param			limit	query		int	false	"limit size"
 //	@Success		200		{object}	tGetTorunamentsResponse
-//	@Failure		400
-//	@Failure		500
+//	@Header			200		{string}	Cache-Control	"public, max-age=<HTTP_PUBLIC_MAX_AGE>"
+//	@Failure		400		{object}	tProblem
+//	@Failure		500		{object}	tProblem
 //	@Router			/tournaments [get]
 func (s *Server) handlerGetAllTournament(c *gin.Context) {
 	tournaments, err := s.sport.GetAllTournaments(c.Request.Context())
 	if err != nil {
-		s.log.Error("failed get all tournaments", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
@@ -154,20 +148,10 @@ func (s *Server) handlerGetAllTournament(c *gin.Context) {
 
 	res := []tTournamentResponse{}
 	for _, t := range (*tournaments)[pg.StartRow:pg.EndRow] {
-		res = append(res, tTournamentResponse{
-			ID:                t.ID,
-			Title:             t.Title,
-			Description:       t.Description,
-			Organization:      t.Organization,
-			OrganizationID:    t.UserID,
-			StartDate:         formatDateTime(t.StartDate),
-			EndDate:           formatDateTime(t.EndDate),
-			RegisterStartDate: formatDateTime(t.RegisterStartDate),
-			RegisterEndDate:   formatDateTime(t.RegisterEndDate),
-			LogoURL:           t.LogoURL,
-		})
+		res = append(res, newTournamentResponse(&t))
 	}
 
+	s.setPublicCache(c)
 	c.JSON(http.StatusOK, tGetTorunamentsResponse{
 		Pagination: pg,
 		Data:       res,
@@ -181,90 +165,166 @@ func (s *Server) handlerGetAllTournament(c *gin.Context) {
 //	@Accept			json
 //	@Produce		json
 //	@Success		200	{object}	tUserResponse
-//	@Failure		401
-//	@Failure		500
+//	@Failure		401	{object}	tProblem
+//	@Failure		500	{object}	tProblem
 //	@Router			/user/profile [get]
 func (s *Server) handlerUser(c *gin.Context) {
 	user, statusCode, err := s.checkUser(c)
 	if err != nil {
-		c.Writer.WriteHeader(statusCode)
+		s.writeStatus(c, statusCode)
+		return
+	}
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+//	@Summary	обновить профиль
+//	@Schemes
+//	@Description	обновить язык писем пользователя (ru, en)
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			profile	body		tUpdUserRequest	true	"profile"
+//	@Success		200		{object}	tUserResponse
+//	@Failure		400		{object}	tProblem
+//	@Failure		401		{object}	tProblem
+//	@Failure		500		{object}	tProblem
+//	@Router			/user/profile [put]
+func (s *Server) handlerUpdUser(c *gin.Context) {
+	userID, err := s.checkAuth(c)
+	if err != nil {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+
+	jBody := tUpdUserRequest{}
+	if !s.bindJSON(c, &jBody) {
+		return
+	}
+
+	user, err := s.sport.UpdUserLanguage(c.Request.Context(), userID, jBody.Language)
+	if err != nil {
+		s.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+//	@Summary	настройки уведомлений
+//	@Schemes
+//	@Description	настройки email уведомлений пользователя
+//	@Tags			user
+//	@Produce		json
+//	@Success		200	{object}	tNotificationSettings
+//	@Failure		401	{object}	tProblem
+//	@Failure		500	{object}	tProblem
+//	@Router			/user/notifications [get]
+func (s *Server) handlerGetNotificationSettings(c *gin.Context) {
+	userID, err := s.checkAuth(c)
+	if err != nil {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+
+	settings, err := s.sport.GetNotificationSettings(c.Request.Context(), userID)
+	if err != nil {
+		s.writeError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, tUserResponse{
-		ID:    user.ID,
-		Email: user.Email,
+
+	c.JSON(http.StatusOK, tNotificationSettings{
+		ApplicationStatus:    settings.ApplicationStatus,
+		ApplicationSubmitted: settings.ApplicationSubmitted,
+		ApplicationCanceled:  settings.ApplicationCanceled,
 	})
 }
 
-//	@Summary	создать турнир
+//	@Summary	обновить настройки уведомлений
 //	@Schemes
-//	@Description	создать турнир
-//	@Tags			user tournament
+//	@Description	обновить настройки email уведомлений пользователя
+//	@Tags			user
 //	@Accept			json
 //	@Produce		json
-//	@Param			tournamet	body		tCreateTournamentRequest	true	"tournament"
-//	@Success		201			{object}	tTournamentResponse
-//	@Failure		400
-//	@Failure		500
-//	@Router			/user/tournaments [post]
-func (s *Server) handlerUserNewTournament(c *gin.Context) {
-	user, statusCode, err := s.checkUser(c)
+//	@Param			settings	body		tNotificationSettings	true	"settings"
+//	@Success		200			{object}	tNotificationSettings
+//	@Failure		400			{object}	tProblem
+//	@Failure		401			{object}	tProblem
+//	@Failure		500			{object}	tProblem
+//	@Router			/user/notifications [put]
+func (s *Server) handlerUpdNotificationSettings(c *gin.Context) {
+	userID, err := s.checkAuth(c)
 	if err != nil {
-		c.Writer.WriteHeader(statusCode)
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
-	bBody, statusCode := s.readBody(c)
-	if statusCode > 0 {
-		c.Writer.WriteHeader(statusCode)
+	jBody := tNotificationSettings{}
+	if !s.bindJSON(c, &jBody) {
+		return
+	}
+
+	settings, err := s.sport.UpdNotificationSettings(c.Request.Context(), &models.NotificationSettings{
+		UserID:               userID,
+		ApplicationStatus:    jBody.ApplicationStatus,
+		ApplicationSubmitted: jBody.ApplicationSubmitted,
+		ApplicationCanceled:  jBody.ApplicationCanceled,
+	})
+	if err != nil {
+		s.writeError(c, err)
 		return
 	}
 
-	jBody := tCreateTournamentRequest{}
+	c.JSON(http.StatusOK, tNotificationSettings{
+		ApplicationStatus:    settings.ApplicationStatus,
+		ApplicationSubmitted: settings.ApplicationSubmitted,
+		ApplicationCanceled:  settings.ApplicationCanceled,
+	})
+}
 
-	err = json.Unmarshal(bBody, &jBody)
+//	@Summary	создать турнир
+//	@Schemes
+//	@Description	создать турнир
+//	@Tags			user tournament
+//	@Accept			json
+//	@Produce		json
+//	@Param			tournamet	body		tTournamentRequest	true	"tournament"
+//	@Success		201			{object}	tTournamentResponse
+//	@Failure		400			{object}	tProblem
+//	@Failure		500			{object}	tProblem
+//	@Router			/user/tournaments [post]
+func (s *Server) handlerUserNewTournament(c *gin.Context) {
+	user, statusCode, err := s.checkUser(c)
 	if err != nil {
-		s.log.Debug("failed parse body", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.writeStatus(c, statusCode)
 		return
 	}
 
-	if !jBody.IsValid() {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+	jBody := tTournamentRequest{}
+	if !s.bindJSON(c, &jBody) {
 		return
 	}
 
 	t := &models.Tournament{
-		UserID:            user.ID,
-		Title:             jBody.Title,
-		Description:       jBody.Description,
-		Organization:      jBody.Organization,
-		StartDate:         jBody.StartDate.DateTime(),
-		EndDate:           jBody.EndDate.DateTime(),
-		RegisterStartDate: jBody.RegisterStartDate.DateTime(),
-		RegisterEndDate:   jBody.RegisterEndDate.DateTime(),
-		LogoURL:           jBody.LogoURL,
+		UserID:               user.ID,
+		Title:                jBody.Title,
+		Description:          jBody.Description,
+		Organization:         jBody.Organization,
+		StartDate:            jBody.StartDate.DateTime(),
+		EndDate:              jBody.EndDate.DateTime(),
+		RegisterStartDate:    jBody.RegisterStartDate.DateTime(),
+		RegisterEndDate:      jBody.RegisterEndDate.DateTime(),
+		RosterFreezeDate:     jBody.RosterFreezeDate.DateTime(),
+		MaxLateSubstitutions: jBody.MaxLateSubstitutions,
+		LogoURL:              jBody.LogoURL,
 	}
 
 	tournament, err := s.sport.NewTournament(c.Request.Context(), t)
 	if err != nil {
-		s.log.Error("filed create tournament", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, tTournamentResponse{
-		ID:                tournament.ID,
-		Title:             tournament.Title,
-		Description:       tournament.Description,
-		Organization:      tournament.Organization,
-		OrganizationID:    tournament.UserID,
-		StartDate:         formatDateTime(tournament.StartDate),
-		EndDate:           formatDateTime(tournament.EndDate),
-		RegisterStartDate: formatDateTime(tournament.RegisterStartDate),
-		RegisterEndDate:   formatDateTime(tournament.RegisterEndDate),
-		LogoURL:           tournament.LogoURL,
-	})
+	c.JSON(http.StatusCreated, newTournamentResponse(tournament))
 }
 
 //	@Summary	турниры пользователя
@@ -277,20 +337,19 @@ func (s *Server) handlerUserNewTournament(c *gin.Context) {
 //	@Param			limit	query		int	false	"limit size"
 //	@Success		200		{object}	tGetTorunamentsResponse
 //	@Failure		204
-//	@Failure		400
-//	@Failure		500
+//	@Failure		400	{object}	tProblem
+//	@Failure		500	{object}	tProblem
 //	@Router			/user/tournaments [get]
 func (s *Server) handlerUserTournaments(c *gin.Context) {
 	user, statusCode, err := s.checkUser(c)
 	if err != nil {
-		c.Writer.WriteHeader(statusCode)
+		s.writeStatus(c, statusCode)
 		return
 	}
 
 	tournaments, err := s.sport.GetTournaments(c.Request.Context(), user)
 	if err != nil {
-		s.log.Error("failed get tournaments by user", zap.Uint("userID", user.ID), zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
@@ -303,18 +362,7 @@ func (s *Server) handlerUserTournaments(c *gin.Context) {
 
 	result := []tTournamentResponse{}
 	for _, t := range (*tournaments)[pg.StartRow:pg.EndRow] {
-		result = append(result, tTournamentResponse{
-			ID:                t.ID,
-			Title:             t.Title,
-			Description:       t.Description,
-			Organization:      t.Organization,
-			OrganizationID:    t.UserID,
-			StartDate:         formatDateTime(t.StartDate),
-			EndDate:           formatDateTime(t.EndDate),
-			RegisterStartDate: formatDateTime(t.RegisterStartDate),
-			RegisterEndDate:   formatDateTime(t.RegisterEndDate),
-			LogoURL:           t.LogoURL,
-		})
+		result = append(result, newTournamentResponse(&t))
 	}
 
 	c.JSON(http.StatusOK, tGetTorunamentsResponse{
@@ -331,8 +379,8 @@ func (s *Server) handlerUserTournaments(c *gin.Context) {
 //	@Produce		json
 //	@Success		200	{object}	tTournamentResponse
 //	@Failure		204
-//	@Failure		400
-//	@Failure		500
+//	@Failure		400	{object}	tProblem
+//	@Failure		500	{object}	tProblem
 //	@Router			/user/tournaments/{tournament_id} [get]
 func (s *Server) handlerUserTournament(c *gin.Context) {
 	// _, statusCode, err := s.checkUser(c)
@@ -343,13 +391,13 @@ func (s *Server) handlerUserTournament(c *gin.Context) {
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.invalidParam(c, "id")
 		return
 	}
 
 	tournament, err := s.sport.GetTournamentByID(c.Request.Context(), uint(id))
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 	if tournament.ID == 0 {
@@ -357,18 +405,8 @@ func (s *Server) handlerUserTournament(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, tTournamentResponse{
-		ID:                tournament.ID,
-		Title:             tournament.Title,
-		Description:       tournament.Description,
-		Organization:      tournament.Organization,
-		OrganizationID:    tournament.UserID,
-		StartDate:         formatDateTime(tournament.StartDate),
-		EndDate:           formatDateTime(tournament.EndDate),
-		RegisterStartDate: formatDateTime(tournament.RegisterStartDate),
-		RegisterEndDate:   formatDateTime(tournament.RegisterEndDate),
-		LogoURL:           tournament.LogoURL,
-	})
+	setETag(c, tournament.Version)
+	c.JSON(http.StatusOK, newTournamentResponse(tournament))
 }
 
 //	@Summary	Обновить турнир
@@ -377,80 +415,66 @@ func (s *Server) handlerUserTournament(c *gin.Context) {
 //	@Tags			user tournament
 //	@Accept			json
 //	@Produce		json
-//	@Param			tournament_id	path	int						true	"tournament id"
-//	@Param			tournamet		body	tUpdTournamentRequest	true	"tournament"
+//	@Param			tournament_id	path	int					true	"tournament id"
+//	@Param			If-Match		header	string				true	"ETag турнира или *"
+//	@Param			tournamet		body	tTournamentRequest	true	"tournament"
 //	@Success		200
 //	@Success		204
-//	@Failure		400
-//	@Failure		500
+//	@Failure		400	{object}	tProblem
+//	@Failure		412	{object}	tProblem
+//	@Failure		428	{object}	tProblem
+//	@Failure		500	{object}	tProblem
 //	@Router			/user/tournaments/{tournament_id} [put]
 func (s *Server) handlerUserUpdTournament(c *gin.Context) {
 	user, statusCode, err := s.checkUser(c)
 	if err != nil {
-		c.Writer.WriteHeader(statusCode)
+		s.writeStatus(c, statusCode)
 		return
 	}
 
 	tournamentID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.invalidParam(c, "id")
 		return
 	}
 
-	bBody, statusCode := s.readBody(c)
+	version, statusCode := ifMatch(c)
 	if statusCode > 0 {
-		c.Writer.WriteHeader(statusCode)
-		return
-	}
-
-	jBody := tUpdTournamentRequest{}
-
-	err = json.Unmarshal(bBody, &jBody)
-	if err != nil {
-		s.log.Debug("failed parse body", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.writeStatus(c, statusCode)
 		return
 	}
 
-	if !jBody.IsValid() {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+	jBody := tTournamentRequest{}
+	if !s.bindJSON(c, &jBody) {
 		return
 	}
 
 	tournament, err := s.sport.UpdTournament(c.Request.Context(), &models.Tournament{
-		ID:                uint(tournamentID),
-		Title:             jBody.Title,
-		Description:       jBody.Description,
-		Organization:      jBody.Organization,
-		StartDate:         jBody.StartDate.DateTime(),
-		EndDate:           jBody.EndDate.DateTime(),
-		RegisterStartDate: jBody.RegisterStartDate.DateTime(),
-		RegisterEndDate:   jBody.RegisterEndDate.DateTime(),
-		LogoURL:           jBody.LogoURL,
-		UserID:            user.ID,
+		ID:                   uint(tournamentID),
+		Title:                jBody.Title,
+		Description:          jBody.Description,
+		Organization:         jBody.Organization,
+		StartDate:            jBody.StartDate.DateTime(),
+		EndDate:              jBody.EndDate.DateTime(),
+		RegisterStartDate:    jBody.RegisterStartDate.DateTime(),
+		RegisterEndDate:      jBody.RegisterEndDate.DateTime(),
+		RosterFreezeDate:     jBody.RosterFreezeDate.DateTime(),
+		MaxLateSubstitutions: jBody.MaxLateSubstitutions,
+		LogoURL:              jBody.LogoURL,
+		UserID:               user.ID,
+		Version:              version,
 	})
 	if err != nil {
 		if errors.Is(err, errstore.ErrNotFoundData) {
 			c.Writer.WriteHeader(http.StatusNoContent)
 			return
 		}
-		s.log.Error("failed update tournament", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	c.JSON(http.StatusOK, tTournamentResponse{
-		ID:                tournament.ID,
-		Title:             tournament.Title,
-		Description:       tournament.Description,
-		Organization:      tournament.Organization,
-		OrganizationID:    tournament.UserID,
-		StartDate:         formatDateTime(tournament.StartDate),
-		EndDate:           formatDateTime(tournament.EndDate),
-		RegisterStartDate: formatDateTime(tournament.RegisterStartDate),
-		RegisterEndDate:   formatDateTime(tournament.RegisterEndDate),
-		LogoURL:           tournament.LogoURL,
-	})
+		s.writeError(c, err)
+		return
+	}
+
+	setETag(c, tournament.Version)
+	c.JSON(http.StatusOK, newTournamentResponse(tournament))
 }
 
 //	@Summary	создать команду
@@ -459,30 +483,23 @@ func (s *Server) handlerUserUpdTournament(c *gin.Context) {
 //	@Tags			user team
 //	@Accept			json
 //	@Produce		json
-//	@Param			tournamet	body		tCreateTeam	true	"team"
-//	@Success		201			{object}	tTeam
-//	@Failure		400
-//	@Failure		500
+//	@Param			tournamet		body		tCreateTeam	true	"team"
+//	@Param			Idempotency-Key	header		string		false	"ключ повтора запроса"
+//	@Success		201				{object}	tTeam
+//	@Failure		400				{object}	tProblem
+//	@Failure		409				{object}	tProblem	"idempotency_in_progress"
+//	@Failure		422				{object}	tProblem	"idempotency_key_reused"
+//	@Failure		500				{object}	tProblem
 //	@Router			/user/teams [post]
 func (s *Server) handlerUserNewTeam(c *gin.Context) {
 	userID, err := s.checkAuth(c)
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusUnauthorized)
-		return
-	}
-
-	bBody, statusCode := s.readBody(c)
-	if statusCode > 0 {
-		c.Writer.WriteHeader(statusCode)
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	jBody := tCreateTeam{}
-
-	err = json.Unmarshal(bBody, &jBody)
-	if err != nil {
-		s.log.Debug("failed parse body", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusBadRequest)
+	if !s.bindJSON(c, &jBody) {
 		return
 	}
 
@@ -493,8 +510,7 @@ func (s *Server) handlerUserNewTeam(c *gin.Context) {
 		LogoURL:  jBody.LogoURL,
 	})
 	if err != nil {
-		s.log.Error("failed create team", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
@@ -515,20 +531,19 @@ func (s *Server) handlerUserNewTeam(c *gin.Context) {
 //	@Param			page	query		int	false	"page number"
 //	@Param			limit	query		int	false	"limit size"
 //	@Success		200		{object}	tGetTeamsResponse
-//	@Failure		400
-//	@Failure		500
+//	@Failure		400		{object}	tProblem
+//	@Failure		500		{object}	tProblem
 //	@Router			/user/teams [get]
 func (s *Server) handlerUserTeams(c *gin.Context) {
 	user, statusCode, err := s.checkUser(c)
 	if err != nil {
-		c.Writer.WriteHeader(statusCode)
+		s.writeStatus(c, statusCode)
 		return
 	}
 
 	teams, err := s.sport.GetTeams(c.Request.Context(), user)
 	if err != nil {
-		s.log.Error("failed get teams: %w", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
@@ -561,19 +576,19 @@ func (s *Server) handlerUserTeams(c *gin.Context) {
 //	@Produce		json
 //	@Success		200	{object}	tGetTeamResponse
 //	@Failure		204
-//	@Failure		400
-//	@Failure		500
+//	@Failure		400	{object}	tProblem
+//	@Failure		500	{object}	tProblem
 //	@Router			/user/teams/{team_id} [get]
 func (s *Server) handlerUserTeam(c *gin.Context) {
 	userID, err := s.checkAuth(c)
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusUnauthorized)
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.invalidParam(c, "id")
 		return
 	}
 
@@ -583,8 +598,7 @@ func (s *Server) handlerUserTeam(c *gin.Context) {
 			c.Writer.WriteHeader(http.StatusNoContent)
 			return
 		}
-		s.log.Error("failed get players from team", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 	if team.UserID != userID {
@@ -604,6 +618,7 @@ func (s *Server) handlerUserTeam(c *gin.Context) {
 		})
 	}
 
+	setETag(c, team.Version)
 	c.JSON(http.StatusOK, tGetTeamResponse{
 		ID:        team.ID,
 		Title:     team.Title,
@@ -618,39 +633,38 @@ func (s *Server) handlerUserTeam(c *gin.Context) {
 //	@Schemes
 //	@Description	обновление команды пользователя
 //	@Tags			user team
-//	@Param			team_id	path	int				true	"team id"
-//	@Param			team	body	tUpdTeamRequest	true	"team"
+//	@Param			team_id		path	int				true	"team id"
+//	@Param			If-Match	header	string			true	"ETag команды или *"
+//	@Param			team		body	tUpdTeamRequest	true	"team"
 //	@Produce		json
 //	@Success		200	{object}	tUpdTeamResponse
 //	@Failure		204
-//	@Failure		400
-//	@Failure		500
+//	@Failure		400	{object}	tProblem
+//	@Failure		412	{object}	tProblem
+//	@Failure		428	{object}	tProblem
+//	@Failure		500	{object}	tProblem
 //	@Router			/user/teams/{team_id} [put]
 func (s *Server) handlerUserUptTeam(c *gin.Context) {
 	userID, err := s.checkAuth(c)
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusUnauthorized)
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	teamID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.invalidParam(c, "id")
 		return
 	}
 
-	bBody, statusCode := s.readBody(c)
+	version, statusCode := ifMatch(c)
 	if statusCode > 0 {
-		c.Writer.WriteHeader(statusCode)
+		s.writeStatus(c, statusCode)
 		return
 	}
 
 	jBody := tUpdTeamRequest{}
-
-	err = json.Unmarshal(bBody, &jBody)
-	if err != nil {
-		s.log.Debug("failed parse body", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusBadRequest)
+	if !s.bindJSON(c, &jBody) {
 		return
 	}
 
@@ -660,8 +674,7 @@ func (s *Server) handlerUserUptTeam(c *gin.Context) {
 			c.Writer.WriteHeader(http.StatusNoContent)
 			return
 		}
-		s.log.Error("failed update team", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 	if team.UserID != userID {
@@ -671,6 +684,7 @@ func (s *Server) handlerUserUptTeam(c *gin.Context) {
 	team.Title = jBody.Title
 	team.LogoURL = jBody.LogoURL
 	team.PhotoURL = jBody.PhotoURL
+	team.Version = version
 
 	team, players, err := s.sport.UpdTeam(c.Request.Context(), team, jBody.Players)
 	if err != nil {
@@ -678,8 +692,7 @@ func (s *Server) handlerUserUptTeam(c *gin.Context) {
 			c.Writer.WriteHeader(http.StatusNoContent)
 			return
 		}
-		s.log.Error("failed update team", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
@@ -695,6 +708,7 @@ func (s *Server) handlerUserUptTeam(c *gin.Context) {
 		})
 	}
 
+	setETag(c, team.Version)
 	c.JSON(http.StatusOK, tUpdTeamResponse{
 		ID:        team.ID,
 		Title:     team.Title,
@@ -712,57 +726,43 @@ func (s *Server) handlerUserUptTeam(c *gin.Context) {
 //	@Param			player	body	tNewPlayerRequest	true	"player"
 //	@Produce		json
 //	@Success		201	{object}	tPlayerResponse
-//	@Failure		400
-//	@Failure		500
+//	@Failure		400	{object}	tProblem
+//	@Failure		500	{object}	tProblem
 //	@Router			/user/players [post]
 func (s *Server) handlerUserNewPlayer(c *gin.Context) {
 	user, statusCode, err := s.checkUser(c)
 	if err != nil {
-		c.Writer.WriteHeader(statusCode)
-		return
-	}
-
-	bBody, statusCode := s.readBody(c)
-	if statusCode > 0 {
-		c.Writer.WriteHeader(statusCode)
+		s.writeStatus(c, statusCode)
 		return
 	}
 
 	jBody := tNewPlayerRequest{}
-
-	err = json.Unmarshal(bBody, &jBody)
-	if err != nil {
-		s.log.Debug("failed parse body", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	if !jBody.IsValid() {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+	if !s.bindJSON(c, &jBody) {
 		return
 	}
 
 	player, err := s.sport.NewPlayer(c.Request.Context(), &models.Player{
-		FirstName:  jBody.FirstName,
-		SecondName: jBody.SecondName,
-		LastName:   jBody.LastName,
-		PhotoURL:   jBody.PhotoURL,
-		UserID:     user.ID,
-		BDay:       jBody.BDay.Date(),
+		FirstName:             jBody.FirstName,
+		SecondName:            jBody.SecondName,
+		LastName:              jBody.LastName,
+		PhotoURL:              jBody.PhotoURL,
+		MedicalCertificateURL: jBody.MedicalCertificateURL,
+		UserID:                user.ID,
+		BDay:                  jBody.BDay.Date(),
 	})
 	if err != nil {
-		s.log.Error("failed create player", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, tPlayerResponse{
-		ID:         player.ID,
-		FirstName:  player.FirstName,
-		SecondName: player.SecondName,
-		LastName:   player.LastName,
-		PhotoURL:   player.PhotoURL,
-		BDay:       formatDate(player.BDay),
+		ID:                    player.ID,
+		FirstName:             player.FirstName,
+		SecondName:            player.SecondName,
+		LastName:              player.LastName,
+		PhotoURL:              player.PhotoURL,
+		MedicalCertificateURL: player.MedicalCertificateURL,
+		BDay:                  formatDate(player.BDay),
 	})
 }
 
@@ -770,76 +770,61 @@ func (s *Server) handlerUserNewPlayer(c *gin.Context) {
 //	@Schemes
 //	@Description	Добавить/Обновить игроков
 //	@Tags			user players
-//	@Param			players	body	[]tNewPlayerBatchRequest	true	"players"
+//	@Param			players			body	[]tNewPlayerBatchRequest	true	"players"
+//	@Param			Idempotency-Key	header	string						false	"ключ повтора запроса"
 //	@Produce		json
 //	@Success		201	{object}	tNewPlayerBatchResponse
-//	@Failure		400
-//	@Failure		409
-//	@Failure		500
+//	@Failure		400	{object}	tProblem
+//	@Failure		409	{object}	tProblem
+//	@Failure		422	{object}	tProblem	"idempotency_key_reused"
+//	@Failure		500	{object}	tProblem
 //	@Router			/user/players/batch [post]
 func (s *Server) handlerUserNewPlayerBatch(c *gin.Context) {
 	userID, err := s.checkAuth(c)
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusUnauthorized)
-		return
-	}
-
-	bBody, statusCode := s.readBody(c)
-	if statusCode > 0 {
-		c.Writer.WriteHeader(statusCode)
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	jBody := []tNewPlayerBatchRequest{}
-
-	err = json.Unmarshal(bBody, &jBody)
-	if err != nil {
-		s.log.Debug("failed parse body", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusBadRequest)
+	if !s.bindJSON(c, &jBody) {
 		return
 	}
 
 	batch := []models.Player{}
 	for _, p := range jBody {
-		if !p.IsValid() {
-			c.Writer.WriteHeader(http.StatusBadRequest)
-			return
-		}
 		batch = append(batch, models.Player{
-			ID:         p.ID,
-			FirstName:  p.FirstName,
-			SecondName: p.SecondName,
-			LastName:   p.LastName,
-			BDay:       p.BDay.DateTime(),
-			UserID:     userID,
-			PhotoURL:   p.PhotoURL,
+			ID:                    p.ID,
+			FirstName:             p.FirstName,
+			SecondName:            p.SecondName,
+			LastName:              p.LastName,
+			BDay:                  p.BDay.DateTime(),
+			UserID:                userID,
+			PhotoURL:              p.PhotoURL,
+			MedicalCertificateURL: p.MedicalCertificateURL,
 		})
 	}
 
 	players, err := s.sport.NewPlayerBatch(c.Request.Context(), &batch)
 	if err != nil {
-		if errors.Is(err, errsport.ErrConflictData) {
-			c.Writer.WriteHeader(http.StatusConflict)
-			return
-		}
 		if errors.Is(err, errsport.ErrNotFoundData) {
-			c.Writer.WriteHeader(http.StatusBadRequest)
+			s.problem(c, http.StatusBadRequest, codeNotFound)
 			return
 		}
-		s.log.Error("failed create players", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
 	res := tNewPlayerBatchResponse{}
 	for _, p := range *players {
 		res.Data = append(res.Data, tPlayerBatchResponse{
-			ID:         p.ID,
-			FirstName:  p.FirstName,
-			SecondName: p.SecondName,
-			LastName:   p.LastName,
-			BDay:       formatDate(p.BDay),
-			PhotoURL:   p.PhotoURL,
+			ID:                    p.ID,
+			FirstName:             p.FirstName,
+			SecondName:            p.SecondName,
+			LastName:              p.LastName,
+			BDay:                  formatDate(p.BDay),
+			PhotoURL:              p.PhotoURL,
+			MedicalCertificateURL: p.MedicalCertificateURL,
 		})
 	}
 
@@ -854,20 +839,19 @@ func (s *Server) handlerUserNewPlayerBatch(c *gin.Context) {
 //	@Param			page	query		int	false	"page number"
 //	@Param			limit	query		int	false	"limit size"
 //	@Success		200		{object}	tGetPlayersResponse
-//	@Failure		400
-//	@Failure		500
+//	@Failure		400		{object}	tProblem
+//	@Failure		500		{object}	tProblem
 //	@Router			/user/players [get]
 func (s *Server) handlerUserPlayers(c *gin.Context) {
 	userID, err := s.checkAuth(c)
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusUnauthorized)
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	players, err := s.sport.GetPlayers(c.Request.Context(), userID)
 	if err != nil {
-		s.log.Error("failed get players", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 	count := 0
@@ -880,12 +864,13 @@ func (s *Server) handlerUserPlayers(c *gin.Context) {
 	if players != nil && pg.TotalRecords > 0 {
 		for _, p := range (*players)[pg.StartRow:pg.EndRow] {
 			res = append(res, tPlayerResponse{
-				ID:         p.ID,
-				FirstName:  p.FirstName,
-				SecondName: p.SecondName,
-				LastName:   p.LastName,
-				PhotoURL:   p.PhotoURL,
-				BDay:       formatDateTime(p.BDay),
+				ID:                    p.ID,
+				FirstName:             p.FirstName,
+				SecondName:            p.SecondName,
+				LastName:              p.LastName,
+				PhotoURL:              p.PhotoURL,
+				MedicalCertificateURL: p.MedicalCertificateURL,
+				BDay:                  formatDateTime(p.BDay),
 			})
 		}
 	}
@@ -896,210 +881,522 @@ func (s *Server) handlerUserPlayers(c *gin.Context) {
 	})
 }
 
+//	@Summary	Игрок
+//	@Schemes
+//	@Description	Игрок пользователя, ETag нужен для обновления
+//	@Tags			user players
+//	@Param			player_id	path	int	true	"player id"
+//	@Produce		json
+//	@Success		200	{object}	tPlayerResponse
+//	@Failure		204
+//	@Failure		400	{object}	tProblem
+//	@Failure		500	{object}	tProblem
+//	@Router			/user/players/{player_id} [get]
+func (s *Server) handlerUserPlayer(c *gin.Context) {
+	userID, err := s.checkAuth(c)
+	if err != nil {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+
+	playerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		s.invalidParam(c, "id")
+		return
+	}
+
+	player, err := s.sport.GetPlayerByID(c.Request.Context(), uint(playerID))
+	if err != nil {
+		if errors.Is(err, errstore.ErrNotFoundData) {
+			c.Writer.WriteHeader(http.StatusNoContent)
+			return
+		}
+		s.writeError(c, err)
+		return
+	}
+	if player.UserID != userID {
+		c.Writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	setETag(c, player.Version)
+	c.JSON(http.StatusOK, tPlayerResponse{
+		ID:                    player.ID,
+		FirstName:             player.FirstName,
+		SecondName:            player.SecondName,
+		LastName:              player.LastName,
+		PhotoURL:              player.PhotoURL,
+		MedicalCertificateURL: player.MedicalCertificateURL,
+		BDay:                  formatDate(player.BDay),
+	})
+}
+
 //	@Summary	обновить игрока
 //	@Schemes
 //	@Description	обновить игрока
 //	@Tags			user players
 //	@Param			player_id	path	int						true	"player id"
+//	@Param			If-Match	header	string					true	"ETag игрока или *"
 //	@Param			id			body	tUpdatePlayerRequest	true	"player"
 //	@Produce		json
 //	@Success		200	{object}	tPlayerResponse
 //	@Failure		204
-//	@Failure		400
-//	@Failure		500
+//	@Failure		400	{object}	tProblem
+//	@Failure		412	{object}	tProblem
+//	@Failure		428	{object}	tProblem
+//	@Failure		500	{object}	tProblem
 //	@Router			/user/players/{player_id} [put]
 func (s *Server) handlerUserUpdatePlayer(c *gin.Context) {
 	userID, err := s.checkAuth(c)
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusUnauthorized)
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	playerID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.invalidParam(c, "id")
 		return
 	}
 
-	bBody, statusCode := s.readBody(c)
+	version, statusCode := ifMatch(c)
 	if statusCode > 0 {
-		c.Writer.WriteHeader(statusCode)
+		s.writeStatus(c, statusCode)
 		return
 	}
 
 	jBody := tUpdatePlayerRequest{}
-
-	err = json.Unmarshal(bBody, &jBody)
-	if err != nil {
-		s.log.Debug("failed parse body", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	if !jBody.IsValid() {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+	if !s.bindJSON(c, &jBody) {
 		return
 	}
 
 	player, err := s.sport.UpdPlayer(c.Request.Context(), &models.Player{
-		ID:         uint(playerID),
-		FirstName:  jBody.FirstName,
-		SecondName: jBody.SecondName,
-		LastName:   jBody.LastName,
-		PhotoURL:   jBody.PhotoURL,
-		UserID:     userID,
-		BDay:       jBody.BDay.Date(),
+		ID:                    uint(playerID),
+		FirstName:             jBody.FirstName,
+		SecondName:            jBody.SecondName,
+		LastName:              jBody.LastName,
+		PhotoURL:              jBody.PhotoURL,
+		MedicalCertificateURL: jBody.MedicalCertificateURL,
+		UserID:                userID,
+		BDay:                  jBody.BDay.Date(),
+		Version:               version,
 	})
 	if err != nil {
 		if errors.Is(err, errstore.ErrNotFoundData) {
 			c.Writer.WriteHeader(http.StatusNoContent)
 			return
 		}
-		s.log.Error("failed update player", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
+	setETag(c, player.Version)
 	c.JSON(http.StatusOK, tPlayerResponse{
-		ID:         player.ID,
-		FirstName:  player.FirstName,
-		SecondName: player.SecondName,
-		LastName:   player.LastName,
-		PhotoURL:   player.PhotoURL,
-		BDay:       formatDate(player.BDay),
+		ID:                    player.ID,
+		FirstName:             player.FirstName,
+		SecondName:            player.SecondName,
+		LastName:              player.LastName,
+		PhotoURL:              player.PhotoURL,
+		MedicalCertificateURL: player.MedicalCertificateURL,
+		BDay:                  formatDate(player.BDay),
 	})
 }
 
-//	@Summary	заявки на турнир
+//	@Summary	Удалить турнир
 //	@Schemes
-//	@Description	заявки на турнир
+//	@Description	Удалить турнир в корзину вместе с заявками, восстановить можно до purgeAt
 //	@Tags			user tournament
 //	@Param			tournament_id	path	int	true	"tournament id"
 //	@Produce		json
-//	@Success		200	{object}	tGetTournamentApplicationsResponse
-//	@Failure		400
-//	@Failure		500
-//	@Router			/user/tournaments/{tournament_id}/applications [get]
-func (s *Server) handlerGetTournamentApplications(c *gin.Context) {
+//	@Success		200	{object}	tTrashItem
+//	@Failure		204	"турнир не найден"
+//	@Failure		400	{object}	tProblem
+//	@Failure		409	{object}	tProblem	"турнир идет и в нем есть принятые заявки"
+//	@Failure		500	{object}	tProblem
+//	@Router			/user/tournaments/{tournament_id} [delete]
+func (s *Server) handlerUserDelTournament(c *gin.Context) {
 	userID, err := s.checkAuth(c)
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusUnauthorized)
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	tournamentID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.invalidParam(c, "id")
 		return
 	}
 
-	tournament, err := s.sport.GetTournamentByID(c.Request.Context(), uint(tournamentID))
+	tournament, err := s.sport.DelTournament(c.Request.Context(), uint(tournamentID), userID)
 	if err != nil {
-		if errors.Is(err, errstore.ErrNotFoundData) {
-			c.Writer.WriteHeader(http.StatusBadRequest)
-			return
-		}
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeDelError(c, err)
 		return
 	}
 
-	if tournament.UserID != userID {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+	c.JSON(http.StatusOK, s.newTrashItem(tournament.ID, tournament.Title, tournament.DeletedAt.Time))
+}
+
+//	@Summary	Восстановить турнир
+//	@Schemes
+//	@Description	Восстановить турнир из корзины вместе с заявками, удаленными с ним
+//	@Tags			user tournament
+//	@Param			tournament_id	path	int	true	"tournament id"
+//	@Produce		json
+//	@Success		200	{object}	tTournamentResponse
+//	@Failure		204	"турнира нет в корзине или срок хранения истек"
+//	@Failure		400	{object}	tProblem
+//	@Failure		500	{object}	tProblem
+//	@Router			/user/tournaments/{tournament_id}/restore [post]
+func (s *Server) handlerUserRestoreTournament(c *gin.Context) {
+	userID, err := s.checkAuth(c)
+	if err != nil {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
-	data := []tTournamentApplication{}
-	for _, a := range tournament.Applications {
-		team, err := s.sport.GetTeamByID(c.Request.Context(), a.TeamID)
-		if err != nil {
-			if errors.Is(err, errstore.ErrNotFoundData) {
-				c.Writer.WriteHeader(http.StatusBadRequest)
-				return
-			}
-			c.Writer.WriteHeader(http.StatusInternalServerError)
+	tournamentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		s.invalidParam(c, "id")
+		return
+	}
+
+	tournament, err := s.sport.RestoreTournament(c.Request.Context(), uint(tournamentID), userID)
+	if err != nil {
+		if errors.Is(err, errstore.ErrNotFoundData) {
+			c.Writer.WriteHeader(http.StatusNoContent)
 			return
 		}
-		if a.Status == models.InProgress || a.Status == models.Accepted || a.Status == models.Rejected {
-			data = append(data, tTournamentApplication{
-				ID:          a.ID,
-				TeamID:      a.TeamID,
-				TeamTitle:   team.Title,
-				TeamLogoURL: team.LogoURL,
-				Status:      string(a.Status),
-			})
-		}
+		s.writeError(c, err)
+		return
 	}
 
-	c.JSON(http.StatusOK, tGetTournamentApplicationsResponse{Data: data})
+	setETag(c, tournament.Version)
+	c.JSON(http.StatusOK, newTournamentResponse(tournament))
 }
 
-//	@Summary	заявка турнира
+//	@Summary	Удалить команду
 //	@Schemes
-//	@Description	заявка турнира
-//	@Tags			user tournament
-//	@Param			tournament_id	path	int	true	"tournament id"
-//	@Param			application_id	path	int	true	"application id"
+//	@Description	Удалить команду в корзину вместе с заявками, восстановить можно до purgeAt
+//	@Tags			user team
+//	@Param			team_id	path	int	true	"team id"
 //	@Produce		json
-//	@Success		200	{object}	tGetTorunamentApplicationResponse
-//	@Failure		400
-//	@Failure		500
-//	@Router			/user/tournaments/{tournament_id}/applications/{application_id} [get]
-func (s *Server) handlerGetTournamentApplication(c *gin.Context) {
+//	@Success		200	{object}	tTrashItem
+//	@Failure		204	"команда не найдена"
+//	@Failure		400	{object}	tProblem
+//	@Failure		409	{object}	tProblem	"у команды принятая заявка в идущий турнир"
+//	@Failure		500	{object}	tProblem
+//	@Router			/user/teams/{team_id} [delete]
+func (s *Server) handlerUserDelTeam(c *gin.Context) {
 	userID, err := s.checkAuth(c)
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusUnauthorized)
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
-	tournamentID, err := strconv.Atoi(c.Param("id"))
+	teamID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.invalidParam(c, "id")
 		return
 	}
 
-	applicationID, err := strconv.Atoi(c.Param("aid"))
+	team, err := s.sport.DelTeam(c.Request.Context(), uint(teamID), userID)
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.writeDelError(c, err)
 		return
 	}
 
-	tournament, err := s.sport.GetTournamentByID(c.Request.Context(), uint(tournamentID))
+	c.JSON(http.StatusOK, s.newTrashItem(team.ID, team.Title, team.DeletedAt.Time))
+}
+
+//	@Summary	Восстановить команду
+//	@Schemes
+//	@Description	Восстановить команду из корзины вместе с заявками, удаленными с ней
+//	@Tags			user team
+//	@Param			team_id	path	int	true	"team id"
+//	@Produce		json
+//	@Success		200	{object}	tTeam
+//	@Failure		204	"команды нет в корзине или срок хранения истек"
+//	@Failure		400	{object}	tProblem
+//	@Failure		500	{object}	tProblem
+//	@Router			/user/teams/{team_id}/restore [post]
+func (s *Server) handlerUserRestoreTeam(c *gin.Context) {
+	userID, err := s.checkAuth(c)
 	if err != nil {
-		if errors.Is(err, errstore.ErrNotFoundData) {
-			c.Writer.WriteHeader(http.StatusBadRequest)
-			return
-		}
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
-	if tournament.UserID != userID {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+	teamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		s.invalidParam(c, "id")
 		return
 	}
 
-	application, err := s.sport.GetApplicationByID(c.Request.Context(), uint(applicationID))
+	team, err := s.sport.RestoreTeam(c.Request.Context(), uint(teamID), userID)
 	if err != nil {
 		if errors.Is(err, errstore.ErrNotFoundData) {
-			c.Writer.WriteHeader(http.StatusBadRequest)
+			c.Writer.WriteHeader(http.StatusNoContent)
 			return
 		}
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
-	if application.TournamentID != tournament.ID {
-		c.Writer.WriteHeader(http.StatusBadRequest)
-		return
-	}
+	setETag(c, team.Version)
+	c.JSON(http.StatusOK, tTeam{
+		ID:        team.ID,
+		Title:     team.Title,
+		LogoURL:   team.LogoURL,
+		PhotoURL:  team.PhotoURL,
+		CreatedAt: formatDateTime(&team.CreatedAt),
+	})
+}
+
+//	@Summary	Удалить игрока
+//	@Schemes
+//	@Description	Удалить игрока в корзину, из составов он пропадает до восстановления
+//	@Tags			user players
+//	@Param			player_id	path	int	true	"player id"
+//	@Produce		json
+//	@Success		200	{object}	tTrashItem
+//	@Failure		204	"игрок не найден"
+//	@Failure		400	{object}	tProblem
+//	@Failure		409	{object}	tProblem	"игрок в принятой заявке идущего турнира"
+//	@Failure		500	{object}	tProblem
+//	@Router			/user/players/{player_id} [delete]
+func (s *Server) handlerUserDelPlayer(c *gin.Context) {
+	userID, err := s.checkAuth(c)
+	if err != nil {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+
+	playerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		s.invalidParam(c, "id")
+		return
+	}
+
+	player, err := s.sport.DelPlayer(c.Request.Context(), uint(playerID), userID)
+	if err != nil {
+		s.writeDelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s.newTrashItem(player.ID, playerTitle(player), player.DeletedAt.Time))
+}
+
+//	@Summary	Восстановить игрока
+//	@Schemes
+//	@Description	Восстановить игрока из корзины
+//	@Tags			user players
+//	@Param			player_id	path	int	true	"player id"
+//	@Produce		json
+//	@Success		200	{object}	tPlayerResponse
+//	@Failure		204	"игрока нет в корзине или срок хранения истек"
+//	@Failure		400	{object}	tProblem
+//	@Failure		500	{object}	tProblem
+//	@Router			/user/players/{player_id}/restore [post]
+func (s *Server) handlerUserRestorePlayer(c *gin.Context) {
+	userID, err := s.checkAuth(c)
+	if err != nil {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+
+	playerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		s.invalidParam(c, "id")
+		return
+	}
+
+	player, err := s.sport.RestorePlayer(c.Request.Context(), uint(playerID), userID)
+	if err != nil {
+		if errors.Is(err, errstore.ErrNotFoundData) {
+			c.Writer.WriteHeader(http.StatusNoContent)
+			return
+		}
+		s.writeError(c, err)
+		return
+	}
+
+	setETag(c, player.Version)
+	c.JSON(http.StatusOK, tPlayerResponse{
+		ID:                    player.ID,
+		FirstName:             player.FirstName,
+		SecondName:            player.SecondName,
+		LastName:              player.LastName,
+		PhotoURL:              player.PhotoURL,
+		MedicalCertificateURL: player.MedicalCertificateURL,
+		BDay:                  formatDate(player.BDay),
+	})
+}
+
+//	@Summary	Корзина
+//	@Schemes
+//	@Description	Удаленные турниры, команды и игроки пользователя, которые еще можно восстановить
+//	@Tags			user trash
+//	@Produce		json
+//	@Success		200	{object}	tTrashResponse
+//	@Failure		500	{object}	tProblem
+//	@Router			/user/trash [get]
+func (s *Server) handlerUserTrash(c *gin.Context) {
+	userID, err := s.checkAuth(c)
+	if err != nil {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+
+	trash, err := s.sport.GetTrash(c.Request.Context(), userID)
+	if err != nil {
+		s.writeError(c, err)
+		return
+	}
+
+	res := tTrashResponse{Tournaments: []tTrashItem{}, Teams: []tTrashItem{}, Players: []tTrashItem{}}
+	for _, t := range *trash.Tournaments {
+		res.Tournaments = append(res.Tournaments, s.newTrashItem(t.ID, t.Title, t.DeletedAt.Time))
+	}
+	for _, t := range *trash.Teams {
+		res.Teams = append(res.Teams, s.newTrashItem(t.ID, t.Title, t.DeletedAt.Time))
+	}
+	for _, p := range *trash.Players {
+		res.Players = append(res.Players, s.newTrashItem(p.ID, playerTitle(&p), p.DeletedAt.Time))
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+//	@Summary	заявки на турнир
+//	@Schemes
+//	@Description	заявки на турнир
+//	@Tags			user tournament
+//	@Param			tournament_id	path	int	true	"tournament id"
+//	@Produce		json
+//	@Success		200	{object}	tGetTournamentApplicationsResponse
+//	@Failure		400	{object}	tProblem
+//	@Failure		500	{object}	tProblem
+//	@Router			/user/tournaments/{tournament_id}/applications [get]
+func (s *Server) handlerGetTournamentApplications(c *gin.Context) {
+	userID, err := s.checkAuth(c)
+	if err != nil {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+
+	tournamentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		s.invalidParam(c, "id")
+		return
+	}
+
+	tournament, err := s.sport.GetTournamentByID(c.Request.Context(), uint(tournamentID))
+	if err != nil {
+		if errors.Is(err, errstore.ErrNotFoundData) {
+			s.problem(c, http.StatusBadRequest, codeNotFound)
+			return
+		}
+		s.writeError(c, err)
+		return
+	}
+
+	if tournament.UserID != userID {
+		s.problem(c, http.StatusBadRequest, codeNotFound)
+		return
+	}
+
+	data := []tTournamentApplication{}
+	for _, a := range tournament.Applications {
+		team, err := s.sport.GetTeamByID(c.Request.Context(), a.TeamID)
+		if err != nil {
+			if errors.Is(err, errstore.ErrNotFoundData) {
+				s.problem(c, http.StatusBadRequest, codeNotFound)
+				return
+			}
+			s.writeError(c, err)
+			return
+		}
+		if a.Status == models.InProgress || a.Status == models.Accepted || a.Status == models.Rejected {
+			data = append(data, tTournamentApplication{
+				ID:          a.ID,
+				TeamID:      a.TeamID,
+				TeamTitle:   team.Title,
+				TeamLogoURL: team.LogoURL,
+				Status:      string(a.Status),
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, tGetTournamentApplicationsResponse{Data: data})
+}
+
+//	@Summary	заявка турнира
+//	@Schemes
+//	@Description	заявка турнира
+//	@Tags			user tournament
+//	@Param			tournament_id	path	int	true	"tournament id"
+//	@Param			application_id	path	int	true	"application id"
+//	@Produce		json
+//	@Success		200	{object}	tGetTorunamentApplicationResponse
+//	@Failure		400	{object}	tProblem
+//	@Failure		500	{object}	tProblem
+//	@Router			/user/tournaments/{tournament_id}/applications/{application_id} [get]
+func (s *Server) handlerGetTournamentApplication(c *gin.Context) {
+	userID, err := s.checkAuth(c)
+	if err != nil {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+
+	tournamentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		s.invalidParam(c, "id")
+		return
+	}
+
+	applicationID, err := strconv.Atoi(c.Param("aid"))
+	if err != nil {
+		s.invalidParam(c, "aid")
+		return
+	}
+
+	tournament, err := s.sport.GetTournamentByID(c.Request.Context(), uint(tournamentID))
+	if err != nil {
+		if errors.Is(err, errstore.ErrNotFoundData) {
+			s.problem(c, http.StatusBadRequest, codeNotFound)
+			return
+		}
+		s.writeError(c, err)
+		return
+	}
+
+	if tournament.UserID != userID {
+		s.problem(c, http.StatusBadRequest, codeNotFound)
+		return
+	}
+
+	application, err := s.sport.GetApplicationByID(c.Request.Context(), uint(applicationID))
+	if err != nil {
+		if errors.Is(err, errstore.ErrNotFoundData) {
+			s.problem(c, http.StatusBadRequest, codeNotFound)
+			return
+		}
+		s.writeError(c, err)
+		return
+	}
+
+	if application.TournamentID != tournament.ID {
+		s.problem(c, http.StatusBadRequest, codeNotFound)
+		return
+	}
 
 	team, err := s.sport.GetTeamByID(c.Request.Context(), application.TeamID)
 	if err != nil {
 		if errors.Is(err, errstore.ErrNotFoundData) {
-			c.Writer.WriteHeader(http.StatusBadRequest)
+			s.problem(c, http.StatusBadRequest, codeNotFound)
 			return
 		}
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
@@ -1114,6 +1411,7 @@ func (s *Server) handlerGetTournamentApplication(c *gin.Context) {
 		})
 	}
 
+	setETag(c, application.Version)
 	c.JSON(http.StatusOK, tGetTorunamentApplicationResponse{
 		ID:        application.ID,
 		TeamID:    application.TeamID,
@@ -1129,77 +1427,73 @@ func (s *Server) handlerGetTournamentApplication(c *gin.Context) {
 //	@Tags			user tournament
 //	@Param			tournament_id	path	int									true	"tournament id"
 //	@Param			application_id	path	int									true	"application id"
+//	@Param			If-Match		header	string								true	"ETag заявки или *"
 //	@param			application		body	tUpdTournamentApplicationRequest	true	"application"
 //	@Produce		json
 //	@Success		200	{object}	tApplication
-//	@Failure		400
-//	@Failure		500
+//	@Failure		400	{object}	tProblem
+//	@Failure		412	{object}	tProblem
+//	@Failure		428	{object}	tProblem
+//	@Failure		500	{object}	tProblem
 //	@Router			/user/tournaments/{tournament_id}/applications/{application_id} [put]
 func (s *Server) handlerUpdTournamentApplication(c *gin.Context) {
 	userID, err := s.checkAuth(c)
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusUnauthorized)
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	tournamentID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.invalidParam(c, "id")
 		return
 	}
 
 	applicationID, err := strconv.Atoi(c.Param("aid"))
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.invalidParam(c, "aid")
 		return
 	}
 
-	bBody, statusCode := s.readBody(c)
+	version, statusCode := ifMatch(c)
 	if statusCode > 0 {
-		c.Writer.WriteHeader(statusCode)
+		s.writeStatus(c, statusCode)
 		return
 	}
 
 	jBody := tUpdTournamentApplicationRequest{}
-
-	err = json.Unmarshal(bBody, &jBody)
-	if err != nil {
-		s.log.Debug("failed parse body", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusBadRequest)
+	if !s.bindJSON(c, &jBody) {
 		return
 	}
 
 	var status models.ApplicationStatus
 	var ok bool
 	if status, ok = applicationTournamentMapStatus[jBody.Status]; !ok {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.invalid(c, tFieldError{Field: "status", Code: fieldInvalid})
 		return
 	}
 
-	application, err := s.sport.UpdApplicationTournament(c.Request.Context(), uint(applicationID), status, uint(tournamentID), userID)
+	application, err := s.sport.UpdApplicationTournament(c.Request.Context(), uint(applicationID), version, status, uint(tournamentID), userID)
 	if err != nil {
 		if errors.Is(err, errstore.ErrNotFoundData) {
-			c.Writer.WriteHeader(http.StatusBadRequest)
-			return
-		}
-		if errors.Is(err, errstore.ErrForbidden) {
-			c.Writer.WriteHeader(http.StatusBadRequest)
+			s.problem(c, http.StatusBadRequest, codeNotFound)
 			return
 		}
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
 	team, err := s.sport.GetTeamByID(c.Request.Context(), application.TeamID)
 	if err != nil {
 		if errors.Is(err, errstore.ErrNotFoundData) {
-			c.Writer.WriteHeader(http.StatusBadRequest)
+			s.problem(c, http.StatusBadRequest, codeNotFound)
 			return
 		}
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
+	setETag(c, application.Version)
 	c.JSON(http.StatusOK, tUpdTournamentApplicationResponse{
 		ID:        application.ID,
 		TeamID:    application.TeamID,
@@ -1212,54 +1506,41 @@ func (s *Server) handlerUpdTournamentApplication(c *gin.Context) {
 //	@Schemes
 //	@Description	подать заявку
 //	@Tags			user team
-//	@Param			team_id		path	int						true	"team id"
-//	@Param			application	body	tNewApplicationRequest	true	"application"
+//	@Param			team_id			path	int						true	"team id"
+//	@Param			application		body	tNewApplicationRequest	true	"application"
+//	@Param			Idempotency-Key	header	string					false	"ключ повтора запроса"
 //	@Produce		json
 //	@Success		201	{object}	tNewApplicationResponse	"заявка создана"
-//	@Failure		400	"не корректный запрос"
-//	@Failure		409	"заявка	уже	была создана ранее"
-//	@Failure		500
+//	@Failure		400	{object}	tProblem				"не корректный запрос"
+//	@Failure		409	{object}	tProblem				"заявка уже была создана ранее или игроки заявлены в другой команде"
+//	@Failure		422	{object}	tProblem				"idempotency_key_reused"
+//	@Failure		500	{object}	tProblem
 //	@Router			/user/teams/{team_id}/applications [post]
 func (s *Server) handlerNewTeamApplication(c *gin.Context) {
 	userID, err := s.checkAuth(c)
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusUnauthorized)
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	teamID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	bBody, statusCode := s.readBody(c)
-	if statusCode > 0 {
-		c.Writer.WriteHeader(statusCode)
+		s.invalidParam(c, "id")
 		return
 	}
 
 	jBody := tNewApplicationRequest{}
-
-	err = json.Unmarshal(bBody, &jBody)
-	if err != nil {
-		s.log.Debug("failed parse body", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusBadRequest)
+	if !s.bindJSON(c, &jBody) {
 		return
 	}
 
-	application, players, err := s.sport.NewApplicationTeam(c.Request.Context(), &jBody.PlayerIDs, jBody.TournamentID, uint(teamID), userID)
+	application, players, warnings, err := s.sport.NewApplicationTeam(c.Request.Context(), &jBody.PlayerIDs, jBody.TournamentID, uint(teamID), userID)
 	if err != nil {
 		if errors.Is(err, errstore.ErrNotFoundData) {
 			c.Writer.WriteHeader(http.StatusNoContent)
 			return
 		}
-		if errors.Is(err, errstore.ErrConflictData) {
-			c.Writer.WriteHeader(http.StatusConflict)
-			return
-		}
-		s.log.Error("failed create application", zap.Int("team_id", teamID), zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
@@ -1276,8 +1557,7 @@ func (s *Server) handlerNewTeamApplication(c *gin.Context) {
 
 	tournament, err := s.sport.GetTournamentByID(c.Request.Context(), application.TournamentID)
 	if err != nil {
-		s.log.Error("failed get tournament", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
@@ -1287,6 +1567,7 @@ func (s *Server) handlerNewTeamApplication(c *gin.Context) {
 		TournamentTitle: tournament.Title,
 		Players:         resPlayers,
 		Status:          string(application.Status),
+		Warnings:        newRosterConflicts(warnings),
 	})
 }
 
@@ -1296,50 +1577,50 @@ func (s *Server) handlerNewTeamApplication(c *gin.Context) {
 //	@Tags			user team
 //	@Param			team_id			path	int								true	"team id"
 //	@Param			application_id	path	int								true	"application	id"
+//	@Param			If-Match		header	string							true	"ETag заявки или *"
 //	@Param			application		body	tUpdApplicationStatusRequest	true	"application status"
 //	@Produce		json
 //	@Success		200	{object}	tUpdApplicationResponse
 //	@Failure		204	"заявка не найдена"
-//	@Failure		400	"не найден или не корректный запрос"
-//	@Failure		403	"не может изменить"
-//	@Failure		500
+//	@Failure		400	{object}	tProblem	"не найден или не корректный запрос"
+//	@Failure		403	{object}	tProblem	"не может изменить"
+//	@Failure		409	{object}	tProblem	"игроки заявлены в другой команде"
+//	@Failure		412	{object}	tProblem	"заявка изменена после получения ETag"
+//	@Failure		428	{object}	tProblem	"нет заголовка If-Match"
+//	@Failure		500	{object}	tProblem
 //	@Router			/user/teams/{team_id}/applications/{application_id} [put]
 func (s *Server) handlerUpdStatusTeamApplication(c *gin.Context) {
 	userID, err := s.checkAuth(c)
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusUnauthorized)
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	teamID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.invalidParam(c, "id")
 		return
 	}
 
 	applicationID, err := strconv.Atoi(c.Param("aid"))
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.invalidParam(c, "aid")
 		return
 	}
 
-	bBody, statusCode := s.readBody(c)
+	version, statusCode := ifMatch(c)
 	if statusCode > 0 {
-		c.Writer.WriteHeader(statusCode)
+		s.writeStatus(c, statusCode)
 		return
 	}
 
 	jBody := tUpdApplicationStatusRequest{}
-
-	err = json.Unmarshal(bBody, &jBody)
-	if err != nil {
-		s.log.Debug("failed parse body", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusBadRequest)
+	if !s.bindJSON(c, &jBody) {
 		return
 	}
 
 	if jBody.Status == nil && jBody.Players == nil {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.invalid(c, tFieldError{Field: "status", Code: fieldRequired}, tFieldError{Field: "players", Code: fieldRequired})
 		return
 	}
 
@@ -1349,23 +1630,18 @@ func (s *Server) handlerUpdStatusTeamApplication(c *gin.Context) {
 		status, ok = applicationMapStatus[*jBody.Status]
 		if !ok {
 			s.log.Debug("failed parse status", zap.Error(err))
-			c.Writer.WriteHeader(http.StatusBadRequest)
+			s.invalid(c, tFieldError{Field: "status", Code: fieldInvalid})
 			return
 		}
 	}
 
-	application, players, err := s.sport.UpdApplicationTeam(c.Request.Context(), uint(applicationID), jBody.Players, status, uint(teamID), userID)
+	application, players, warnings, err := s.sport.UpdApplicationTeam(c.Request.Context(), uint(applicationID), version, jBody.Players, status, uint(teamID), userID)
 	if err != nil {
-		if errors.Is(err, errstore.ErrForbidden) {
-			c.Writer.WriteHeader(http.StatusForbidden)
-			return
-		}
 		if errors.Is(err, errstore.ErrNotFoundData) {
 			c.Writer.WriteHeader(http.StatusNoContent)
 			return
 		}
-		s.log.Error("failed update application", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
@@ -1381,17 +1657,18 @@ func (s *Server) handlerUpdStatusTeamApplication(c *gin.Context) {
 	}
 	tournament, err := s.sport.GetTournamentByID(c.Request.Context(), application.TournamentID)
 	if err != nil {
-		s.log.Error("failed get tournament", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
+	setETag(c, application.Version)
 	c.JSON(http.StatusOK, tUpdApplicationResponse{
 		ID:              application.ID,
 		TournamentID:    application.TournamentID,
 		TournamentTitle: tournament.Title,
 		Players:         resPlayers,
 		Status:          string(application.Status),
+		Warnings:        newRosterConflicts(warnings),
 	})
 }
 
@@ -1402,32 +1679,34 @@ func (s *Server) handlerUpdStatusTeamApplication(c *gin.Context) {
 //	@Param			team_id	path	int	true	"team id"
 //	@Produce		json
 //	@Success		200	{object}	tGetApplicationsTeamResponse
-//	@Failure		400	"команда не найдена"
-//	@Failure		500
+//	@Failure		400	{object}	tProblem	"команда не найдена"
+//	@Failure		500	{object}	tProblem
 //	@Router			/user/teams/{team_id}/applications [get]
 func (s *Server) handlerGetTeamApplications(c *gin.Context) {
 	userID, err := s.checkAuth(c)
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusUnauthorized)
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	teamID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.invalidParam(c, "id")
 		return
 	}
 
 	team, err := s.sport.GetTeamByID(c.Request.Context(), uint(teamID))
 	if err != nil {
 		if errors.Is(err, errstore.ErrNotFoundData) {
-			c.Writer.WriteHeader(http.StatusBadRequest)
+			s.problem(c, http.StatusBadRequest, codeNotFound)
 			return
 		}
+		s.writeError(c, err)
+		return
 	}
 
 	if team.UserID != userID {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.problem(c, http.StatusBadRequest, codeNotFound)
 		return
 	}
 
@@ -1437,8 +1716,7 @@ func (s *Server) handlerGetTeamApplications(c *gin.Context) {
 			c.JSON(http.StatusOK, tGetApplicationsTeamResponse{Data: []tApplication{}})
 			return
 		}
-		s.log.Error("failed get applications by team", zap.Uint("team_id", team.ID), zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
@@ -1447,10 +1725,10 @@ func (s *Server) handlerGetTeamApplications(c *gin.Context) {
 		tournament, err := s.sport.GetTournamentByID(c.Request.Context(), a.TournamentID)
 		if err != nil {
 			if errors.Is(err, errstore.ErrNotFoundData) {
-				c.Writer.WriteHeader(http.StatusBadRequest)
+				s.problem(c, http.StatusBadRequest, codeNotFound)
 				return
 			}
-			c.Writer.WriteHeader(http.StatusInternalServerError)
+			s.writeError(c, err)
 			return
 		}
 		data = append(data, tApplication{
@@ -1473,53 +1751,55 @@ func (s *Server) handlerGetTeamApplications(c *gin.Context) {
 //	@Param			application_id	path	int	true	"application id"
 //	@Produce		json
 //	@Success		200	{object}	tGetApplicationResponse
-//	@Failure		400	"не корректный запрос"
-//	@Failure		500
+//	@Failure		400	{object}	tProblem	"не корректный запрос"
+//	@Failure		500	{object}	tProblem
 //	@Router			/user/teams/{team_id}/applications/{application_id} [get]
 func (s *Server) handlerGetApplication(c *gin.Context) {
 	userID, err := s.checkAuth(c)
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusUnauthorized)
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	teamID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.invalidParam(c, "id")
 		return
 	}
 
 	team, err := s.sport.GetTeamByID(c.Request.Context(), uint(teamID))
 	if err != nil {
 		if errors.Is(err, errstore.ErrNotFoundData) {
-			c.Writer.WriteHeader(http.StatusBadRequest)
+			s.problem(c, http.StatusBadRequest, codeNotFound)
 			return
 		}
+		s.writeError(c, err)
+		return
 	}
 
 	if team.UserID != userID {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.problem(c, http.StatusBadRequest, codeNotFound)
 		return
 	}
 
 	applicationID, err := strconv.Atoi(c.Param("aid"))
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.invalidParam(c, "aid")
 		return
 	}
 
 	application, err := s.sport.GetApplicationByID(c.Request.Context(), uint(applicationID))
 	if err != nil {
 		if errors.Is(err, errstore.ErrNotFoundData) {
-			c.Writer.WriteHeader(http.StatusBadRequest)
+			s.problem(c, http.StatusBadRequest, codeNotFound)
 			return
 		}
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
 	if application.TeamID != team.ID {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		s.problem(c, http.StatusBadRequest, codeNotFound)
 		return
 	}
 
@@ -1537,13 +1817,14 @@ func (s *Server) handlerGetApplication(c *gin.Context) {
 	tournament, err := s.sport.GetTournamentByID(c.Request.Context(), application.TournamentID)
 	if err != nil {
 		if errors.Is(err, errstore.ErrNotFoundData) {
-			c.Writer.WriteHeader(http.StatusBadRequest)
+			s.problem(c, http.StatusBadRequest, codeNotFound)
 			return
 		}
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
 		return
 	}
 
+	setETag(c, application.Version)
 	c.JSON(http.StatusOK, tGetApplicationResponse{
 		ID:              application.ID,
 		TournamentID:    application.TournamentID,
@@ -1553,50 +1834,697 @@ func (s *Server) handlerGetApplication(c *gin.Context) {
 	})
 }
 
-//	@Summary	загрузка файла
+//	@Summary	запрос на замену игрока
 //	@Schemes
-//	@Description	загрузка файла
-//	@Tags			user
-//	@Accept			json
+//	@Description	запрос на замену игрока в принятой заявке после заморозки состава
+//	@Tags			user team
+//	@Param			team_id			path	int						true	"team id"
+//	@Param			application_id	path	int						true	"application id"
+//	@Param			change			body	tNewRosterChangeRequest	true	"roster change"
 //	@Produce		json
-//	@Param			file	formData	file	true	"файл"
-//	@Success		201		{object}	tHandlerUploadResponse
-//	@Failure		400
-//	@Failure		500
-//	@Router			/user/upload [post]
-func (s *Server) handlerUpload(c *gin.Context) {
+//	@Success		201	{object}	tRosterChange
+//	@Failure		204	"заявка не найдена"
+//	@Failure		400	{object}	tProblem	"не корректный запрос"
+//	@Failure		403	{object}	tProblem	"заявка не принята или турнир завершен"
+//	@Failure		409	{object}	tProblem	"состав не заморожен, лимит замен исчерпан или игрок уже заявлен"
+//	@Failure		500	{object}	tProblem
+//	@Router			/user/teams/{team_id}/applications/{application_id}/changes [post]
+func (s *Server) handlerNewRosterChange(c *gin.Context) {
 	userID, err := s.checkAuth(c)
 	if err != nil {
-		c.Writer.WriteHeader(http.StatusUnauthorized)
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
-	logoFile, err := c.FormFile("file")
-	if err != nil && !errors.Is(err, http.ErrMissingFile) {
-		if errors.Is(err, http.ErrMissingBoundary) {
-			c.Writer.WriteHeader(http.StatusBadRequest)
+	teamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		s.invalidParam(c, "id")
+		return
+	}
+
+	applicationID, err := strconv.Atoi(c.Param("aid"))
+	if err != nil {
+		s.invalidParam(c, "aid")
+		return
+	}
+
+	jBody := tNewRosterChangeRequest{}
+	if !s.bindJSON(c, &jBody) {
+		return
+	}
+
+	change, warnings, err := s.sport.NewRosterChange(c.Request.Context(), &models.RosterChange{
+		ApplicationID: uint(applicationID),
+		PlayerOutID:   jBody.PlayerOutID,
+		PlayerInID:    jBody.PlayerInID,
+		Reason:        jBody.Reason,
+	}, uint(teamID), userID)
+	if err != nil {
+		if errors.Is(err, errstore.ErrNotFoundData) {
+			c.Writer.WriteHeader(http.StatusNoContent)
 			return
 		}
-		s.log.Error("failed get file", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.writeError(c, err)
+		return
+	}
+
+	res := newRosterChangeResponse(change)
+	res.Warnings = newRosterConflicts(warnings)
+	c.JSON(http.StatusCreated, res)
+}
+
+//	@Summary	запросы на замену игроков заявки команды
+//	@Schemes
+//	@Description	запросы на замену игроков заявки команды
+//	@Tags			user team
+//	@Param			team_id			path	int	true	"team id"
+//	@Param			application_id	path	int	true	"application id"
+//	@Produce		json
+//	@Success		200	{object}	tGetRosterChangesResponse
+//	@Failure		400	{object}	tProblem	"не корректный запрос"
+//	@Failure		500	{object}	tProblem
+//	@Router			/user/teams/{team_id}/applications/{application_id}/changes [get]
+func (s *Server) handlerGetTeamRosterChanges(c *gin.Context) {
+	userID, err := s.checkAuth(c)
+	if err != nil {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
-	if !s.isValidImgExtension(logoFile) {
-		c.Writer.WriteHeader(http.StatusBadRequest)
+	teamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		s.invalidParam(c, "id")
 		return
 	}
 
-	dst := fmt.Sprintf("%v/%s", userID, s.genUploadName(logoFile.Filename))
-	err = s.saveFile(logoFile, dst)
+	applicationID, err := strconv.Atoi(c.Param("aid"))
 	if err != nil {
-		s.log.Error("failed upload file", zap.Error(err))
-		c.Writer.WriteHeader(http.StatusInternalServerError)
+		s.invalidParam(c, "aid")
 		return
 	}
 
-	c.JSON(http.StatusCreated, tHandlerUploadResponse{
-		URL:      s.getFullUploadURL(dst),
-		Filename: dst,
-	})
+	team, err := s.sport.GetTeamByID(c.Request.Context(), uint(teamID))
+	if err != nil {
+		if errors.Is(err, errstore.ErrNotFoundData) {
+			s.problem(c, http.StatusBadRequest, codeNotFound)
+			return
+		}
+		s.writeError(c, err)
+		return
+	}
+
+	if team.UserID != userID {
+		s.problem(c, http.StatusBadRequest, codeNotFound)
+		return
+	}
+
+	application, err := s.sport.GetApplicationByID(c.Request.Context(), uint(applicationID))
+	if err != nil {
+		if errors.Is(err, errstore.ErrNotFoundData) {
+			s.problem(c, http.StatusBadRequest, codeNotFound)
+			return
+		}
+		s.writeError(c, err)
+		return
+	}
+
+	if application.TeamID != team.ID {
+		s.problem(c, http.StatusBadRequest, codeNotFound)
+		return
+	}
+
+	s.writeRosterChanges(c, application.ID)
+}
+
+//	@Summary	запросы на замену игроков заявки турнира
+//	@Schemes
+//	@Description	запросы на замену игроков заявки турнира
+//	@Tags			user tournament
+//	@Param			tournament_id	path	int	true	"tournament id"
+//	@Param			application_id	path	int	true	"application id"
+//	@Produce		json
+//	@Success		200	{object}	tGetRosterChangesResponse
+//	@Failure		400	{object}	tProblem	"не корректный запрос"
+//	@Failure		500	{object}	tProblem
+//	@Router			/user/tournaments/{tournament_id}/applications/{application_id}/changes [get]
+func (s *Server) handlerGetTournamentRosterChanges(c *gin.Context) {
+	userID, err := s.checkAuth(c)
+	if err != nil {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+
+	tournamentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		s.invalidParam(c, "id")
+		return
+	}
+
+	applicationID, err := strconv.Atoi(c.Param("aid"))
+	if err != nil {
+		s.invalidParam(c, "aid")
+		return
+	}
+
+	tournament, err := s.sport.GetTournamentByID(c.Request.Context(), uint(tournamentID))
+	if err != nil {
+		s.writeError(c, err)
+		return
+	}
+
+	if tournament.ID == 0 || tournament.UserID != userID {
+		s.problem(c, http.StatusBadRequest, codeNotFound)
+		return
+	}
+
+	application, err := s.sport.GetApplicationByID(c.Request.Context(), uint(applicationID))
+	if err != nil {
+		if errors.Is(err, errstore.ErrNotFoundData) {
+			s.problem(c, http.StatusBadRequest, codeNotFound)
+			return
+		}
+		s.writeError(c, err)
+		return
+	}
+
+	if application.TournamentID != tournament.ID {
+		s.problem(c, http.StatusBadRequest, codeNotFound)
+		return
+	}
+
+	s.writeRosterChanges(c, application.ID)
+}
+
+func (s *Server) writeRosterChanges(c *gin.Context, applicationID uint) {
+	changes, err := s.sport.GetRosterChanges(c.Request.Context(), applicationID)
+	if err != nil {
+		s.writeError(c, err)
+		return
+	}
+
+	data := []tRosterChange{}
+	for _, change := range *changes {
+		data = append(data, newRosterChangeResponse(&change))
+	}
+
+	c.JSON(http.StatusOK, tGetRosterChangesResponse{Data: data})
+}
+
+//	@Summary	рассмотреть запрос на замену игрока
+//	@Schemes
+//	@Description	одобрить или отклонить запрос на замену игрока
+//	@Tags			user tournament
+//	@Param			tournament_id	path	int						true	"tournament id"
+//	@Param			application_id	path	int						true	"application id"
+//	@Param			change_id		path	int						true	"change id"
+//	@Param			change			body	tUpdRosterChangeRequest	true	"roster change status"
+//	@Produce		json
+//	@Success		200	{object}	tRosterChange
+//	@Failure		400	{object}	tProblem	"не найден или не корректный запрос"
+//	@Failure		403	{object}	tProblem	"запрос уже рассмотрен"
+//	@Failure		409	{object}	tProblem	"замена больше не применима"
+//	@Failure		500	{object}	tProblem
+//	@Router			/user/tournaments/{tournament_id}/applications/{application_id}/changes/{change_id} [put]
+func (s *Server) handlerUpdRosterChange(c *gin.Context) {
+	userID, err := s.checkAuth(c)
+	if err != nil {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+
+	tournamentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		s.invalidParam(c, "id")
+		return
+	}
+
+	applicationID, err := strconv.Atoi(c.Param("aid"))
+	if err != nil {
+		s.invalidParam(c, "aid")
+		return
+	}
+
+	changeID, err := strconv.Atoi(c.Param("cid"))
+	if err != nil {
+		s.invalidParam(c, "cid")
+		return
+	}
+
+	jBody := tUpdRosterChangeRequest{}
+	if !s.bindJSON(c, &jBody) {
+		return
+	}
+
+	status, ok := rosterChangeMapStatus[jBody.Status]
+	if !ok {
+		s.invalid(c, tFieldError{Field: "status", Code: fieldInvalid})
+		return
+	}
+
+	change, warnings, err := s.sport.UpdRosterChange(c.Request.Context(),
+		uint(changeID), uint(applicationID), uint(tournamentID), userID, status)
+	if err != nil {
+		if errors.Is(err, errstore.ErrNotFoundData) {
+			s.problem(c, http.StatusBadRequest, codeNotFound)
+			return
+		}
+		s.writeError(c, err)
+		return
+	}
+
+	res := newRosterChangeResponse(change)
+	res.Warnings = newRosterConflicts(warnings)
+	c.JSON(http.StatusOK, res)
+}
+
+//	@Summary	загрузка изображения
+//	@Schemes
+//	@Description	загрузка логотипа или фото (JPEG, PNG, GIF, WebP) размером до UPLOAD_MAX_SIZE. Формат определяется по содержимому, метаданные удаляются, изображение сохраняется в вариантах thumbnail, medium и original с общим путем `<каталог>/<вариант>.<ext>`. В logoUrl и photoUrl можно указывать только url своих загрузок. Загрузки, на которые ничего не ссылается, периодически удаляются
+//	@Tags			user
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			file	formData	file	true	"файл"
+//	@Success		201		{object}	tHandlerUploadResponse
+//	@Failure		400		{object}	tProblem
+//	@Failure		401		{object}	tProblem
+//	@Failure		403		{object}	tProblem	"upload quota exceeded"
+//	@Failure		413		{object}	tProblem	"file is too large"
+//	@Failure		500		{object}	tProblem
+//	@Router			/user/upload [post]
+func (s *Server) handlerUpload(c *gin.Context) {
+	userID, err := s.checkAuth(c)
+	if err != nil {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+
+	// тело запроса больше файла на заголовки частей multipart
+	bodyLimit := s.uploadMaxSize + multipartOverhead
+	if c.Request.ContentLength > bodyLimit {
+		tooLarge(c, s.uploadMaxSize)
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, bodyLimit)
+
+	logoFile, err := c.FormFile("file")
+	if err != nil && !errors.Is(err, http.ErrMissingFile) {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			tooLarge(c, s.uploadMaxSize)
+			return
+		}
+		if errors.Is(err, http.ErrMissingBoundary) || errors.Is(err, http.ErrNotMultipart) {
+			s.problem(c, http.StatusBadRequest, codeBadRequest)
+			return
+		}
+		s.log.Error("failed get file", zap.Error(err))
+		s.problem(c, http.StatusInternalServerError, codeInternal)
+		return
+	}
+
+	if logoFile == nil {
+		s.invalid(c, tFieldError{Field: "file", Code: fieldRequired})
+		return
+	}
+	if logoFile.Size > s.uploadMaxSize {
+		tooLarge(c, s.uploadMaxSize)
+		return
+	}
+
+	src, err := logoFile.Open()
+	if err != nil {
+		s.log.Error("failed open file", zap.Error(err))
+		s.problem(c, http.StatusInternalServerError, codeInternal)
+		return
+	}
+	defer src.Close()
+
+	upload, err := s.sport.NewUpload(c.Request.Context(), userID, src)
+	if err != nil {
+		s.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tHandlerUploadResponse{
+		URL:      upload.URL,
+		Filename: upload.Keys[imageOriginal],
+		Variants: upload.Variants,
+	})
+}
+
+//	@Summary	начало загрузки документа
+//	@Schemes
+//	@Description	загрузка документа (PDF, JPEG, PNG), например медицинской справки, частями до chunkMaxSize байт. Части отправляются PUT /user/upload/sessions/{sid}, после последней части документ доступен в upload.url, его можно указать в medicalCertificateUrl игрока. Незавершенные загрузки удаляются через UPLOAD_SESSION_TTL
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			params	body		tNewUploadSessionRequest	true	"имя и размер файла"
+//	@Success		201		{object}	tUploadSessionResponse
+//	@Failure		400		{object}	tProblem
+//	@Failure		401		{object}	tProblem
+//	@Failure		403		{object}	tProblem	"upload quota exceeded"
+//	@Failure		413		{object}	tProblem	"document is too large"
+//	@Failure		500		{object}	tProblem
+//	@Router			/user/upload/sessions [post]
+func (s *Server) handlerNewUploadSession(c *gin.Context) {
+	userID, err := s.checkAuth(c)
+	if err != nil {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+
+	jBody := tNewUploadSessionRequest{}
+	if !s.bindJSON(c, &jBody) {
+		return
+	}
+	if jBody.Size > s.docMaxSize {
+		tooLarge(c, s.docMaxSize)
+		return
+	}
+
+	session, err := s.sport.NewUploadSession(c.Request.Context(), userID, jBody.Filename, jBody.Size)
+	if err != nil {
+		s.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newUploadSessionResponse(session, s.chunkMaxSize))
+}
+
+//	@Summary	состояние загрузки документа
+//	@Schemes
+//	@Description	сколько байт получено, после обрыва соединения загрузка продолжается с offset
+//	@Tags			user
+//	@Produce		json
+//	@Param			sid	path		string	true	"upload session id"
+//	@Success		200	{object}	tUploadSessionResponse
+//	@Failure		401	{object}	tProblem
+//	@Failure		404	{object}	tProblem
+//	@Failure		500	{object}	tProblem
+//	@Router			/user/upload/sessions/{sid} [get]
+func (s *Server) handlerGetUploadSession(c *gin.Context) {
+	userID, err := s.checkAuth(c)
+	if err != nil {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+
+	session, err := s.sport.GetUploadSession(c.Request.Context(), c.Param("sid"), userID)
+	if err != nil {
+		if errors.Is(err, errsport.ErrNotFoundData) {
+			s.problem(c, http.StatusNotFound, codeNotFound)
+			return
+		}
+		s.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newUploadSessionResponse(session, s.chunkMaxSize))
+}
+
+//	@Summary	часть документа
+//	@Schemes
+//	@Description	тело запроса - байты документа с позиции start по end включительно из заголовка `Content-Range: bytes <start>-<end>/<size>`, start должен быть равен offset сессии. При несовпадении возвращается 409 upload_offset с offset сессии. Повтор последней части возвращает собранный документ
+//	@Tags			user
+//	@Accept			application/octet-stream
+//	@Produce		json
+//	@Param			sid				path		string	true	"upload session id"
+//	@Param			Content-Range	header		string	true	"bytes <start>-<end>/<size>"
+//	@Success		200				{object}	tUploadSessionResponse
+//	@Failure		400				{object}	tProblem
+//	@Failure		401				{object}	tProblem
+//	@Failure		403				{object}	tProblem	"upload quota exceeded"
+//	@Failure		404				{object}	tProblem
+//	@Failure		409				{object}	tProblem	"upload_offset, продолжить с offset"
+//	@Failure		410				{object}	tProblem	"upload session expired"
+//	@Failure		413				{object}	tProblem	"chunk is too large"
+//	@Failure		500				{object}	tProblem
+//	@Router			/user/upload/sessions/{sid} [put]
+func (s *Server) handlerUploadChunk(c *gin.Context) {
+	userID, err := s.checkAuth(c)
+	if err != nil {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+
+	start, end, total, ok := parseContentRange(c.GetHeader("Content-Range"))
+	if !ok {
+		s.invalid(c, tFieldError{Field: "Content-Range", Code: fieldInvalid})
+		return
+	}
+	size := end - start + 1
+	if size > s.chunkMaxSize {
+		tooLarge(c, s.chunkMaxSize)
+		return
+	}
+	if c.Request.ContentLength != size {
+		s.invalid(c, tFieldError{Field: "Content-Length", Code: fieldInvalid})
+		return
+	}
+
+	session, err := s.sport.GetUploadSession(c.Request.Context(), c.Param("sid"), userID)
+	if err != nil {
+		if errors.Is(err, errsport.ErrNotFoundData) {
+			s.problem(c, http.StatusNotFound, codeNotFound)
+			return
+		}
+		s.writeError(c, err)
+		return
+	}
+	if total != session.Size {
+		s.invalid(c, tFieldError{Field: "Content-Range", Code: fieldInvalid, Message: "size does not match upload session"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, s.chunkMaxSize)
+	session, err = s.sport.AppendUploadChunk(c.Request.Context(), session.ID, userID, start, size, c.Request.Body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxErr):
+			tooLarge(c, s.chunkMaxSize)
+		case errors.Is(err, errsport.ErrNotFoundData):
+			s.problem(c, http.StatusNotFound, codeNotFound)
+		case errors.Is(err, errsport.ErrUploadOffset) && session != nil:
+			p := newProblem(c, http.StatusConflict, codeUploadOffset)
+			p.Offset = &session.Received
+			writeProblem(c, p)
+		default:
+			s.writeError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newUploadSessionResponse(session, s.chunkMaxSize))
+}
+
+//	@Summary	отмена загрузки документа
+//	@Schemes
+//	@Description	удаляет полученные части, уже собранный документ остается
+//	@Tags			user
+//	@Param			sid	path	string	true	"upload session id"
+//	@Success		204
+//	@Failure		401	{object}	tProblem
+//	@Failure		404	{object}	tProblem
+//	@Failure		500	{object}	tProblem
+//	@Router			/user/upload/sessions/{sid} [delete]
+func (s *Server) handlerAbortUploadSession(c *gin.Context) {
+	userID, err := s.checkAuth(c)
+	if err != nil {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+
+	err = s.sport.AbortUploadSession(c.Request.Context(), c.Param("sid"), userID)
+	if err != nil {
+		if errors.Is(err, errsport.ErrNotFoundData) {
+			s.problem(c, http.StatusNotFound, codeNotFound)
+			return
+		}
+		s.writeError(c, err)
+		return
+	}
+
+	c.Writer.WriteHeader(http.StatusNoContent)
+}
+
+//	@Summary	очередь писем
+//	@Schemes
+//	@Description	письма в outbox по статусу, по умолчанию не доставленные (dead)
+//	@Tags			admin
+//	@Produce		json
+//	@Param			status	query		string	false	"status"	Enums(pending, sent, dead)
+//	@Param			page	query		int		false	"page number"
+//	@Param			limit	query		int		false	"limit size"
+//	@Success		200		{object}	tGetOutboxResponse
+//	@Failure		400		{object}	tProblem
+//	@Failure		401		{object}	tProblem
+//	@Failure		403		{object}	tProblem
+//	@Failure		500		{object}	tProblem
+//	@Router			/admin/outbox [get]
+func (s *Server) handlerAdminOutbox(c *gin.Context) {
+	status := models.OutboxStatus(c.DefaultQuery("status", string(models.OutboxDead)))
+	if status != models.OutboxPending && status != models.OutboxSent && status != models.OutboxDead {
+		s.invalidParam(c, "status")
+		return
+	}
+
+	messages, err := s.outbox.GetOutbox(c.Request.Context(), status)
+	if err != nil {
+		s.writeError(c, err)
+		return
+	}
+
+	pg := s.getPagination(c, len(*messages))
+
+	res := []tOutboxMessage{}
+	for _, m := range (*messages)[pg.StartRow:pg.EndRow] {
+		res = append(res, newOutboxMessageResponse(&m))
+	}
+
+	c.JSON(http.StatusOK, tGetOutboxResponse{
+		Pagination: pg,
+		Data:       res,
+	})
+}
+
+//	@Summary	повторить отправку письма
+//	@Schemes
+//	@Description	вернуть письмо в очередь со сброшенным счетчиком попыток
+//	@Tags			admin
+//	@Produce		json
+//	@Param			message_id	path		int	true	"message id"
+//	@Success		200			{object}	tOutboxMessage
+//	@Failure		400			{object}	tProblem
+//	@Failure		401			{object}	tProblem
+//	@Failure		403			{object}	tProblem
+//	@Failure		409			{object}	tProblem	"mail_expired"
+//	@Failure		500			{object}	tProblem
+//	@Router			/admin/outbox/{message_id}/requeue [post]
+func (s *Server) handlerAdminRequeueOutbox(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		s.invalidParam(c, "id")
+		return
+	}
+
+	msg, err := s.outbox.RequeueOutbox(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, errstore.ErrNotFoundData) {
+			s.problem(c, http.StatusBadRequest, codeNotFound)
+			return
+		}
+		s.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newOutboxMessageResponse(msg))
+}
+
+//	@Summary	перехваченные письма
+//	@Schemes
+//	@Description	письма, отправленные через транспорт memory, новые первыми. Доступно только в режиме разработки
+//	@Tags			dev
+//	@Produce		json
+//	@Param			to		query		string	false	"recipient email"
+//	@Param			page	query		int		false	"page number"
+//	@Param			limit	query		int		false	"limit size"
+//	@Success		200		{object}	tGetMailsResponse
+//	@Router			/dev/mails [get]
+func (s *Server) handlerDevMails(c *gin.Context) {
+	to := c.Query("to")
+	messages := s.mailbox.Messages()
+
+	mails := []tMail{}
+	for i := len(messages) - 1; i >= 0; i-- {
+		if to != "" && messages[i].To != to {
+			continue
+		}
+		mails = append(mails, newMailResponse(&messages[i]))
+	}
+
+	pg := s.getPagination(c, len(mails))
+
+	c.JSON(http.StatusOK, tGetMailsResponse{
+		Pagination: pg,
+		Data:       mails[pg.StartRow:pg.EndRow],
+	})
+}
+
+//	@Summary	очистить перехваченные письма
+//	@Schemes
+//	@Description	удалить письма, перехваченные транспортом memory
+//	@Tags			dev
+//	@Success		200
+//	@Router			/dev/mails [delete]
+func (s *Server) handlerDevResetMails(c *gin.Context) {
+	s.mailbox.Reset()
+	c.Writer.WriteHeader(http.StatusOK)
+}
+
+//	@Summary	webhook Telegram бота
+//	@Schemes
+//	@Description	обновления от Telegram Bot API. Контакт, которым пользователь поделился в боте, привязывает чат к номеру телефона, на остальные сообщения бот просит поделиться номером
+//	@Tags			auth
+//	@Accept			json
+//	@Param			X-Telegram-Bot-Api-Secret-Token	header	string			true	"webhook secret"
+//	@Param			update							body	tTelegramUpdate	true	"update"
+//	@Success		200
+//	@Failure		401	{object}	tProblem
+//	@Failure		500	{object}	tProblem
+//	@Router			/telegram/webhook [post]
+func (s *Server) handlerTelegramWebhook(c *gin.Context) {
+	secret := c.GetHeader("X-Telegram-Bot-Api-Secret-Token")
+	if s.tgSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(s.tgSecret)) != 1 {
+		s.problem(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+
+	bBody, statusCode := s.readBody(c)
+	if statusCode > 0 {
+		s.writeStatus(c, statusCode)
+		return
+	}
+
+	update := tTelegramUpdate{}
+
+	err := json.Unmarshal(bBody, &update)
+	if err != nil {
+		// повтор того же обновления не поможет, отвечаем 200 что бы Telegram не слал его снова
+		s.log.Debug("failed parse telegram update", zap.Error(err))
+		c.Writer.WriteHeader(http.StatusOK)
+		return
+	}
+	msg := update.Message
+	if msg == nil || msg.From == nil {
+		c.Writer.WriteHeader(http.StatusOK)
+		return
+	}
+
+	ctx := c.Request.Context()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	language := acceptLanguage(msg.From.LanguageCode)
+
+	// принимаем только собственный номер пользователя, а не пересланный чужой контакт
+	if msg.Contact == nil || msg.Contact.UserID != msg.From.ID {
+		if err = s.telegram.RequestContact(ctx, chatID, language); err != nil {
+			s.log.Error("failed request telegram contact", zap.String("chatID", chatID), zap.Error(err))
+		}
+		c.Writer.WriteHeader(http.StatusOK)
+		return
+	}
+
+	number := phone.Phone(msg.Contact.PhoneNumber).Normalize()
+	if !number.IsValid() {
+		c.Writer.WriteHeader(http.StatusOK)
+		return
+	}
+
+	_, err = s.sport.LinkTelegram(ctx, number.String(), chatID, language)
+	if err != nil {
+		s.writeError(c, err)
+		return
+	}
+
+	c.Writer.WriteHeader(http.StatusOK)
 }
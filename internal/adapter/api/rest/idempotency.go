@@ -0,0 +1,120 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	headerIdempotencyKey = "Idempotency-Key"
+	// headerIdempotentReplayed отмечает ответ, повторенный по Idempotency-Key.
+	headerIdempotentReplayed = "Idempotent-Replayed"
+	idempotencyKeyMaxLength  = 255
+)
+
+// idempotencyWriter запоминает тело ответа, чтобы сохранить его для повторов.
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// requestHash хеш метода, пути и тела запроса: с одним ключом можно повторить только тот же запрос.
+func requestHash(c *gin.Context, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// middlewareIdempotency повторяет сохраненный ответ на запрос с тем же Idempotency-Key,
+// вместо того чтобы выполнить его второй раз. Ответ 5xx не сохраняется, такой запрос
+// повтор выполнит заново. Запросы без заголовка выполняются как обычно.
+func (s *Server) middlewareIdempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(headerIdempotencyKey)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > idempotencyKeyMaxLength {
+			s.invalid(c, tFieldError{Field: headerIdempotencyKey, Code: fieldTooLong})
+			c.Abort()
+			return
+		}
+
+		userID, err := s.checkAuth(c)
+		if err != nil {
+			s.problem(c, http.StatusUnauthorized, codeUnauthorized)
+			c.Abort()
+			return
+		}
+
+		body, statusCode := s.readBody(c)
+		if statusCode > 0 {
+			s.writeStatus(c, statusCode)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		saved, err := s.sport.BeginIdempotent(c.Request.Context(), userID, key, requestHash(c, body))
+		if err != nil {
+			s.writeError(c, err)
+			c.Abort()
+			return
+		}
+		if saved.StatusCode > 0 {
+			c.Header(headerIdempotentReplayed, "true")
+			c.Data(saved.StatusCode, saved.ContentType, saved.Body)
+			c.Abort()
+			return
+		}
+
+		// клиент мог отключиться, не дождавшись ответа, а ответ все равно нужно сохранить
+		// для его повтора, поэтому ключ сохраняется без отмены вместе с запросом
+		ctx := context.WithoutCancel(c.Request.Context())
+		w := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		finished := false
+		defer func() {
+			if finished {
+				return
+			}
+			// обработчик упал, ключ освобождается для повтора
+			if err := s.sport.CancelIdempotent(ctx, saved); err != nil {
+				s.log.Error("failed cancel idempotency key", zap.Error(err))
+			}
+		}()
+
+		c.Next()
+
+		if w.Status() >= http.StatusInternalServerError {
+			return
+		}
+		saved.StatusCode = w.Status()
+		saved.ContentType = w.Header().Get("Content-Type")
+		saved.Body = w.body.Bytes()
+		if err := s.sport.FinishIdempotent(ctx, saved); err != nil {
+			s.log.Error("failed save idempotency key", zap.Error(err))
+			return
+		}
+		finished = true
+	}
+}
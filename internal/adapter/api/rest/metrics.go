@@ -0,0 +1,38 @@
+package rest
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestMetrics метрики сервиса: учет HTTP запросов и их выдача в формате Prometheus.
+type requestMetrics interface {
+	ObserveRequest(method, route string, status int, d time.Duration)
+	Handler() http.Handler
+}
+
+// middlewareMetrics учитывает запрос по шаблону маршрута, а не по пути, чтобы
+// идентификаторы в пути не плодили серии метрик.
+func (s *Server) middlewareMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		s.metrics.ObserveRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start))
+	}
+}
+
+// handlerMetrics отдает метрики, при заданном токене только с заголовком Authorization: Bearer <токен>.
+func (s *Server) handlerMetrics(c *gin.Context) {
+	if s.metricsToken != "" {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.metricsToken)) != 1 {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+	}
+	s.metrics.Handler().ServeHTTP(c.Writer, c.Request)
+}
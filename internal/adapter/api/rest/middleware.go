@@ -4,10 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
 	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/ratelimit"
 	"sport-space/pkg/jwt"
 
 	"github.com/gin-gonic/gin"
@@ -34,6 +36,7 @@ func (s *Server) middlewareLogger() gin.HandlerFunc {
 			zap.String("method", c.Request.Method),
 			zap.Int("status", c.Writer.Status()),
 			zap.Int("size", c.Writer.Size()),
+			traceID(c),
 		)
 	}
 }
@@ -43,15 +46,15 @@ func (s *Server) middlewareAuthentication() gin.HandlerFunc {
 		userID, err := s.checkAuth(c)
 		if err != nil {
 			if !errors.Is(err, errUnauthorize) {
-				c.Writer.WriteHeader(http.StatusInternalServerError)
+				s.problem(c, http.StatusInternalServerError, codeInternal)
 			} else {
-				c.Writer.WriteHeader(http.StatusUnauthorized)
+				s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 			}
 			c.Abort()
 		}
 
 		if err == nil && userID == 0 {
-			c.Writer.WriteHeader(http.StatusUnauthorized)
+			s.problem(c, http.StatusUnauthorized, codeUnauthorized)
 			c.Abort()
 		}
 
@@ -59,6 +62,24 @@ func (s *Server) middlewareAuthentication() gin.HandlerFunc {
 	}
 }
 
+// middlewareAdmin пропускает только пользователей из ADMIN_EMAILS.
+func (s *Server) middlewareAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, statusCode, err := s.checkUser(c)
+		if err != nil {
+			c.AbortWithStatus(statusCode)
+			return
+		}
+
+		if !slices.Contains(s.adminEmails, user.Email) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
+
 func (s *Server) checkAuth(c *gin.Context) (userID uint, err error) {
 	var ok bool
 	var userIDS string
@@ -99,3 +120,59 @@ func (s *Server) checkUser(c *gin.Context) (user *models.User, statusCode int, e
 	}
 	return user, 0, nil
 }
+
+// middlewareRateLimit ограничивает частоту запросов группы name корзиной токенов limit.
+// Ключ - пользователь, если byUser и он вошел, иначе IP клиента. Ответ дополняется
+// заголовками RateLimit-*, сверх лимита - 429 с Retry-After. Ошибка хранилища
+// счетчиков запрос не блокирует.
+func (s *Server) middlewareRateLimit(name string, limit ratelimit.Limit, byUser bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.limiter == nil || limit.Unlimited() {
+			c.Next()
+			return
+		}
+
+		key := name + ":ip:" + c.ClientIP()
+		if byUser {
+			if userID, err := s.checkAuth(c); err == nil && userID > 0 {
+				key = name + ":user:" + strconv.FormatUint(uint64(userID), 10)
+			}
+		}
+
+		if !s.takeRateLimit(c, key, limit) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// takeRateLimit забирает токен из корзины key и выставляет заголовки RateLimit-*.
+// Сверх лимита отвечает 429 с Retry-After и возвращает false.
+func (s *Server) takeRateLimit(c *gin.Context, key string, limit ratelimit.Limit) bool {
+	if s.limiter == nil || limit.Unlimited() {
+		return true
+	}
+
+	res, err := s.limiter.Take(c.Request.Context(), key, limit)
+	if err != nil {
+		s.log.Error("failed take rate limit token", zap.String("key", key), zap.Error(err))
+		return true
+	}
+
+	c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Burst, int(limit.Period.Seconds())))
+	c.Header("RateLimit-Limit", strconv.Itoa(limit.Burst))
+	c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+	if !res.Allowed {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+		s.problem(c, http.StatusTooManyRequests, codeTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// ceilSeconds длительность в целых секундах с округлением вверх.
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
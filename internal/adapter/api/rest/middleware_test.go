@@ -0,0 +1,119 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sport-space/internal/adapter/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// stubLimiter отвечает заданным результатом.
+type stubLimiter struct {
+	res ratelimit.Result
+	err error
+}
+
+func (l stubLimiter) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return l.res, l.err
+}
+
+func rateLimited(s *Server, limit ratelimit.Limit) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		if s.takeRateLimit(c, "key", limit) {
+			c.Status(http.StatusNoContent)
+		}
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w
+}
+
+func TestTakeRateLimit(t *testing.T) {
+	limit := ratelimit.Limit{Burst: 5, Period: time.Minute}
+	noHeaders := map[string]string{
+		"RateLimit-Policy": "", "RateLimit-Limit": "", "RateLimit-Remaining": "", "RateLimit-Reset": "", "Retry-After": "",
+	}
+
+	cases := []struct {
+		name    string
+		limiter rateLimiter
+		limit   ratelimit.Limit
+		status  int
+		headers map[string]string
+	}{
+		{"no limiter", nil, limit, http.StatusNoContent, noHeaders},
+		{"unlimited", stubLimiter{}, ratelimit.Limit{}, http.StatusNoContent, noHeaders},
+		// ошибка хранилища не блокирует запросы
+		{"limiter error", stubLimiter{err: errors.New("down")}, limit, http.StatusNoContent, noHeaders},
+		{
+			"allowed",
+			stubLimiter{res: ratelimit.Result{Allowed: true, Remaining: 4, Reset: 1500 * time.Millisecond}},
+			limit, http.StatusNoContent,
+			map[string]string{
+				"RateLimit-Policy":    "5;w=60",
+				"RateLimit-Limit":     "5",
+				"RateLimit-Remaining": "4",
+				"RateLimit-Reset":     "2",
+				"Retry-After":         "",
+			},
+		},
+		{
+			"denied",
+			stubLimiter{res: ratelimit.Result{Reset: time.Minute, RetryAfter: 12001 * time.Millisecond}},
+			limit, http.StatusTooManyRequests,
+			map[string]string{
+				"RateLimit-Policy":    "5;w=60",
+				"RateLimit-Limit":     "5",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "60",
+				"Retry-After":         "13",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := rateLimited(&Server{log: zap.NewNop(), limiter: c.limiter}, c.limit)
+			if w.Code != c.status {
+				t.Fatalf("expected status %d, got %d", c.status, w.Code)
+			}
+			for name, want := range c.headers {
+				if got := w.Header().Get(name); got != want {
+					t.Fatalf("header %s: expected `%s`, got `%s`", name, want, got)
+				}
+			}
+			if c.status != http.StatusTooManyRequests {
+				return
+			}
+			var p tProblem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil || p.Code != codeTooManyRequests {
+				t.Fatalf("unexpected problem %s: %v", w.Body.String(), err)
+			}
+		})
+	}
+}
+
+func TestTakeRateLimitMemory(t *testing.T) {
+	s := &Server{log: zap.NewNop(), limiter: ratelimit.NewMemory()}
+	limit := ratelimit.Limit{Burst: 2, Period: time.Minute}
+
+	for i, remaining := range []string{"1", "0"} {
+		w := rateLimited(s, limit)
+		if w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Remaining") != remaining {
+			t.Fatalf("request %d: status %d, remaining `%s`", i+1, w.Code, w.Header().Get("RateLimit-Remaining"))
+		}
+	}
+	w := rateLimited(s, limit)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" {
+		t.Fatalf("expected 429 with Retry-After 30, got %d `%s`", w.Code, w.Header().Get("Retry-After"))
+	}
+}
@@ -0,0 +1,293 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+
+	"sport-space/internal/adapter/errsport"
+	"sport-space/internal/adapter/sender"
+	"sport-space/internal/adapter/storage/errstore"
+	"sport-space/internal/core/sportspace"
+	"sport-space/pkg/imaging"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const problemContentType = "application/problem+json"
+
+// Коды ошибок в поле code ответа problem+json, клиент выбирает поведение по ним,
+// а не по тексту.
+const (
+	codeBadRequest           = "bad_request"
+	codeInvalidBody          = "invalid_body"
+	codeValidation           = "validation_failed"
+	codeUnauthorized         = "unauthorized"
+	codeForbidden            = "forbidden"
+	codeNotFound             = "not_found"
+	codeConflict             = "conflict"
+	codeTooLarge             = "too_large"
+	codeTooManyRequests      = "too_many_requests"
+	codePreconditionRequired = "precondition_required"
+	codePreconditionFailed   = "precondition_failed"
+	codeVersionConflict      = "version_conflict"
+	codeInternal             = "internal"
+	codeLoginInvalid         = "login_invalid"
+	codeLoginFailed          = "login_failed"
+	codeLanguage             = "language_not_supported"
+	codeChannel              = "channel_not_supported"
+	codeTelegramNotLinked    = "telegram_not_linked"
+	codeRosterConflict       = "roster_conflict"
+	codeRosterFrozen         = "roster_frozen"
+	codeRosterNotFrozen      = "roster_not_frozen"
+	codeSubstitutionLimit    = "substitution_limit"
+	codeRunningApplication   = "running_application"
+	codeImageFormat          = "image_format"
+	codeDocumentFormat       = "document_format"
+	codeUploadQuota          = "upload_quota"
+	codeUploadNotOwned       = "upload_not_owned"
+	codeUploadOffset         = "upload_offset"
+	codeUploadChunkSize      = "upload_chunk_size"
+	codeUploadExpired        = "upload_expired"
+	codeIdempotencyReused    = "idempotency_key_reused"
+	codeIdempotencyProgress  = "idempotency_in_progress"
+	codeMailExpired          = "mail_expired"
+)
+
+// Коды ошибок полей в errors[].code.
+const (
+	fieldRequired  = "required"
+	fieldInvalid   = "invalid"
+	fieldTooLong   = "too_long"
+	fieldTooShort  = "too_short"
+	fieldEmail     = "email"
+	fieldPhone     = "phone"
+	fieldDate      = "date"
+	fieldDateOrder = "date_order"
+)
+
+// knownErrors ошибки сервиса и хранилища, которые отдаются клиенту со своим кодом,
+// первое совпадение по errors.Is. Отсутствие записи здесь нет: ответ на него зависит
+// от метода (204 для запрошенной записи, 400 для записи из тела запроса).
+var knownErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{errstore.ErrVersionConflict, http.StatusPreconditionFailed, codeVersionConflict},
+	{errstore.ErrForbidden, http.StatusForbidden, codeForbidden},
+	{errstore.ErrLoginNotUnique, http.StatusConflict, codeConflict},
+	{errstore.ErrConflictData, http.StatusConflict, codeConflict},
+	{errstore.ErrInvalidImageExt, http.StatusBadRequest, codeImageFormat},
+	{errsport.ErrRosterConflict, http.StatusConflict, codeRosterConflict},
+	{errsport.ErrRosterFrozen, http.StatusForbidden, codeRosterFrozen},
+	{errsport.ErrRosterNotFrozen, http.StatusConflict, codeRosterNotFrozen},
+	{errsport.ErrSubstitutionLimit, http.StatusConflict, codeSubstitutionLimit},
+	{errsport.ErrRunningApplication, http.StatusConflict, codeRunningApplication},
+	{errsport.ErrConflictData, http.StatusConflict, codeConflict},
+	{errsport.ErrUploadQuota, http.StatusForbidden, codeUploadQuota},
+	{errsport.ErrUploadNotOwned, http.StatusBadRequest, codeUploadNotOwned},
+	{errsport.ErrUploadOffset, http.StatusConflict, codeUploadOffset},
+	{errsport.ErrUploadChunkSize, http.StatusBadRequest, codeUploadChunkSize},
+	{errsport.ErrUploadExpired, http.StatusGone, codeUploadExpired},
+	{errsport.ErrDocumentFormat, http.StatusBadRequest, codeDocumentFormat},
+	{errsport.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, codeIdempotencyReused},
+	{errsport.ErrIdempotencyInProgress, http.StatusConflict, codeIdempotencyProgress},
+	{sender.ErrMessageExpired, http.StatusConflict, codeMailExpired},
+	{imaging.ErrUnsupportedFormat, http.StatusBadRequest, codeImageFormat},
+	{imaging.ErrTooLarge, http.StatusBadRequest, codeImageFormat},
+	{sportspace.ErrLoginNotValid, http.StatusBadRequest, codeLoginInvalid},
+	{sportspace.ErrPasswordNotValid, http.StatusBadRequest, codeLoginInvalid},
+	{sportspace.ErrPasswordNotEquale, http.StatusUnauthorized, codeLoginFailed},
+	{sportspace.ErrLanguageNotSupported, http.StatusBadRequest, codeLanguage},
+	{sportspace.ErrChannelNotSupported, http.StatusBadRequest, codeChannel},
+	{sportspace.ErrTelegramNotLinked, http.StatusConflict, codeTelegramNotLinked},
+}
+
+// statusCodes код для ответа, у которого известен только статус.
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            codeBadRequest,
+	http.StatusUnauthorized:          codeUnauthorized,
+	http.StatusForbidden:             codeForbidden,
+	http.StatusNotFound:              codeNotFound,
+	http.StatusConflict:              codeConflict,
+	http.StatusRequestEntityTooLarge: codeTooLarge,
+	http.StatusPreconditionFailed:    codePreconditionFailed,
+	http.StatusPreconditionRequired:  codePreconditionRequired,
+	http.StatusInternalServerError:   codeInternal,
+}
+
+// problemLanguage язык сообщений, если клиент не прислал поддерживаемый Accept-Language.
+const problemLanguage = "ru"
+
+// problemTitles тексты ошибок по языкам, для каждого кода есть русский текст.
+var problemTitles = map[string]map[string]string{
+	"ru": {
+		codeBadRequest:           "Некорректный запрос",
+		codeInvalidBody:          "Тело запроса не является корректным JSON",
+		codeValidation:           "Ошибка в данных запроса",
+		codeUnauthorized:         "Требуется вход",
+		codeForbidden:            "Действие запрещено",
+		codeNotFound:             "Запись не найдена",
+		codeConflict:             "Запись уже существует или изменилась",
+		codeTooLarge:             "Слишком большой запрос",
+		codeTooManyRequests:      "Слишком много запросов, повторите позже",
+		codePreconditionRequired: "Нужен заголовок If-Match с ETag записи",
+		codePreconditionFailed:   "Некорректный заголовок If-Match",
+		codeVersionConflict:      "Запись уже изменили, перечитайте ее и повторите",
+		codeInternal:             "Внутренняя ошибка сервера",
+		codeLoginInvalid:         "Некорректный email или телефон",
+		codeLoginFailed:          "Неверный код входа",
+		codeLanguage:             "Язык не поддерживается",
+		codeChannel:              "Канал отправки кода не поддерживается",
+		codeTelegramNotLinked:    "Telegram не привязан, отправьте номер телефона боту",
+		codeRosterConflict:       "Игроки уже заявлены в другой команде турнира",
+		codeRosterFrozen:         "Состав заморожен, используйте запрос на замену",
+		codeRosterNotFrozen:      "Состав не заморожен, измените игроков заявки напрямую",
+		codeSubstitutionLimit:    "Лимит замен исчерпан",
+		codeRunningApplication:   "Есть принятая заявка в идущий турнир",
+		codeImageFormat:          "Неподдерживаемое изображение",
+		codeDocumentFormat:       "Неподдерживаемый формат документа, допустимы pdf, jpeg, png",
+		codeUploadQuota:          "Превышен лимит загрузок",
+		codeUploadNotOwned:       "Файл не загружен пользователем",
+		codeUploadOffset:         "Смещение части не совпадает с загруженным размером",
+		codeUploadChunkSize:      "Размер части не совпадает с заголовками",
+		codeUploadExpired:        "Срок загрузки истек",
+		codeIdempotencyReused:    "Idempotency-Key уже использован для другого запроса",
+		codeIdempotencyProgress:  "Запрос с этим Idempotency-Key еще выполняется",
+		codeMailExpired:          "Срок отправки письма истек, повторная отправка невозможна",
+		fieldRequired:            "Обязательное поле",
+		fieldInvalid:             "Некорректное значение",
+		fieldTooLong:             "Слишком длинное значение",
+		fieldTooShort:            "Слишком короткое значение",
+		fieldEmail:               "Некорректный email",
+		fieldPhone:               "Некорректный номер телефона",
+		fieldDate:                "Дата должна быть в формате RFC 3339",
+		fieldDateOrder:           "Дата раньше предыдущей по порядку",
+	},
+	"en": {
+		codeBadRequest:           "Bad request",
+		codeInvalidBody:          "Request body is not valid JSON",
+		codeValidation:           "Request validation failed",
+		codeUnauthorized:         "Authentication required",
+		codeForbidden:            "Action is forbidden",
+		codeNotFound:             "Record not found",
+		codeConflict:             "Record already exists or has changed",
+		codeTooLarge:             "Request is too large",
+		codeTooManyRequests:      "Too many requests, retry later",
+		codePreconditionRequired: "If-Match header with the record ETag is required",
+		codePreconditionFailed:   "Invalid If-Match header",
+		codeVersionConflict:      "Record has been changed, reload it and retry",
+		codeInternal:             "Internal server error",
+		codeLoginInvalid:         "Invalid email or phone",
+		codeLoginFailed:          "Invalid login code",
+		codeLanguage:             "Language is not supported",
+		codeChannel:              "Code delivery channel is not supported",
+		codeTelegramNotLinked:    "Telegram is not linked, share your phone number with the bot",
+		codeRosterConflict:       "Players are already in another application of the tournament",
+		codeRosterFrozen:         "Roster is frozen, use a roster change request",
+		codeRosterNotFrozen:      "Roster is not frozen, change application players directly",
+		codeSubstitutionLimit:    "Late substitutions limit reached",
+		codeRunningApplication:   "There is an accepted application in a running tournament",
+		codeImageFormat:          "Unsupported image",
+		codeDocumentFormat:       "Unsupported document format, allowed pdf, jpeg, png",
+		codeUploadQuota:          "Upload quota exceeded",
+		codeUploadNotOwned:       "File is not an upload of the user",
+		codeUploadOffset:         "Chunk offset does not match received size",
+		codeUploadChunkSize:      "Chunk size does not match headers",
+		codeUploadExpired:        "Upload session expired",
+		codeIdempotencyReused:    "Idempotency-Key is already used with another request",
+		codeIdempotencyProgress:  "Request with this Idempotency-Key is in progress",
+		codeMailExpired:          "Email delivery deadline has passed, it cannot be requeued",
+		fieldRequired:            "Field is required",
+		fieldInvalid:             "Invalid value",
+		fieldTooLong:             "Value is too long",
+		fieldTooShort:            "Value is too short",
+		fieldEmail:               "Invalid email",
+		fieldPhone:               "Invalid phone number",
+		fieldDate:                "Date must be in RFC 3339 format",
+		fieldDateOrder:           "Date is out of order",
+	},
+}
+
+// problemTitle текст кода на языке клиента.
+func problemTitle(c *gin.Context, code string) string {
+	if titles, ok := problemTitles[acceptLanguage(c.GetHeader("Accept-Language"))]; ok {
+		if title, ok := titles[code]; ok {
+			return title
+		}
+	}
+	return problemTitles[problemLanguage][code]
+}
+
+// newProblem ответ с кодом code, тексты ошибок полей переводятся на язык клиента.
+func newProblem(c *gin.Context, status int, code string, fields ...tFieldError) tProblem {
+	p := tProblem{
+		Type:     "urn:sportspace:problem:" + code,
+		Title:    problemTitle(c, code),
+		Status:   status,
+		Code:     code,
+		Instance: c.Request.URL.Path,
+	}
+	for _, f := range fields {
+		if f.Message == "" {
+			f.Message = problemTitle(c, f.Code)
+		}
+		p.Errors = append(p.Errors, f)
+	}
+	return p
+}
+
+func writeProblem(c *gin.Context, p tProblem) {
+	c.Header("Content-Type", problemContentType)
+	c.JSON(p.Status, p)
+}
+
+// problem ответ problem+json со статусом status и кодом code.
+func (s *Server) problem(c *gin.Context, status int, code string, fields ...tFieldError) {
+	writeProblem(c, newProblem(c, status, code, fields...))
+}
+
+// writeStatus ответ problem+json, когда известен только статус.
+func (s *Server) writeStatus(c *gin.Context, status int) {
+	code, ok := statusCodes[status]
+	if !ok {
+		code = codeBadRequest
+	}
+	s.problem(c, status, code)
+}
+
+// invalid ответ 400 с ошибками полей запроса.
+func (s *Server) invalid(c *gin.Context, fields ...tFieldError) {
+	s.problem(c, http.StatusBadRequest, codeValidation, fields...)
+}
+
+// invalidParam ответ 400 на некорректный параметр пути или запроса.
+func (s *Server) invalidParam(c *gin.Context, name string) {
+	s.invalid(c, tFieldError{Field: name, Code: fieldInvalid})
+}
+
+// invalidBody ответ 400 на тело, которое не разбирается как JSON.
+func (s *Server) invalidBody(c *gin.Context) {
+	s.problem(c, http.StatusBadRequest, codeInvalidBody)
+}
+
+// writeError ответ на ошибку сервиса: известные ошибки из knownErrors со своим статусом
+// и кодом, остальные - 500 с записью в лог.
+func (s *Server) writeError(c *gin.Context, err error) {
+	var rosterErr *errsport.RosterConflictError
+	if errors.As(err, &rosterErr) {
+		p := newProblem(c, http.StatusConflict, codeRosterConflict)
+		p.Conflicts = newRosterConflicts(rosterErr.Conflicts)
+		writeProblem(c, p)
+		return
+	}
+	for _, known := range knownErrors {
+		if errors.Is(err, known.err) {
+			s.problem(c, known.status, known.code)
+			return
+		}
+	}
+	s.log.Error("failed request", zap.String("path", c.FullPath()), zap.Error(err))
+	s.problem(c, http.StatusInternalServerError, codeInternal)
+}
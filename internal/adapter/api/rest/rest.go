@@ -2,21 +2,23 @@ package rest
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"net/http"
 	"net/url"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"sport-space/docs"
 	"sport-space/internal/adapter/errsport"
 	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/ratelimit"
+	"sport-space/internal/adapter/sender"
+	"sport-space/internal/adapter/storage/errstore"
+	"sport-space/internal/core/sportspace"
 	"sport-space/pkg/jwt"
-	"sport-space/pkg/tools"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -31,11 +33,18 @@ var (
 	msgErrorCloseBody = "failed close body request"
 )
 
+// multipartOverhead запас на заголовки частей multipart сверх размера файла.
+const multipartOverhead = 64 << 10
+
 type sport interface {
-	NewOTP(ctx context.Context, email string) error
+	NewOTP(ctx context.Context, email, language string) error
+	NewOTPByPhone(ctx context.Context, phone string, channel models.OTPChannel, language string) error
 	LoginWithOTP(ctx context.Context, email, otp string) (*models.User, error)
+	LoginWithPhoneOTP(ctx context.Context, phone, otp string) (*models.User, error)
+	LinkTelegram(ctx context.Context, phone, chatID, language string) (*models.User, error)
 	GetAllTournaments(ctx context.Context) (*[]models.Tournament, error)
 	GetUserByID(ctx context.Context, userID uint) (*models.User, error)
+	UpdUserLanguage(ctx context.Context, userID uint, language string) (*models.User, error)
 	NewTournament(ctx context.Context, tournament *models.Tournament) (*models.Tournament, error)
 	GetTournaments(ctx context.Context, user *models.User) (*[]models.Tournament, error)
 	GetTournamentByID(ctx context.Context, tournamentID uint) (*models.Tournament, error)
@@ -49,27 +58,100 @@ type sport interface {
 	GetPlayers(ctx context.Context, userID uint) (*[]models.Player, error)
 	GetPlayerByID(ctx context.Context, playerID uint) (*models.Player, error)
 	UpdPlayer(ctx context.Context, player *models.Player) (*models.Player, error)
+	DelTournament(ctx context.Context, tournamentID, userID uint) (*models.Tournament, error)
+	DelTeam(ctx context.Context, teamID, userID uint) (*models.Team, error)
+	DelPlayer(ctx context.Context, playerID, userID uint) (*models.Player, error)
+	GetTrash(ctx context.Context, userID uint) (*sportspace.Trash, error)
+	RestoreTournament(ctx context.Context, tournamentID, userID uint) (*models.Tournament, error)
+	RestoreTeam(ctx context.Context, teamID, userID uint) (*models.Team, error)
+	RestorePlayer(ctx context.Context, playerID, userID uint) (*models.Player, error)
+	PurgeAt(deletedAt time.Time) time.Time
+	BeginIdempotent(ctx context.Context, userID uint, key, hash string) (*models.IdempotencyKey, error)
+	FinishIdempotent(ctx context.Context, key *models.IdempotencyKey) error
+	CancelIdempotent(ctx context.Context, key *models.IdempotencyKey) error
 	NewApplicationTeam(ctx context.Context, playerIDs *[]uint, tournamentID, teamID, userID uint) (
-		*models.Application, *[]models.Player, error,
+		*models.Application, *[]models.Player, []errsport.RosterConflict, error,
 	)
-	UpdApplicationTeam(ctx context.Context, applicationID uint, playerIDs *[]uint, status models.ApplicationStatus, teamID uint, userID uint) (
-		*models.Application, *[]models.Player, error,
+	UpdApplicationTeam(ctx context.Context, applicationID, version uint, playerIDs *[]uint, status models.ApplicationStatus, teamID uint, userID uint) (
+		*models.Application, *[]models.Player, []errsport.RosterConflict, error,
 	)
 	GetApplicationsTeam(ctx context.Context, teamID uint) (*[]models.Application, error)
 	GetApplicationByID(ctx context.Context, applicationID uint) (*models.Application, error)
 	GetPlayersFromApplication(ctx context.Context, applicationID uint) (*[]models.Player, error)
 	GetApplicationsFromTournament(ctx context.Context, tournamentID uint) (*[]models.Application, error)
-	UpdApplicationTournament(ctx context.Context, applicationID uint, status models.ApplicationStatus, tournamentID uint, userID uint) (*models.Application, error)
+	UpdApplicationTournament(ctx context.Context, applicationID, version uint, status models.ApplicationStatus, tournamentID uint, userID uint) (*models.Application, error)
+	NewRosterChange(ctx context.Context, change *models.RosterChange, teamID, userID uint) (
+		*models.RosterChange, []errsport.RosterConflict, error,
+	)
+	GetRosterChanges(ctx context.Context, applicationID uint) (*[]models.RosterChange, error)
+	UpdRosterChange(ctx context.Context, changeID, applicationID, tournamentID, userID uint,
+		status models.RosterChangeStatus,
+	) (*models.RosterChange, []errsport.RosterConflict, error)
+	NewUpload(ctx context.Context, userID uint, file io.Reader) (*models.Upload, error)
+	NewUploadSession(ctx context.Context, userID uint, filename string, size int64) (*models.UploadSession, error)
+	GetUploadSession(ctx context.Context, sessionID string, userID uint) (*models.UploadSession, error)
+	AppendUploadChunk(ctx context.Context, sessionID string, userID uint, offset, size int64, chunk io.Reader) (
+		*models.UploadSession, error,
+	)
+	AbortUploadSession(ctx context.Context, sessionID string, userID uint) error
+	GetNotificationSettings(ctx context.Context, userID uint) (*models.NotificationSettings, error)
+	UpdNotificationSettings(ctx context.Context, settings *models.NotificationSettings) (
+		*models.NotificationSettings, error,
+	)
+}
+
+type outbox interface {
+	GetOutbox(ctx context.Context, status models.OutboxStatus) (*[]models.OutboxMessage, error)
+	RequeueOutbox(ctx context.Context, id uint) (*models.OutboxMessage, error)
+}
+
+// mailbox письма, перехваченные транспортом sender в режиме разработки.
+type mailbox interface {
+	Messages() []sender.Message
+	Reset()
+}
+
+// telegram бот, через который пользователь делится номером телефона для получения кодов.
+type telegram interface {
+	RequestContact(ctx context.Context, chatID, language string) error
+}
+
+// rateLimiter корзины токенов для ограничения частоты запросов.
+type rateLimiter interface {
+	Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error)
+}
+
+// blob хранилище загруженных файлов, сохраняет их сервис sport.
+type blob interface {
+	URL(key string) string
+}
+
+// blobServer хранилище, файлы которого раздает сам сервер.
+type blobServer interface {
+	http.Handler
+	URLPath() string
 }
 
 type Server struct {
 	srv           *http.Server
 	log           *zap.Logger
 	sport         sport
+	outbox        outbox
+	mailbox       mailbox
+	telegram      telegram
+	tgSecret      string
+	adminEmails   []string
+	proxies       []string
 	secret        string
-	uploadPath    string
-	uploadURL     string
+	blob          blob
 	uploadMaxSize int64
+	docMaxSize    int64
+	chunkMaxSize  int64
+	publicMaxAge  time.Duration
+	limiter       rateLimiter
+	rateLimits    RateLimits
+	metrics       requestMetrics
+	metricsToken  string
 	baseURL       string
 	tlsEnable     uint
 	tlsCert       string
@@ -98,9 +180,57 @@ func SetSecretKey(secret string) option {
 	}
 }
 
-func SetUploadPath(path string) option {
+func SetBlob(b blob) option {
+	return func(s *Server) {
+		s.blob = b
+	}
+}
+
+// SetUploadLimits лимиты в байтах: изображения, документа целиком и одной части документа.
+func SetUploadLimits(image, document, chunk int64) option {
+	return func(s *Server) {
+		s.uploadMaxSize = image
+		s.docMaxSize = document
+		s.chunkMaxSize = chunk
+	}
+}
+
+// SetPublicMaxAge время кеширования публичных ответов клиентами и CDN, 0 - не кешировать.
+func SetPublicMaxAge(d time.Duration) option {
+	return func(s *Server) {
+		s.publicMaxAge = d
+	}
+}
+
+// SetRateLimiter хранилище счетчиков запросов, nil - без ограничений.
+func SetRateLimiter(l rateLimiter, limits RateLimits) option {
 	return func(s *Server) {
-		s.uploadPath = path
+		if l != nil {
+			s.limiter = l
+		}
+		s.rateLimits = limits
+	}
+}
+
+// SetMetrics учет запросов и маршрут /metrics, token - Bearer токен для чтения метрик, пустой - без него.
+func SetMetrics(m requestMetrics, token string) option {
+	return func(s *Server) {
+		if m != nil {
+			s.metrics = m
+		}
+		s.metricsToken = token
+	}
+}
+
+// SetTrustedProxies адреса и подсети прокси через ";", только им доверяется X-Forwarded-For.
+func SetTrustedProxies(proxies string) option {
+	return func(s *Server) {
+		s.proxies = nil
+		for _, p := range strings.Split(proxies, ";") {
+			if p = strings.TrimSpace(p); p != "" {
+				s.proxies = append(s.proxies, p)
+			}
+		}
 	}
 }
 
@@ -120,14 +250,49 @@ func SetTLSConfig(enable uint, cert, key, hosts, dirCache string) option {
 	}
 }
 
+func SetOutbox(o outbox) option {
+	return func(s *Server) {
+		s.outbox = o
+	}
+}
+
+// SetMailbox включает просмотр перехваченных писем, nil - выключено.
+func SetMailbox(m *sender.MemoryTransport) option {
+	return func(s *Server) {
+		if m != nil {
+			s.mailbox = m
+		}
+	}
+}
+
+// SetTelegram включает webhook бота, secret сверяется с заголовком X-Telegram-Bot-Api-Secret-Token.
+// Без secret webhook не регистрируется: иначе любой мог бы привязать чужой номер к своему чату.
+func SetTelegram(t telegram, secret string) option {
+	return func(s *Server) {
+		s.telegram = t
+		s.tgSecret = secret
+	}
+}
+
+func SetAdminEmails(emails string) option {
+	return func(s *Server) {
+		s.adminEmails = []string{}
+		for _, email := range strings.Split(emails, ";") {
+			if email = strings.TrimSpace(email); email != "" {
+				s.adminEmails = append(s.adminEmails, email)
+			}
+		}
+	}
+}
+
 func New(service sport, options ...option) (*Server, error) {
 	s := &Server{
 		srv:           &http.Server{},
 		log:           zap.NewNop(),
 		sport:         service,
-		uploadPath:    "./uploads", // Directory upload.
-		uploadURL:     "/uploads",  // URL upload.
 		uploadMaxSize: 2 << 20,
+		docMaxSize:    20 << 20,
+		chunkMaxSize:  5 << 20,
 		secret:        "",
 		baseURL:       "",
 	}
@@ -156,55 +321,115 @@ func (s *Server) Run() error {
 
 	r := gin.New()
 	r.MaxMultipartMemory = s.uploadMaxSize
+	// IP клиента для лимитов запросов, без доверенных прокси - адрес соединения
+	if err := r.SetTrustedProxies(s.proxies); err != nil {
+		return fmt.Errorf("failed set trusted proxies: %w", err)
+	}
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = []string{"*"}
 	r.Use(cors.New(corsConfig))
+	r.Use(s.middlewareTracing())
 	r.Use(s.middlewareLogger())
+	if s.metrics != nil {
+		r.Use(s.middlewareMetrics())
+		r.GET("/metrics", s.handlerMetrics)
+	}
 	r.GET("/ping", s.handlerPing)
-	r.Static(s.uploadURL, s.uploadPath)
+	// локальное хранилище файлов раздает сам сервер
+	if bs, ok := s.blob.(blobServer); ok {
+		r.GET(bs.URLPath()+"/*filepath", gin.WrapH(bs))
+		r.HEAD(bs.URLPath()+"/*filepath", gin.WrapH(bs))
+	}
 
 	api := r.Group("/api/v1")
 	{
 		auth := api.Group("/auth")
+		auth.Use(s.middlewareRateLimit("auth", s.rateLimits.Auth, false))
 		{
 			auth.POST("/otp", s.handlerAuthOTP)
 			auth.POST("/login", s.handlerLogin)
 			auth.POST("/logout", s.handlerLogout)
 		}
 		user := api.Group("/user")
-		user.Use(s.middlewareAuthentication())
+		user.Use(s.middlewareAuthentication(), s.middlewareRateLimit("user", s.rateLimits.User, true))
 		{
 			user.GET("/profile", s.handlerUser)
+			user.PUT("/profile", s.handlerUpdUser)
+			user.GET("/notifications", s.handlerGetNotificationSettings)
+			user.PUT("/notifications", s.handlerUpdNotificationSettings)
 			user.POST("/tournaments", s.handlerUserNewTournament)
 			user.GET("/tournaments", s.handlerUserTournaments)
 			user.GET("/tournaments/:id", s.handlerUserTournament)
 			user.PUT("/tournaments/:id", s.handlerUserUpdTournament)
+			user.DELETE("/tournaments/:id", s.handlerUserDelTournament)
+			user.POST("/tournaments/:id/restore", s.handlerUserRestoreTournament)
 
-			user.POST("/teams", s.handlerUserNewTeam)
+			user.POST("/teams", s.middlewareIdempotency(), s.handlerUserNewTeam)
 			user.GET("/teams", s.handlerUserTeams)
 			user.GET("/teams/:id", s.handlerUserTeam)
 			user.PUT("/teams/:id", s.handlerUserUptTeam)
+			user.DELETE("/teams/:id", s.handlerUserDelTeam)
+			user.POST("/teams/:id/restore", s.handlerUserRestoreTeam)
 
 			user.POST("/players", s.handlerUserNewPlayer)
-			user.POST("/players/batch", s.handlerUserNewPlayerBatch)
+			user.POST("/players/batch", s.middlewareIdempotency(), s.handlerUserNewPlayerBatch)
 			user.GET("/players", s.handlerUserPlayers)
+			user.GET("/players/:id", s.handlerUserPlayer)
 			user.PUT("/players/:id", s.handlerUserUpdatePlayer)
+			user.DELETE("/players/:id", s.handlerUserDelPlayer)
+			user.POST("/players/:id/restore", s.handlerUserRestorePlayer)
+
+			user.GET("/trash", s.handlerUserTrash)
 
 			// заявки турнира
 			user.GET("/tournaments/:id/applications", s.handlerGetTournamentApplications)
 			user.GET("/tournaments/:id/applications/:aid", s.handlerGetTournamentApplication)
 			user.PUT("/tournaments/:id/applications/:aid", s.handlerUpdTournamentApplication)
+			user.GET("/tournaments/:id/applications/:aid/changes", s.handlerGetTournamentRosterChanges)
+			user.PUT("/tournaments/:id/applications/:aid/changes/:cid", s.handlerUpdRosterChange)
 
 			// заявки команды
-			user.POST("/teams/:id/applications", s.handlerNewTeamApplication)
+			user.POST("/teams/:id/applications", s.middlewareIdempotency(), s.handlerNewTeamApplication)
 			user.PUT("/teams/:id/applications/:aid", s.handlerUpdStatusTeamApplication)
 			user.GET("/teams/:id/applications", s.handlerGetTeamApplications)
 			user.GET("/teams/:id/applications/:aid", s.handlerGetApplication)
+			user.POST("/teams/:id/applications/:aid/changes", s.handlerNewRosterChange)
+			user.GET("/teams/:id/applications/:aid/changes", s.handlerGetTeamRosterChanges)
+
+			if s.blob != nil {
+				upload := user.Group("/upload")
+				upload.Use(s.middlewareRateLimit("upload", s.rateLimits.Upload, true))
+				upload.POST("", s.handlerUpload)
+				upload.POST("/sessions", s.handlerNewUploadSession)
+				upload.GET("/sessions/:sid", s.handlerGetUploadSession)
+				upload.PUT("/sessions/:sid", s.handlerUploadChunk)
+				upload.DELETE("/sessions/:sid", s.handlerAbortUploadSession)
+			}
+		}
 
-			user.POST("/upload", s.handlerUpload)
+		admin := api.Group("/admin")
+		admin.Use(s.middlewareAuthentication(), s.middlewareAdmin())
+		if s.outbox != nil {
+			admin.GET("/outbox", s.handlerAdminOutbox)
+			admin.POST("/outbox/:id/requeue", s.handlerAdminRequeueOutbox)
+		}
+
+		if s.telegram != nil {
+			if s.tgSecret != "" {
+				api.POST("/telegram/webhook", s.handlerTelegramWebhook)
+			} else {
+				s.log.Warn("telegram webhook is disabled: webhook secret is empty")
+			}
+		}
+
+		if s.mailbox != nil {
+			dev := api.Group("/dev")
+			dev.GET("/mails", s.handlerDevMails)
+			dev.DELETE("/mails", s.handlerDevResetMails)
 		}
 
 		guest := api.Group("/")
+		guest.Use(s.middlewareRateLimit("public", s.rateLimits.Public, false))
 		{
 			guest.GET("/tournaments", s.handlerGetAllTournament)
 		}
@@ -223,9 +448,7 @@ func (s *Server) Run() error {
 	)
 	switch s.tlsEnable {
 	case 1:
-		if err := s.srv.ListenAndServeTLS(s.tlsCert, s.tlsKey); err != nil {
-			return fmt.Errorf("server stopped with error: %w", err)
-		}
+		err = s.srv.ListenAndServeTLS(s.tlsCert, s.tlsKey)
 	case 2:
 		autocertManager := autocert.Manager{
 			Prompt:     autocert.AcceptTOS,
@@ -233,15 +456,23 @@ func (s *Server) Run() error {
 			Cache:      autocert.DirCache(s.tlsDirCache),
 		}
 		s.srv.Handler = autocertManager.HTTPHandler(s.srv.Handler)
-		if err := s.srv.ListenAndServe(); err != nil {
-			return fmt.Errorf("server stopped with error: %w", err)
-		}
+		err = s.srv.ListenAndServe()
 	default:
-		if err := s.srv.ListenAndServe(); err != nil {
-			return fmt.Errorf("server stopped with error: %w", err)
-		}
+		err = s.srv.ListenAndServe()
 	}
+	// ErrServerClosed - остановка через Shutdown
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server stopped with error: %w", err)
+	}
+
+	return nil
+}
 
+// Shutdown перестает принимать соединения и ждет завершения текущих запросов до отмены ctx.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if err := s.srv.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed shutdown server: %w", err)
+	}
 	return nil
 }
 
@@ -255,11 +486,16 @@ func unauthorize(c *gin.Context) {
 	http.SetCookie(c.Writer, userCookie)
 }
 
-func (s *Server) authorization(c *gin.Context, login, password string) (*models.User, error) {
+func (s *Server) authorization(c *gin.Context, auth tAuthorization) (*models.User, error) {
 	var err error
 	var user *models.User
 	ctx := c.Request.Context()
-	if user, err = s.sport.LoginWithOTP(ctx, login, password); err != nil {
+	if auth.Phone != "" {
+		user, err = s.sport.LoginWithPhoneOTP(ctx, auth.Phone.Normalize().String(), auth.OTP)
+	} else {
+		user, err = s.sport.LoginWithOTP(ctx, auth.Email, auth.OTP)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed authorization: %w", err)
 	}
 
@@ -294,38 +530,113 @@ func (s *Server) readBody(c *gin.Context) ([]byte, int) {
 	return bBody, 0
 }
 
-func (s *Server) login(c *gin.Context, login, password string) (user *models.User, err error) {
-	if user, err = s.authorization(c, login, password); err != nil {
+func (s *Server) login(c *gin.Context, auth tAuthorization) (user *models.User, err error) {
+	if user, err = s.authorization(c, auth); err != nil {
 		s.log.Debug("authorization failed", zap.Error(err))
 		return nil, err
 	}
 	return user, nil
 }
 
-func (s *Server) genUploadName(name string) string {
-	return tools.RandomString(20) + filepath.Ext(name)
+// tooLarge ответ 413 с лимитом, который превышен.
+func tooLarge(c *gin.Context, limit int64) {
+	p := newProblem(c, http.StatusRequestEntityTooLarge, codeTooLarge)
+	p.MaxSize = limit
+	writeProblem(c, p)
+}
+
+// parseContentRange разбирает заголовок `Content-Range: bytes <start>-<end>/<total>`.
+func parseContentRange(header string) (start, end, total int64, ok bool) {
+	rng, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, 0, false
+	}
+	rng, sTotal, found := strings.Cut(rng, "/")
+	if !found {
+		return 0, 0, 0, false
+	}
+	sStart, sEnd, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, 0, false
+	}
+
+	var err error
+	if start, err = strconv.ParseInt(sStart, 10, 64); err != nil {
+		return 0, 0, 0, false
+	}
+	if end, err = strconv.ParseInt(sEnd, 10, 64); err != nil {
+		return 0, 0, 0, false
+	}
+	if total, err = strconv.ParseInt(sTotal, 10, 64); err != nil {
+		return 0, 0, 0, false
+	}
+	if start < 0 || end < start || end >= total {
+		return 0, 0, 0, false
+	}
+	return start, end, total, true
+}
+
+// acceptLanguage первый язык из заголовка Accept-Language без региона и веса, например `en-US;q=0.8` -> `en`.
+func acceptLanguage(header string) string {
+	lang, _, _ := strings.Cut(header, ",")
+	lang, _, _ = strings.Cut(lang, ";")
+	lang, _, _ = strings.Cut(lang, "-")
+	return strings.ToLower(strings.TrimSpace(lang))
+}
+
+// setETag версия записи в заголовке ETag, например `"3"`.
+func setETag(c *gin.Context, version uint) {
+	c.Header("ETag", strconv.Quote(strconv.FormatUint(uint64(version), 10)))
+}
+
+// ifMatch версия из заголовка If-Match, 0 для `*` - любая версия. Без заголовка
+// возвращает 428, с тегом не из ETag - 412.
+func ifMatch(c *gin.Context) (uint, int) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return 0, http.StatusPreconditionRequired
+	}
+	if header == "*" {
+		return 0, 0
+	}
+	tag, err := strconv.Unquote(header)
+	if err != nil {
+		return 0, http.StatusPreconditionFailed
+	}
+	version, err := strconv.ParseUint(tag, 10, 64)
+	if err != nil || version == 0 {
+		return 0, http.StatusPreconditionFailed
+	}
+	return uint(version), 0
 }
 
-func (s *Server) saveFile(file *multipart.FileHeader, dst string) error {
-	path := s.uploadPath + "/" + dst
-	if _, err := os.Stat(path); err == nil {
-		return errsport.ErrFileAlreadyExists
+// setPublicCache разрешает кешировать публичный ответ. Данные в кеше могут отставать
+// от изменений на publicMaxAge.
+func (s *Server) setPublicCache(c *gin.Context) {
+	if s.publicMaxAge <= 0 {
+		c.Header("Cache-Control", "no-cache")
+		return
 	}
-	return tools.SaveUploadedFile(file, path)
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(s.publicMaxAge.Seconds())))
 }
 
-func (s Server) getFullUploadURL(name string) string {
-	if name == "" {
-		return ""
+// writeDelError ответ на ошибку удаления в корзину.
+func (s *Server) writeDelError(c *gin.Context, err error) {
+	if errors.Is(err, errstore.ErrNotFoundData) {
+		c.Writer.WriteHeader(http.StatusNoContent)
+		return
 	}
-	return s.baseURL + s.uploadURL + "/" + name
+	s.writeError(c, err)
 }
 
-func (s Server) isValidImgExtension(file *multipart.FileHeader) bool {
-	return strings.HasSuffix(file.Filename, ".png") ||
-		strings.HasSuffix(file.Filename, ".jpg") ||
-		strings.HasSuffix(file.Filename, ".jpeg") ||
-		strings.HasSuffix(file.Filename, ".gif")
+func (s *Server) newTrashItem(id uint, title string, deletedAt time.Time) tTrashItem {
+	purgeAt := s.sport.PurgeAt(deletedAt)
+	return tTrashItem{
+		ID:        id,
+		Title:     title,
+		DeletedAt: formatDateTime(&deletedAt),
+		PurgeAt:   formatDateTime(&purgeAt),
+	}
 }
 
 func (s Server) getPagination(c *gin.Context, count int) pagination {
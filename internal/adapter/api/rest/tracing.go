@@ -0,0 +1,32 @@
+package rest
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// tracingService имя сервера в атрибутах спанов запросов.
+const tracingService = "sportspace"
+
+// middlewareTracing начинает спан запроса с именем по шаблону маршрута. Контекст
+// трассировки берется из заголовка traceparent (W3C), спан передается дальше в
+// c.Request.Context(). Служебные маршруты не трассируются.
+func (s *Server) middlewareTracing() gin.HandlerFunc {
+	return otelgin.Middleware(tracingService, otelgin.WithGinFilter(func(c *gin.Context) bool {
+		path := c.FullPath()
+		return path != "/metrics" && path != "/ping" && !strings.HasPrefix(path, "/swagger/")
+	}))
+}
+
+// traceID идентификатор трассировки запроса для логов, если запрос трассируется.
+func traceID(c *gin.Context) zap.Field {
+	sc := trace.SpanContextFromContext(c.Request.Context())
+	if !sc.IsValid() {
+		return zap.Skip()
+	}
+	return zap.String("traceID", sc.TraceID().String())
+}
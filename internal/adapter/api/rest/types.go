@@ -1,10 +1,14 @@
 package rest
 
 import (
+	"strings"
 	"time"
 
+	"sport-space/internal/adapter/errsport"
 	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/sender"
 	"sport-space/pkg/email"
+	"sport-space/pkg/phone"
 )
 
 var (
@@ -66,67 +70,135 @@ type pagination struct {
 	EndRow       int  `json:"-"`
 }
 
+// tProblem ошибка в формате RFC 7807, Content-Type application/problem+json.
+type tProblem struct {
+	Type  string `json:"type" example:"urn:sportspace:problem:validation_failed"`
+	Title string `json:"title" example:"Ошибка в данных запроса"`
+	// Status дублирует статус ответа.
+	Status int `json:"status" example:"400"`
+	// Code машиночитаемый код ошибки, текст title зависит от Accept-Language.
+	Code     string `json:"code" example:"validation_failed"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance" example:"/api/v1/user/teams"`
+	// Errors ошибки отдельных полей при code=validation_failed.
+	Errors []tFieldError `json:"errors,omitempty"`
+	// Conflicts игроки, заявленные в другой команде, при code=roster_conflict.
+	Conflicts []tRosterConflict `json:"conflicts,omitempty"`
+	// MaxSize лимит в байтах при code=too_large.
+	MaxSize int64 `json:"maxSize,omitempty"`
+	// Offset сколько байт загрузки уже получено при code=upload_offset.
+	Offset *int64 `json:"offset,omitempty"`
+}
+
+type tFieldError struct {
+	Field   string `json:"field" example:"title"`
+	Code    string `json:"code" example:"required"`
+	Message string `json:"message" example:"Обязательное поле"`
+}
+
+// fieldErrors ошибки полей запроса, пустой - запрос корректен.
+type fieldErrors []tFieldError
+
 type tLoginResponse struct {
-	UserID uint   `json:"userID" example:"1"`
-	Error  string `json:"error"`
+	UserID uint `json:"userID" example:"1"`
 }
 
 type tAuthorization struct {
-	Email string `json:"email"`
+	Email string `json:"email" validate:"required_without=Phone,omitempty,sportemail"`
+	// Phone вход по номеру телефона вместо email.
+	Phone phone.Phone `json:"phone,omitempty" validate:"omitempty,phone"`
 	// Password string `json:"password"`
-	OTP string `json:"otp"`
+	OTP string `json:"otp" validate:"required"`
 }
 
 type tRequestOTP struct {
-	Email email.Email `json:"email"`
+	Email email.Email `json:"email" validate:"required_without=Phone,omitempty,sportemail"`
+	// Phone номер телефона вместо email, код придет в канал, выбранный при регистрации.
+	Phone phone.Phone `json:"phone,omitempty" validate:"omitempty,phone"`
+	// Channel канал для нового пользователя с номером телефона: sms (по умолчанию) или telegram.
+	Channel string `json:"channel,omitempty" enums:"sms,telegram" validate:"omitempty,oneof=sms telegram"`
+	// Language язык писем для нового пользователя, по умолчанию берется из Accept-Language.
+	Language string `json:"language,omitempty"`
 }
 
 type tUserResponse struct {
-	ID    uint   `json:"id"`
-	Email string `json:"email"`
+	ID             uint   `json:"id"`
+	Email          string `json:"email"`
+	Phone          string `json:"phone"`
+	Channel        string `json:"channel"`
+	TelegramLinked bool   `json:"telegramLinked"`
+	Language       string `json:"language"`
+}
+
+func newUserResponse(user *models.User) tUserResponse {
+	return tUserResponse{
+		ID:             user.ID,
+		Email:          user.Email,
+		Phone:          user.Phone,
+		Channel:        string(user.OTPChannel),
+		TelegramLinked: user.TelegramChatID != "",
+		Language:       user.Language,
+	}
 }
 
-type tCreateTournamentRequest struct {
-	Title             string     `json:"title" validate:"required"`
-	Description       string     `json:"description"`
-	Organization      string     `json:"organization"`
-	StartDate         *sportTime `json:"startDate" example:"2024-12-31T06:00:00+03:00" validate:"required"`
-	EndDate           *sportTime `json:"endDate" example:"2024-12-31T06:00:00+03:00" validate:"required"`
-	RegisterStartDate *sportTime `json:"registerStartDate" example:"2024-12-31T06:00:00+03:00"`
-	RegisterEndDate   *sportTime `json:"registerEndDate" example:"2024-12-31T06:00:00+03:00"`
-	LogoURL           string     `json:"logoUrl"`
+type tUpdUserRequest struct {
+	Language string `json:"language" validate:"required"`
 }
 
-func (tct tCreateTournamentRequest) IsValid() bool {
-	return !(tct.Title == "" || tct.StartDate == nil || tct.EndDate == nil)
+type tNotificationSettings struct {
+	// ApplicationStatus решение организатора по заявкам команд пользователя.
+	ApplicationStatus bool `json:"applicationStatus"`
+	// ApplicationSubmitted подача заявки на турниры пользователя.
+	ApplicationSubmitted bool `json:"applicationSubmitted"`
+	// ApplicationCanceled отмена заявки на турниры пользователя.
+	ApplicationCanceled bool `json:"applicationCanceled"`
 }
 
-type tUpdTournamentRequest struct {
-	Title             string     `json:"title"`
+// tTournamentRequest данные турнира при создании и изменении.
+type tTournamentRequest struct {
+	Title             string     `json:"title" validate:"required,max=255"`
 	Description       string     `json:"description"`
-	Organization      string     `json:"organization"`
-	StartDate         *sportTime `json:"startDate" example:"2024-12-31T06:00:00+03:00" validate:"required"`
-	EndDate           *sportTime `json:"endDate" example:"2024-12-31T06:00:00+03:00" validate:"required"`
-	RegisterStartDate *sportTime `json:"registerStartDate" example:"2024-12-31T06:00:00+03:00"`
-	RegisterEndDate   *sportTime `json:"registerEndDate" example:"2024-12-31T06:00:00+03:00"`
+	Organization      string     `json:"organization" validate:"max=255"`
+	StartDate         *sportTime `json:"startDate" example:"2024-12-31T06:00:00+03:00" validate:"required,sporttime"`
+	EndDate           *sportTime `json:"endDate" example:"2024-12-31T06:00:00+03:00" validate:"required,sporttime"`
+	RegisterStartDate *sportTime `json:"registerStartDate" example:"2024-12-31T06:00:00+03:00" validate:"omitempty,sporttime"`
+	RegisterEndDate   *sportTime `json:"registerEndDate" example:"2024-12-31T06:00:00+03:00" validate:"omitempty,sporttime"`
 	LogoURL           string     `json:"logoUrl"`
-}
-
-func (tutr tUpdTournamentRequest) IsValid() bool {
-	return !(tutr.Title == "" || tutr.StartDate == nil || tutr.EndDate == nil)
+	RosterFreezeDate  *sportTime `json:"rosterFreezeDate" example:"2024-12-31T06:00:00+03:00" validate:"omitempty,sporttime"`
+	// MaxLateSubstitutions лимит замен после заморозки состава, null - значение по умолчанию.
+	MaxLateSubstitutions *uint `json:"maxLateSubstitutions"`
 }
 
 type tTournamentResponse struct {
-	ID                uint   `json:"id"`
-	Title             string `json:"title"`
-	Description       string `json:"description"`
-	Organization      string `json:"organization"`
-	OrganizationID    uint   `json:"organizationID"`
-	StartDate         string `json:"startDate" example:"2024-12-31T06:00:00+03:00"`
-	EndDate           string `json:"endDate" example:"2024-12-31T06:00:00+03:00"`
-	RegisterStartDate string `json:"registerStartDate" example:"2024-12-31T06:00:00+03:00"`
-	RegisterEndDate   string `json:"registerEndDate" example:"2024-12-31T06:00:00+03:00"`
-	LogoURL           string `json:"logoUrl"`
+	ID                   uint   `json:"id"`
+	Title                string `json:"title"`
+	Description          string `json:"description"`
+	Organization         string `json:"organization"`
+	OrganizationID       uint   `json:"organizationID"`
+	StartDate            string `json:"startDate" example:"2024-12-31T06:00:00+03:00"`
+	EndDate              string `json:"endDate" example:"2024-12-31T06:00:00+03:00"`
+	RegisterStartDate    string `json:"registerStartDate" example:"2024-12-31T06:00:00+03:00"`
+	RegisterEndDate      string `json:"registerEndDate" example:"2024-12-31T06:00:00+03:00"`
+	LogoURL              string `json:"logoUrl"`
+	RosterFreezeDate     string `json:"rosterFreezeDate" example:"2024-12-31T06:00:00+03:00"`
+	MaxLateSubstitutions *uint  `json:"maxLateSubstitutions"`
+}
+
+func newTournamentResponse(t *models.Tournament) tTournamentResponse {
+	return tTournamentResponse{
+		ID:                   t.ID,
+		Title:                t.Title,
+		Description:          t.Description,
+		Organization:         t.Organization,
+		OrganizationID:       t.UserID,
+		StartDate:            formatDateTime(t.StartDate),
+		EndDate:              formatDateTime(t.EndDate),
+		RegisterStartDate:    formatDateTime(t.RegisterStartDate),
+		RegisterEndDate:      formatDateTime(t.RegisterEndDate),
+		LogoURL:              t.LogoURL,
+		RosterFreezeDate:     formatDateTime(t.RosterFreezeDate),
+		MaxLateSubstitutions: t.MaxLateSubstitutions,
+	}
 }
 
 type tGetTorunamentsResponse struct {
@@ -178,47 +250,43 @@ type tUpdTeamResponse struct {
 	CreatedAt string             `json:"createdAt"`
 }
 
-type tNewPlayerRequest struct {
-	FirstName  string     `json:"firstName"`
-	SecondName string     `json:"secondName"`
-	LastName   string     `json:"lastName"`
-	PhotoURL   string     `json:"photoUrl"`
-	BDay       *sportTime `json:"bDay" example:"2024-12-31T06:00:00+03:00"`
+// tPlayerRequest данные игрока при создании и изменении.
+type tPlayerRequest struct {
+	FirstName             string     `json:"firstName" validate:"required,max=100"`
+	SecondName            string     `json:"secondName" validate:"max=100"`
+	LastName              string     `json:"lastName" validate:"required,max=100"`
+	PhotoURL              string     `json:"photoUrl"`
+	MedicalCertificateURL string     `json:"medicalCertificateUrl"`
+	BDay                  *sportTime `json:"bDay" example:"2024-12-31T06:00:00+03:00" validate:"omitempty,sporttime"`
 }
 
-func (tnp tNewPlayerRequest) IsValid() bool {
-	return !(tnp.FirstName == "" || tnp.LastName == "")
+type tNewPlayerRequest struct {
+	tPlayerRequest
 }
 
 type tPlayerResponse struct {
-	ID         uint   `json:"id"`
-	FirstName  string `json:"firstName"`
-	SecondName string `json:"secondName"`
-	LastName   string `json:"lastName"`
-	PhotoURL   string `json:"photoUrl"`
-	BDay       string `json:"bDay" example:"2024-12-31T06:00:00+03:00"`
+	ID                    uint   `json:"id"`
+	FirstName             string `json:"firstName"`
+	SecondName            string `json:"secondName"`
+	LastName              string `json:"lastName"`
+	PhotoURL              string `json:"photoUrl"`
+	MedicalCertificateURL string `json:"medicalCertificateUrl"`
+	BDay                  string `json:"bDay" example:"2024-12-31T06:00:00+03:00"`
 }
 
 type tNewPlayerBatchRequest struct {
-	FirstName  string     `json:"firstName"`
-	SecondName string     `json:"secondName"`
-	LastName   string     `json:"lastName"`
-	PhotoURL   string     `json:"photoUrl"`
-	BDay       *sportTime `json:"bDay" example:"2024-12-31T06:00:00+03:00"`
-	ID         uint       `json:"id"`
-}
-
-func (tnp tNewPlayerBatchRequest) IsValid() bool {
-	return !(tnp.FirstName == "" || tnp.LastName == "")
+	tPlayerRequest
+	ID uint `json:"id"`
 }
 
 type tPlayerBatchResponse struct {
-	ID         uint   `json:"id"`
-	FirstName  string `json:"firstName"`
-	SecondName string `json:"secondName"`
-	LastName   string `json:"lastName"`
-	PhotoURL   string `json:"photoUrl"`
-	BDay       string `json:"bDay" example:"2024-12-31T06:00:00+03:00"`
+	ID                    uint   `json:"id"`
+	FirstName             string `json:"firstName"`
+	SecondName            string `json:"secondName"`
+	LastName              string `json:"lastName"`
+	PhotoURL              string `json:"photoUrl"`
+	MedicalCertificateURL string `json:"medicalCertificateUrl"`
+	BDay                  string `json:"bDay" example:"2024-12-31T06:00:00+03:00"`
 }
 
 type tNewPlayerBatchResponse struct {
@@ -232,15 +300,7 @@ type tGetPlayersResponse struct {
 }
 
 type tUpdatePlayerRequest struct {
-	FirstName  string     `json:"firstName"`
-	SecondName string     `json:"secondName"`
-	LastName   string     `json:"lastName"`
-	PhotoURL   string     `json:"photoUrl"`
-	BDay       *sportTime `json:"bDay" example:"2024-12-31T06:00:00+03:00"`
-}
-
-func (tup tUpdatePlayerRequest) IsValid() bool {
-	return !(tup.FirstName == "" || tup.LastName == "")
+	tPlayerRequest
 }
 
 type tApplication struct {
@@ -252,7 +312,7 @@ type tApplication struct {
 }
 
 type tNewApplicationRequest struct {
-	TournamentID uint   `json:"tournamentId"`
+	TournamentID uint   `json:"tournamentId" validate:"required"`
 	PlayerIDs    []uint `json:"playerIds"`
 }
 
@@ -262,6 +322,8 @@ type tNewApplicationResponse struct {
 	TournamentTitle string            `json:"tournamentTitle"`
 	Status          string            `json:"status"`
 	Players         []tPlayerResponse `json:"players"`
+	// Warnings игроки, заявленные в другой команде, при ROSTER_CONFLICT_POLICY=warn.
+	Warnings []tRosterConflict `json:"warnings,omitempty"`
 }
 
 type applicationStatus string
@@ -279,7 +341,7 @@ var applicationMapStatus = map[applicationStatus]models.ApplicationStatus{
 }
 
 type tUpdApplicationStatusRequest struct {
-	Status  *applicationStatus `json:"status" enums:"submit,cancel,draft"`
+	Status  *applicationStatus `json:"status" enums:"submit,cancel,draft" validate:"omitempty,oneof=submit cancel draft"`
 	Players *[]uint            `json:"playerIds"`
 }
 
@@ -289,6 +351,26 @@ type tUpdApplicationResponse struct {
 	TournamentTitle string            `json:"tournamentTitle"`
 	Status          string            `json:"status"`
 	Players         []tPlayerResponse `json:"players"`
+	// Warnings игроки, заявленные в другой команде, при ROSTER_CONFLICT_POLICY=warn.
+	Warnings []tRosterConflict `json:"warnings,omitempty"`
+}
+
+type tRosterConflict struct {
+	PlayerID      uint `json:"playerId"`
+	ApplicationID uint `json:"applicationId"`
+	TeamID        uint `json:"teamId"`
+}
+
+func newRosterConflicts(conflicts []errsport.RosterConflict) []tRosterConflict {
+	res := []tRosterConflict{}
+	for _, c := range conflicts {
+		res = append(res, tRosterConflict{
+			PlayerID:      c.PlayerID,
+			ApplicationID: c.ApplicationID,
+			TeamID:        c.TeamID,
+		})
+	}
+	return res
 }
 
 type tGetApplicationsTeamResponse struct {
@@ -336,7 +418,7 @@ var applicationTournamentMapStatus = map[applicationTournamentStatus]models.Appl
 }
 
 type tUpdTournamentApplicationRequest struct {
-	Status applicationTournamentStatus `json:"status" enums:"accept,reject"`
+	Status applicationTournamentStatus `json:"status" enums:"accept,reject" validate:"required,oneof=accept reject"`
 }
 
 type tUpdTournamentApplicationResponse struct {
@@ -346,7 +428,205 @@ type tUpdTournamentApplicationResponse struct {
 	Status    string `json:"status"`
 }
 
+// imageOriginal вариант изображения, адрес которого сохраняется в logoUrl и photoUrl.
+const imageOriginal = "original"
+
 type tHandlerUploadResponse struct {
 	URL      string `json:"url"`
 	Filename string `json:"filename"`
+	// Variants адреса вариантов изображения: thumbnail, medium, original.
+	Variants map[string]string `json:"variants"`
+}
+
+type tNewUploadSessionRequest struct {
+	Filename string `json:"filename"`
+	Size     int64  `json:"size" validate:"gt=0"`
+}
+
+type tUploadSessionResponse struct {
+	ID       string `json:"id"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	// Offset сколько байт получено, следующая часть начинается с него.
+	Offset       int64  `json:"offset"`
+	ChunkMaxSize int64  `json:"chunkMaxSize"`
+	ExpiresAt    string `json:"expiresAt"`
+	// Upload собранный документ, когда получены все части.
+	Upload *tHandlerUploadResponse `json:"upload"`
+}
+
+func newUploadSessionResponse(session *models.UploadSession, chunkMaxSize int64) tUploadSessionResponse {
+	res := tUploadSessionResponse{
+		ID:           session.ID,
+		Filename:     session.Filename,
+		Size:         session.Size,
+		Offset:       session.Received,
+		ChunkMaxSize: chunkMaxSize,
+		ExpiresAt:    formatDateTime(&session.ExpiresAt),
+	}
+	if session.Upload != nil {
+		res.Upload = &tHandlerUploadResponse{
+			URL:      session.Upload.URL,
+			Filename: session.Upload.Keys[imageOriginal],
+			Variants: session.Upload.Variants,
+		}
+	}
+	return res
+}
+
+type tNewRosterChangeRequest struct {
+	PlayerOutID uint   `json:"playerOutId" validate:"required"`
+	PlayerInID  uint   `json:"playerInId" validate:"required,nefield=PlayerOutID"`
+	Reason      string `json:"reason" validate:"max=1000"`
+}
+
+type tRosterChange struct {
+	ID            uint   `json:"id"`
+	ApplicationID uint   `json:"applicationId"`
+	PlayerOutID   uint   `json:"playerOutId"`
+	PlayerInID    uint   `json:"playerInId"`
+	Reason        string `json:"reason"`
+	Status        string `json:"status"`
+	StatusDate    string `json:"statusDate" example:"2024-12-31T06:00:00+03:00"`
+	CreatedAt     string `json:"createdAt" example:"2024-12-31T06:00:00+03:00"`
+	// Warnings игроки, заявленные в другой команде, при ROSTER_CONFLICT_POLICY=warn.
+	Warnings []tRosterConflict `json:"warnings,omitempty"`
+}
+
+func newRosterChangeResponse(change *models.RosterChange) tRosterChange {
+	return tRosterChange{
+		ID:            change.ID,
+		ApplicationID: change.ApplicationID,
+		PlayerOutID:   change.PlayerOutID,
+		PlayerInID:    change.PlayerInID,
+		Reason:        change.Reason,
+		Status:        string(change.Status),
+		StatusDate:    formatDateTime(&change.StatusDate),
+		CreatedAt:     formatDateTime(&change.CreatedAt),
+	}
+}
+
+type tGetRosterChangesResponse struct {
+	Data []tRosterChange `json:"data"`
+}
+
+type rosterChangeStatus string
+
+var (
+	approve rosterChangeStatus = "approve"
+	decline rosterChangeStatus = "decline"
+)
+
+var rosterChangeMapStatus = map[rosterChangeStatus]models.RosterChangeStatus{
+	approve: models.RosterChangeApproved,
+	decline: models.RosterChangeDeclined,
+}
+
+type tUpdRosterChangeRequest struct {
+	Status rosterChangeStatus `json:"status" enums:"approve,decline" validate:"required,oneof=approve decline"`
+}
+
+type tOutboxMessage struct {
+	ID            uint   `json:"id"`
+	To            string `json:"to"`
+	Template      string `json:"template"`
+	Subject       string `json:"subject"`
+	Status        string `json:"status" enums:"pending,sent,dead"`
+	Attempts      uint   `json:"attempts"`
+	NextAttemptAt string `json:"nextAttemptAt" example:"2024-12-31T06:00:00+03:00"`
+	// ExpiresAt срок отправки, после него письмо переносится в dead.
+	ExpiresAt string `json:"expiresAt,omitempty" example:"2024-12-31T06:00:00+03:00"`
+	LastError string `json:"lastError"`
+	SentAt    string `json:"sentAt" example:"2024-12-31T06:00:00+03:00"`
+	CreatedAt string `json:"createdAt" example:"2024-12-31T06:00:00+03:00"`
+}
+
+func newOutboxMessageResponse(msg *models.OutboxMessage) tOutboxMessage {
+	return tOutboxMessage{
+		ID:            msg.ID,
+		To:            msg.To,
+		Template:      msg.Template,
+		Subject:       msg.Subject,
+		Status:        string(msg.Status),
+		Attempts:      msg.Attempts,
+		NextAttemptAt: formatDateTime(&msg.NextAttemptAt),
+		ExpiresAt:     formatDateTime(msg.ExpiresAt),
+		LastError:     msg.LastError,
+		SentAt:        formatDateTime(msg.SentAt),
+		CreatedAt:     formatDateTime(&msg.CreatedAt),
+	}
+}
+
+type tGetOutboxResponse struct {
+	Pagination pagination       `json:"pagination"`
+	Data       []tOutboxMessage `json:"data"`
+}
+
+type tMail struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html,omitempty"`
+	Date    string `json:"date"`
+}
+
+func newMailResponse(msg *sender.Message) tMail {
+	return tMail{
+		From:    msg.From,
+		To:      msg.To,
+		Subject: msg.Subject,
+		Text:    msg.Text,
+		HTML:    msg.HTML,
+		Date:    formatDateTime(&msg.Date),
+	}
+}
+
+type tGetMailsResponse struct {
+	Pagination pagination `json:"pagination"`
+	Data       []tMail    `json:"data"`
+}
+
+type tTelegramUser struct {
+	ID           int64  `json:"id"`
+	LanguageCode string `json:"language_code"`
+}
+
+type tTelegramChat struct {
+	ID int64 `json:"id"`
+}
+
+type tTelegramContact struct {
+	PhoneNumber string `json:"phone_number"`
+	UserID      int64  `json:"user_id"`
+}
+
+type tTelegramMessage struct {
+	From    *tTelegramUser    `json:"from"`
+	Chat    tTelegramChat     `json:"chat"`
+	Contact *tTelegramContact `json:"contact"`
+}
+
+// tTelegramUpdate нужные поля Update из Telegram Bot API.
+type tTelegramUpdate struct {
+	UpdateID int64             `json:"update_id"`
+	Message  *tTelegramMessage `json:"message"`
+}
+
+type tTrashItem struct {
+	ID        uint   `json:"id"`
+	Title     string `json:"title"`
+	DeletedAt string `json:"deletedAt"`
+	PurgeAt   string `json:"purgeAt"`
+}
+
+type tTrashResponse struct {
+	Tournaments []tTrashItem `json:"tournaments"`
+	Teams       []tTrashItem `json:"teams"`
+	Players     []tTrashItem `json:"players"`
+}
+
+// playerTitle имя игрока для списков.
+func playerTitle(p *models.Player) string {
+	return strings.TrimSpace(p.LastName + " " + p.FirstName)
 }
@@ -0,0 +1,154 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"sport-space/pkg/email"
+	"sport-space/pkg/phone"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// validate проверяет запросы по тегам validate. Кроме встроенных правил есть:
+// sportemail - email по pkg/email, phone - номер по pkg/phone, sporttime - дата в формате
+// defaultDateTimeFormat. Имена полей в ошибках берутся из тега json.
+var validate = newValidator()
+
+// Правила тегов, для которых в ответе свой код ошибки поля.
+var fieldCodes = map[string]string{
+	"required":         fieldRequired,
+	"required_without": fieldRequired,
+	"max":              fieldTooLong,
+	"min":              fieldTooShort,
+	"sportemail":       fieldEmail,
+	"phone":            fieldPhone,
+	"sporttime":        fieldDate,
+	"dateorder":        fieldDateOrder,
+}
+
+// embeddedName имя встроенной структуры запроса (tPlayerRequest) в пути поля, в JSON ее
+// поля лежат на уровне внешней структуры, поэтому из пути оно удаляется.
+const embeddedName = "~"
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		if f.Anonymous {
+			return embeddedName
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	// Пустая дата считается отсутствующей, как и в sportTime.DateTime.
+	v.RegisterCustomTypeFunc(func(f reflect.Value) any {
+		if st := f.Interface().(sportTime); st != "" {
+			return string(st)
+		}
+		return nil
+	}, sportTime(""))
+
+	must := func(err error) {
+		if err != nil {
+			panic(err)
+		}
+	}
+	must(v.RegisterValidation("sportemail", func(fl validator.FieldLevel) bool {
+		return email.Email(fl.Field().String()).IsValid()
+	}))
+	must(v.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		return phone.Phone(fl.Field().String()).IsValid()
+	}))
+	must(v.RegisterValidation("sporttime", func(fl validator.FieldLevel) bool {
+		_, err := time.Parse(defaultDateTimeFormat, fl.Field().String())
+		return err == nil
+	}))
+	v.RegisterStructValidation(validateTournamentDates, tTournamentRequest{})
+	return v
+}
+
+// validateTournamentDates порядок дат турнира: окончание не раньше начала, регистрация
+// заканчивается не раньше, чем начинается, и не позже окончания турнира.
+func validateTournamentDates(sl validator.StructLevel) {
+	t := sl.Current().Interface().(tTournamentRequest)
+	before := func(a, b *sportTime) bool {
+		at, bt := a.DateTime(), b.DateTime()
+		return at != nil && bt != nil && !at.IsZero() && !bt.IsZero() && bt.Before(*at)
+	}
+	if before(t.StartDate, t.EndDate) {
+		sl.ReportError(t.EndDate, "endDate", "EndDate", "dateorder", "startDate")
+	}
+	if before(t.RegisterStartDate, t.RegisterEndDate) {
+		sl.ReportError(t.RegisterEndDate, "registerEndDate", "RegisterEndDate", "dateorder", "registerStartDate")
+	}
+	if before(t.RegisterEndDate, t.EndDate) {
+		sl.ReportError(t.RegisterEndDate, "registerEndDate", "RegisterEndDate", "dateorder", "endDate")
+	}
+}
+
+// validationErrors все ошибки полей из ошибки validator. Путь поля строится по именам
+// json без имени типа запроса, у элементов массива в начале индекс: [0].firstName.
+func validationErrors(err error) fieldErrors {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil
+	}
+
+	res := fieldErrors{}
+	for _, fe := range verrs {
+		field := strings.ReplaceAll(fe.Namespace(), embeddedName+".", "")
+		if i := strings.IndexAny(field, ".["); i >= 0 && field[i] == '.' {
+			field = field[i+1:]
+		}
+		code, ok := fieldCodes[fe.Tag()]
+		if !ok {
+			code = fieldInvalid
+		}
+		res = append(res, tFieldError{Field: field, Code: code})
+	}
+	return res
+}
+
+// bindJSON читает тело запроса в v и проверяет его по тегам validate. При ошибке ответ
+// уже отправлен (400 со всеми ошибками полей) и возвращается false.
+func (s *Server) bindJSON(c *gin.Context, v any) bool {
+	bBody, statusCode := s.readBody(c)
+	if statusCode > 0 {
+		s.writeStatus(c, statusCode)
+		return false
+	}
+
+	if err := json.Unmarshal(bBody, v); err != nil {
+		s.log.Debug("failed parse body", zap.Error(err))
+		s.invalidBody(c)
+		return false
+	}
+
+	var err error
+	if reflect.Indirect(reflect.ValueOf(v)).Kind() == reflect.Slice {
+		err = validate.Var(v, "dive")
+	} else {
+		err = validate.Struct(v)
+	}
+	if err != nil {
+		errs := validationErrors(err)
+		if len(errs) == 0 {
+			s.log.Error("failed validate request", zap.Error(err))
+			s.problem(c, http.StatusInternalServerError, codeInternal)
+			return false
+		}
+		s.invalid(c, errs...)
+		return false
+	}
+	return true
+}
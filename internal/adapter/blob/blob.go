@@ -0,0 +1,44 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"sport-space/internal/adapter/blob/local"
+	"sport-space/internal/adapter/blob/s3"
+)
+
+// Blob хранилище загруженных файлов.
+type Blob interface {
+	// Put сохраняет содержимое r размером size под ключом key.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open открывает файл на чтение, вызывающий закрывает его.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// URL публичный адрес файла.
+	URL(key string) string
+}
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+type Config struct {
+	Driver string `env:"BLOB_DRIVER" envDefault:"local"`
+	Local  local.Config
+	S3     s3.Config
+}
+
+// New создает хранилище, baseURL - адрес API, от которого строятся ссылки на локальные файлы.
+func New(ctx context.Context, cfg Config, baseURL string) (Blob, error) {
+	switch cfg.Driver {
+	case DriverLocal, "":
+		return local.New(cfg.Local, baseURL)
+	case DriverS3:
+		return s3.New(ctx, cfg.S3)
+	default:
+		return nil, fmt.Errorf("blob driver `%s` is not supported", cfg.Driver)
+	}
+}
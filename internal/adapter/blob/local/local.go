@@ -0,0 +1,114 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Config struct {
+	Path    string `env:"UPLOAD_PATH" envDefault:"/uploads"`
+	URLPath string `env:"UPLOAD_URL_PATH" envDefault:"/uploads"`
+}
+
+// Local хранит файлы на диске и сам раздает их по URLPath,
+// подходит только для запуска одной реплики API.
+type Local struct {
+	root    string
+	urlPath string
+	baseURL string
+	files   http.Handler
+}
+
+func New(cfg Config, baseURL string) (*Local, error) {
+	if err := os.MkdirAll(cfg.Path, 0o750); err != nil {
+		return nil, fmt.Errorf("failed create upload dir: %w", err)
+	}
+
+	urlPath := "/" + strings.Trim(cfg.URLPath, "/")
+	return &Local{
+		root:    cfg.Path,
+		urlPath: urlPath,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		files:   http.StripPrefix(urlPath, http.FileServer(http.Dir(cfg.Path))),
+	}, nil
+}
+
+func (l *Local) path(key string) (string, error) {
+	path := filepath.Join(l.root, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, filepath.Clean(l.root)+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid key `%s`", key)
+	}
+	return path, nil
+}
+
+// Put пишет во временный файл и переименовывает, чтобы файл не был виден недописанным.
+func (l *Local) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed create dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed create file: %w", err)
+	}
+	_, err = io.Copy(tmp, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed write file: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed save file: %w", err)
+	}
+	return nil
+}
+
+func (l *Local) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed open file: %w", err)
+	}
+	return f, nil
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed delete file: %w", err)
+	}
+	return nil
+}
+
+func (l *Local) URL(key string) string {
+	return l.baseURL + l.urlPath + "/" + key
+}
+
+// URLPath путь, по которому сервер раздает файлы.
+func (l *Local) URLPath() string {
+	return l.urlPath
+}
+
+func (l *Local) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.files.ServeHTTP(w, r)
+}
@@ -0,0 +1,100 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type Config struct {
+	Endpoint  string `env:"S3_ENDPOINT" envDefault:""`
+	AccessKey string `env:"S3_ACCESS_KEY" envDefault:""`
+	SecretKey string `env:"S3_SECRET_KEY" envDefault:""`
+	Bucket    string `env:"S3_BUCKET" envDefault:"uploads"`
+	Region    string `env:"S3_REGION" envDefault:""`
+	UseSSL    bool   `env:"S3_USE_SSL" envDefault:"true"`
+	// PublicURL адрес, по которому клиенты получают файлы (CDN или публичный бакет),
+	// по умолчанию <endpoint>/<bucket>.
+	PublicURL string `env:"S3_PUBLIC_URL" envDefault:""`
+}
+
+// S3 хранит файлы в S3-совместимом хранилище (AWS S3, MinIO, Yandex Object Storage и т.п.).
+type S3 struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+func New(ctx context.Context, cfg Config) (*S3, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("s3 endpoint is empty")
+	}
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed create s3 client: %w", err)
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed check bucket: %w", err)
+	}
+	if !exists {
+		err = client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region})
+		if err != nil {
+			return nil, fmt.Errorf("failed create bucket: %w", err)
+		}
+	}
+
+	publicURL := cfg.PublicURL
+	if publicURL == "" {
+		scheme := "https"
+		if !cfg.UseSSL {
+			scheme = "http"
+		}
+		publicURL = (&url.URL{Scheme: scheme, Host: cfg.Endpoint, Path: "/" + cfg.Bucket}).String()
+	}
+
+	return &S3{
+		client:    client,
+		bucket:    cfg.Bucket,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+	}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return fmt.Errorf("failed put object: %w", err)
+	}
+	return nil
+}
+
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed get object: %w", err)
+	}
+	return obj, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("failed remove object: %w", err)
+	}
+	return nil
+}
+
+func (s *S3) URL(key string) string {
+	return s.publicURL + "/" + key
+}
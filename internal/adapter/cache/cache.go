@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sport-space/internal/adapter/cache/lru"
+	"sport-space/internal/adapter/cache/redis"
+)
+
+// Cache хранилище закешированных значений.
+type Cache interface {
+	// Get возвращает значение ключа, ok=false если его нет или истек срок.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set сохраняет значение на ttl, 0 - без срока.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+const (
+	DriverNone  = "none"
+	DriverLRU   = "lru"
+	DriverRedis = "redis"
+)
+
+type Config struct {
+	// Driver lru - в памяти процесса, redis - общий для всех реплик, none - без кеша.
+	Driver string `env:"CACHE_DRIVER" envDefault:"lru"`
+	// TTL сколько хранится прочитанное значение, записи сбрасывают кеш раньше.
+	TTL   time.Duration `env:"CACHE_TTL" envDefault:"30s"`
+	LRU   lru.Config
+	Redis redis.Config
+}
+
+// New создает кеш, при DriverNone возвращает nil.
+func New(ctx context.Context, cfg Config) (Cache, error) {
+	switch cfg.Driver {
+	case DriverLRU, "":
+		return lru.New(cfg.LRU), nil
+	case DriverRedis:
+		return redis.New(ctx, cfg.Redis)
+	case DriverNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("cache driver `%s` is not supported", cfg.Driver)
+	}
+}
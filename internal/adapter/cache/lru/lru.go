@@ -0,0 +1,79 @@
+package lru
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	Size int `env:"CACHE_LRU_SIZE" envDefault:"1000"`
+}
+
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// LRU кеш в памяти процесса, при переполнении вытесняет давно не читанные значения.
+type LRU struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func New(cfg Config) *LRU {
+	size := cfg.Size
+	if size <= 0 {
+		size = 1
+	}
+	return &LRU{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (l *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*entry)
+	if !e.expiresAt.IsZero() && time.Now().After(e.expiresAt) {
+		l.order.Remove(el)
+		delete(l.entries, key)
+		return nil, false, nil
+	}
+	l.order.MoveToFront(el)
+	return e.value, true, nil
+}
+
+func (l *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e := &entry{key: key, value: value}
+	if ttl > 0 {
+		e.expiresAt = time.Now().Add(ttl)
+	}
+	if el, ok := l.entries[key]; ok {
+		el.Value = e
+		l.order.MoveToFront(el)
+		return nil
+	}
+
+	l.entries[key] = l.order.PushFront(e)
+	for l.order.Len() > l.size {
+		el := l.order.Back()
+		l.order.Remove(el)
+		delete(l.entries, el.Value.(*entry).key)
+	}
+	return nil
+}
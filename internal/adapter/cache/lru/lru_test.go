@@ -0,0 +1,79 @@
+package lru
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func get(t *testing.T, l *LRU, key string) (string, bool) {
+	t.Helper()
+	value, ok, err := l.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return string(value), ok
+}
+
+func set(t *testing.T, l *LRU, key, value string, ttl time.Duration) {
+	t.Helper()
+	if err := l.Set(context.Background(), key, []byte(value), ttl); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestEviction(t *testing.T) {
+	l := New(Config{Size: 2})
+	set(t, l, "a", "1", 0)
+	set(t, l, "b", "2", 0)
+
+	// чтение a делает давно не читанным b, его и вытесняет c
+	if _, ok := get(t, l, "a"); !ok {
+		t.Fatal("a is missing")
+	}
+	set(t, l, "c", "3", 0)
+
+	if _, ok := get(t, l, "b"); ok {
+		t.Fatal("b is not evicted")
+	}
+	for key, want := range map[string]string{"a": "1", "c": "3"} {
+		if got, ok := get(t, l, key); !ok || got != want {
+			t.Fatalf("%s: expected %q, got %q (%v)", key, want, got, ok)
+		}
+	}
+}
+
+func TestOverwrite(t *testing.T) {
+	l := New(Config{Size: 2})
+	set(t, l, "a", "1", 0)
+	set(t, l, "a", "2", 0)
+	set(t, l, "b", "3", 0)
+
+	if got, ok := get(t, l, "a"); !ok || got != "2" {
+		t.Fatalf("expected overwritten value, got %q (%v)", got, ok)
+	}
+	if l.order.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", l.order.Len())
+	}
+}
+
+func TestTTL(t *testing.T) {
+	l := New(Config{Size: 10})
+	set(t, l, "short", "1", 20*time.Millisecond)
+	set(t, l, "forever", "2", 0)
+
+	if _, ok := get(t, l, "short"); !ok {
+		t.Fatal("value expired too early")
+	}
+	time.Sleep(40 * time.Millisecond)
+
+	if _, ok := get(t, l, "short"); ok {
+		t.Fatal("expired value is returned")
+	}
+	if _, ok := l.entries["short"]; ok {
+		t.Fatal("expired value is not removed")
+	}
+	if _, ok := get(t, l, "forever"); !ok {
+		t.Fatal("value without ttl expired")
+	}
+}
@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type Config struct {
+	// Addr адрес Redis или совместимого сервера (KeyDB, Valkey, miniredis в тестах).
+	Addr     string `env:"REDIS_ADDR" envDefault:"localhost:6379"`
+	Password string `env:"REDIS_PASSWORD" envDefault:""`
+	DB       int    `env:"REDIS_DB" envDefault:"0"`
+	// Prefix общий префикс ключей, чтобы делить сервер с другими сервисами.
+	Prefix string `env:"REDIS_PREFIX" envDefault:"sportspace:"`
+}
+
+// Redis кеш, общий для всех реплик API.
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+func New(ctx context.Context, cfg Config) (*Redis, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed connect redis: %w", err)
+	}
+	return &Redis{client: client, prefix: cfg.Prefix}, nil
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed get key: %w", err)
+	}
+	return value, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := r.client.Set(ctx, r.prefix+key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed set key: %w", err)
+	}
+	return nil
+}
+
+func (r *Redis) Close() error {
+	if err := r.client.Close(); err != nil {
+		return fmt.Errorf("failed close redis: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newRedis(t *testing.T) (*Redis, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	r, err := New(context.Background(), Config{Addr: mr.Addr(), Prefix: "test:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	return r, mr
+}
+
+func TestGetSet(t *testing.T) {
+	ctx := context.Background()
+	r, mr := newRedis(t)
+
+	if _, ok, err := r.Get(ctx, "key"); err != nil || ok {
+		t.Fatalf("expected miss, got ok=%v err=%v", ok, err)
+	}
+	if err := r.Set(ctx, "key", []byte("value"), 0); err != nil {
+		t.Fatal(err)
+	}
+	value, ok, err := r.Get(ctx, "key")
+	if err != nil || !ok || string(value) != "value" {
+		t.Fatalf("unexpected value %q ok=%v err=%v", value, ok, err)
+	}
+
+	// ключи хранятся с префиксом
+	if !mr.Exists("test:key") {
+		t.Fatalf("key without prefix: %v", mr.Keys())
+	}
+}
+
+func TestTTL(t *testing.T) {
+	ctx := context.Background()
+	r, mr := newRedis(t)
+
+	if err := r.Set(ctx, "key", []byte("value"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	mr.FastForward(2 * time.Minute)
+	if _, ok, err := r.Get(ctx, "key"); err != nil || ok {
+		t.Fatalf("expected expired key, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestUnavailable(t *testing.T) {
+	ctx := context.Background()
+	r, mr := newRedis(t)
+
+	mr.Close()
+	if _, _, err := r.Get(ctx, "key"); err == nil {
+		t.Fatal("expected error from closed server")
+	}
+}
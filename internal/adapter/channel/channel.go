@@ -0,0 +1,89 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// Провайдеры каналов доставки.
+const (
+	ProviderGateway = "gateway"
+	ProviderBot     = "bot"
+	ProviderFake    = "fake"
+)
+
+var ErrProviderNotSupported = errors.New("channel provider is not supported")
+
+// Provider доставляет код авторизации получателю: номер телефона для SMS, chat id для Telegram.
+type Provider interface {
+	SendCode(ctx context.Context, to, language, code string) error
+}
+
+// TelegramProvider бот дополнительно умеет попросить пользователя поделиться номером телефона.
+type TelegramProvider interface {
+	Provider
+	RequestContact(ctx context.Context, chatID, language string) error
+}
+
+// Channels настроенные каналы доставки, nil - канал выключен.
+type Channels struct {
+	SMS      Provider
+	Telegram TelegramProvider
+}
+
+type options struct {
+	log *zap.Logger
+}
+
+type option func(o *options)
+
+func SetLogger(l *zap.Logger) option {
+	return func(o *options) {
+		o.log = l
+	}
+}
+
+func New(cfg Config, opts ...option) (*Channels, error) {
+	o := &options{log: zap.NewNop()}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	client := &http.Client{Timeout: cfg.Timeout}
+	ch := &Channels{}
+
+	switch cfg.SMSProvider {
+	case "":
+	case ProviderGateway:
+		if cfg.SMSGatewayURL == "" {
+			return nil, errors.New("sms gateway url is empty")
+		}
+		ch.SMS = NewSMSGateway(client, cfg.SMSGatewayURL, cfg.SMSGatewayToken, cfg.SMSSender)
+	case ProviderFake:
+		ch.SMS = NewFake("sms", o.log)
+	default:
+		return nil, fmt.Errorf("%w: sms %s", ErrProviderNotSupported, cfg.SMSProvider)
+	}
+
+	switch cfg.TelegramProvider {
+	case "":
+	case ProviderBot:
+		if cfg.TelegramBotToken == "" {
+			return nil, errors.New("telegram bot token is empty")
+		}
+		if cfg.TelegramWebhookSecret == "" {
+			return nil, errors.New("telegram webhook secret is empty")
+		}
+		ch.Telegram = NewTelegramBot(client, cfg.TelegramAPIURL, cfg.TelegramBotToken)
+	case ProviderFake:
+		ch.Telegram = NewFake("telegram", o.log)
+	default:
+		return nil, fmt.Errorf("%w: telegram %s", ErrProviderNotSupported, cfg.TelegramProvider)
+	}
+
+	return ch, nil
+}
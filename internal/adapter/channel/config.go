@@ -0,0 +1,17 @@
+package channel
+
+import "time"
+
+type Config struct {
+	SMSProvider     string `env:"SMS_PROVIDER" envDefault:""`
+	SMSGatewayURL   string `env:"SMS_GATEWAY_URL" envDefault:""`
+	SMSGatewayToken string `env:"SMS_GATEWAY_TOKEN" envDefault:""`
+	SMSSender       string `env:"SMS_SENDER" envDefault:"SportSpace"`
+
+	TelegramProvider      string `env:"TELEGRAM_PROVIDER" envDefault:""`
+	TelegramBotToken      string `env:"TELEGRAM_BOT_TOKEN" envDefault:""`
+	TelegramAPIURL        string `env:"TELEGRAM_API_URL" envDefault:"https://api.telegram.org"`
+	TelegramWebhookSecret string `env:"TELEGRAM_WEBHOOK_SECRET" envDefault:""`
+
+	Timeout time.Duration `env:"CHANNEL_TIMEOUT" envDefault:"10s"`
+}
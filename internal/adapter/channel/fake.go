@@ -0,0 +1,45 @@
+package channel
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Fake канал для разработки и тестов: коды пишутся в лог и запоминаются по получателю.
+type Fake struct {
+	name  string
+	log   *zap.Logger
+	mu    sync.RWMutex
+	codes map[string]string
+}
+
+func NewFake(name string, log *zap.Logger) *Fake {
+	return &Fake{name: name, log: log, codes: map[string]string{}}
+}
+
+func (f *Fake) SendCode(_ context.Context, to, language, code string) error {
+	f.mu.Lock()
+	f.codes[to] = code
+	f.mu.Unlock()
+
+	f.log.Info("fake channel code", zap.String("channel", f.name), zap.String("to", to),
+		zap.String("language", language), zap.String("code", code))
+	return nil
+}
+
+func (f *Fake) RequestContact(_ context.Context, chatID, language string) error {
+	f.log.Info("fake channel contact request", zap.String("channel", f.name), zap.String("chatID", chatID),
+		zap.String("language", language))
+	return nil
+}
+
+// LastCode последний отправленный получателю код.
+func (f *Fake) LastCode(to string) (string, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	code, ok := f.codes[to]
+	return code, ok
+}
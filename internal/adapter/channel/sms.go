@@ -0,0 +1,57 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// SMSGateway отправляет SMS через HTTP шлюз: POST JSON {"from", "to", "text"}
+// с заголовком Authorization: Bearer <token>, успешный ответ - 2xx.
+type SMSGateway struct {
+	client *http.Client
+	url    string
+	token  string
+	from   string
+}
+
+func NewSMSGateway(client *http.Client, url, token, from string) *SMSGateway {
+	return &SMSGateway{client: client, url: url, token: token, from: from}
+}
+
+type smsRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Text string `json:"text"`
+}
+
+func (g *SMSGateway) SendCode(ctx context.Context, to, language, code string) error {
+	body, err := json.Marshal(smsRequest{From: g.from, To: to, Text: codeText(language, code)})
+	if err != nil {
+		return fmt.Errorf("failed marshal sms: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed create sms request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed send sms: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sms gateway responded %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+// TelegramBot отправляет сообщения через Telegram Bot API.
+type TelegramBot struct {
+	client *http.Client
+	apiURL string
+	token  string
+}
+
+func NewTelegramBot(client *http.Client, apiURL, token string) *TelegramBot {
+	return &TelegramBot{client: client, apiURL: strings.TrimSuffix(apiURL, "/"), token: token}
+}
+
+type tgKeyboardButton struct {
+	Text           string `json:"text"`
+	RequestContact bool   `json:"request_contact,omitempty"`
+}
+
+type tgReplyMarkup struct {
+	Keyboard        [][]tgKeyboardButton `json:"keyboard"`
+	OneTimeKeyboard bool                 `json:"one_time_keyboard"`
+	ResizeKeyboard  bool                 `json:"resize_keyboard"`
+}
+
+type tgSendMessage struct {
+	ChatID      string         `json:"chat_id"`
+	Text        string         `json:"text"`
+	ReplyMarkup *tgReplyMarkup `json:"reply_markup,omitempty"`
+}
+
+type tgResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+func (t *TelegramBot) SendCode(ctx context.Context, chatID, language, code string) error {
+	return t.sendMessage(ctx, &tgSendMessage{ChatID: chatID, Text: codeText(language, code)})
+}
+
+// RequestContact отправляет кнопку, по которой пользователь делится своим номером телефона.
+func (t *TelegramBot) RequestContact(ctx context.Context, chatID, language string) error {
+	return t.sendMessage(ctx, &tgSendMessage{
+		ChatID: chatID,
+		Text:   localize(contactTexts, language),
+		ReplyMarkup: &tgReplyMarkup{
+			Keyboard: [][]tgKeyboardButton{{{
+				Text:           localize(contactButtons, language),
+				RequestContact: true,
+			}}},
+			OneTimeKeyboard: true,
+			ResizeKeyboard:  true,
+		},
+	})
+}
+
+func (t *TelegramBot) sendMessage(ctx context.Context, msg *tgSendMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed marshal telegram message: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", t.apiURL, t.token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed create telegram request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// в ошибке клиента есть url с токеном бота
+		return fmt.Errorf("failed send telegram message: %w", unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+
+	res := tgResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("failed parse telegram response %d: %w", resp.StatusCode, err)
+	}
+	if !res.OK {
+		return fmt.Errorf("telegram responded %d: %s", resp.StatusCode, res.Description)
+	}
+	return nil
+}
+
+func unwrapURLError(err error) error {
+	var urlErr *neturl.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
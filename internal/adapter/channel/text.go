@@ -0,0 +1,30 @@
+package channel
+
+import "fmt"
+
+// тексты сообщений, для неизвестного языка используется ru.
+var (
+	codeTexts = map[string]string{
+		"ru": "SportSpace: код входа %s",
+		"en": "SportSpace sign-in code: %s",
+	}
+	contactTexts = map[string]string{
+		"ru": "Чтобы получать коды входа в SportSpace, поделитесь номером телефона.",
+		"en": "Share your phone number to receive SportSpace sign-in codes.",
+	}
+	contactButtons = map[string]string{
+		"ru": "Отправить номер",
+		"en": "Share phone number",
+	}
+)
+
+func localize(texts map[string]string, language string) string {
+	if text, ok := texts[language]; ok {
+		return text
+	}
+	return texts["ru"]
+}
+
+func codeText(language, code string) string {
+	return fmt.Sprintf(localize(codeTexts, language), code)
+}
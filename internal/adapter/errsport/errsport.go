@@ -1,9 +1,47 @@
 package errsport
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrFileAlreadyExists = errors.New("file already exists")
 	ErrConflictData      = errors.New("conflict data")
 	ErrNotFoundData      = errors.New("not found data")
+	ErrRosterConflict    = errors.New("player already in another application of tournament")
+	ErrRosterFrozen      = errors.New("roster is frozen, use roster change request")
+	ErrRosterNotFrozen   = errors.New("roster is not frozen, change application players directly")
+	ErrSubstitutionLimit = errors.New("late substitutions limit reached")
+	ErrUploadQuota       = errors.New("upload quota exceeded")
+	ErrUploadNotOwned    = errors.New("file url is not an upload of the user")
+	ErrUploadOffset      = errors.New("chunk offset does not match received size")
+	ErrUploadExpired     = errors.New("upload session expired")
+	ErrUploadChunkSize   = errors.New("chunk size does not match content length")
+	ErrDocumentFormat    = errors.New("unsupported document format, allowed pdf, jpeg, png")
+	// ErrRunningApplication запись нельзя удалить, пока идет турнир с ее принятой заявкой.
+	ErrRunningApplication = errors.New("accepted application in a running tournament")
+	// ErrIdempotencyKeyReused ключ Idempotency-Key уже использован для другого запроса.
+	ErrIdempotencyKeyReused = errors.New("idempotency key is used with another request")
+	// ErrIdempotencyInProgress запрос с этим ключом Idempotency-Key еще выполняется.
+	ErrIdempotencyInProgress = errors.New("request with idempotency key is in progress")
 )
+
+// RosterConflict игрок уже заявлен в другой заявке турнира.
+type RosterConflict struct {
+	PlayerID      uint
+	ApplicationID uint
+	TeamID        uint
+}
+
+type RosterConflictError struct {
+	Conflicts []RosterConflict
+}
+
+func (e *RosterConflictError) Error() string {
+	return fmt.Sprintf("%s: %d conflicts", ErrRosterConflict, len(e.Conflicts))
+}
+
+func (e *RosterConflictError) Unwrap() error {
+	return ErrRosterConflict
+}
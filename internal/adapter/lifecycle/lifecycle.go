@@ -0,0 +1,122 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Lifecycle останавливает компоненты сервиса в порядке, обратном запуску: сначала
+// сервер перестает принимать запросы и дожидается текущих, затем фоновые задачи,
+// очередь писем и в конце хранилище, которым пользуются все остальные.
+type Lifecycle struct {
+	log   *zap.Logger
+	mu    sync.Mutex
+	stops []stopHook
+}
+
+type stopHook struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+type option func(l *Lifecycle)
+
+func SetLogger(lgr *zap.Logger) option {
+	return func(l *Lifecycle) {
+		l.log = lgr
+	}
+}
+
+func New(options ...option) *Lifecycle {
+	l := &Lifecycle{log: zap.NewNop()}
+	for _, opt := range options {
+		opt(l)
+	}
+	return l
+}
+
+// OnStop добавляет остановку компонента name, компоненты останавливаются в обратном порядке.
+func (l *Lifecycle) OnStop(name string, stop func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stops = append(l.stops, stopHook{name: name, stop: stop})
+}
+
+// Go запускает фоновую задачу name. При остановке ее ctx отменяется и Lifecycle ждет
+// ее завершения.
+func (l *Lifecycle) Go(ctx context.Context, name string, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(ctx)
+	}()
+
+	l.OnStop(name, func(stopCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-stopCtx.Done():
+			return stopCtx.Err()
+		}
+	})
+}
+
+// Run запускает serve и ждет SIGINT, SIGTERM или завершения serve, затем останавливает
+// все компоненты, на остановку дается timeout.
+func (l *Lifecycle) Run(serve func() error, timeout time.Duration) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- serve()
+	}()
+
+	var err error
+	select {
+	case sig := <-signals:
+		l.log.Info("shutdown started", zap.String("signal", sig.String()))
+	case err = <-served:
+		if err != nil {
+			err = fmt.Errorf("server stopped: %w", err)
+		}
+		l.log.Info("shutdown started", zap.Error(err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return errors.Join(err, l.Stop(ctx))
+}
+
+// Stop останавливает компоненты в обратном порядке. Ошибка одного компонента не мешает
+// остановке остальных.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	stops := l.stops
+	l.stops = nil
+	l.mu.Unlock()
+
+	var errs []error
+	for i := len(stops) - 1; i >= 0; i-- {
+		start := time.Now()
+		if err := stops[i].stop(ctx); err != nil {
+			l.log.Error("failed stop", zap.String("component", stops[i].name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("failed stop %s: %w", stops[i].name, err))
+			continue
+		}
+		l.log.Info("stopped", zap.String("component", stops[i].name), zap.Duration("duration", time.Since(start)))
+	}
+	return errors.Join(errs...)
+}
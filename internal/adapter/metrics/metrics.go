@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sport-space/internal/adapter/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+const namespace = "sportspace"
+
+// Результаты в метках result.
+const (
+	resultOK     = "ok"
+	resultFailed = "failed"
+)
+
+// statsTimeout сколько ждать показателей хранилища при чтении метрик.
+const statsTimeout = 5 * time.Second
+
+// stats источник бизнес-показателей, читается при каждом запросе /metrics.
+type stats interface {
+	GetStats(ctx context.Context) (*models.Stats, error)
+}
+
+// Metrics метрики сервиса в формате Prometheus в собственном реестре.
+type Metrics struct {
+	log      *zap.Logger
+	registry *prometheus.Registry
+	requests *prometheus.HistogramVec
+	queries  *prometheus.HistogramVec
+	mails    *prometheus.CounterVec
+	otpIssue *prometheus.CounterVec
+	otpCheck *prometheus.CounterVec
+}
+
+type option func(m *Metrics)
+
+func SetLogger(l *zap.Logger) option {
+	return func(m *Metrics) {
+		m.log = l
+	}
+}
+
+func New(options ...option) *Metrics {
+	m := &Metrics{
+		log:      zap.NewNop(),
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Длительность HTTP запросов по шаблону маршрута.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		queries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Длительность запросов к базе по операции и таблице.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table", "result"}),
+		mails: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "mail_send_total",
+			Help:      "Попытки отправки писем через транспорт.",
+		}, []string{"result"}),
+		otpIssue: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "otp_issued_total",
+			Help:      "Выданные коды авторизации по каналу доставки.",
+		}, []string{"channel", "result"}),
+		otpCheck: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "otp_verified_total",
+			Help:      "Проверки кодов авторизации при входе.",
+		}, []string{"result"}),
+	}
+
+	for _, opt := range options {
+		opt(m)
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests, m.queries, m.mails, m.otpIssue, m.otpCheck,
+	)
+	return m
+}
+
+// Handler отдает метрики в текстовом формате Prometheus. Ошибка одного сборщика
+// не ломает ответ, остальные метрики отдаются.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		ErrorLog:      zap.NewStdLog(m.log),
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// RegisterStats добавляет бизнес-показатели из s: открытые турниры и заявки на рассмотрении.
+func (m *Metrics) RegisterStats(s stats) {
+	m.registry.MustRegister(&statsCollector{source: s, log: m.log})
+}
+
+// ObserveRequest учитывает HTTP запрос, route - шаблон маршрута gin (/user/teams/:id).
+func (m *Metrics) ObserveRequest(method, route string, status int, d time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Observe(d.Seconds())
+}
+
+// ObserveQuery учитывает запрос к базе, operation - create, query, update, delete, row или raw.
+func (m *Metrics) ObserveQuery(operation, table string, d time.Duration, err error) {
+	if table == "" {
+		table = "unknown"
+	}
+	m.queries.WithLabelValues(operation, table, result(err)).Observe(d.Seconds())
+}
+
+// ObserveMail учитывает попытку отправки письма.
+func (m *Metrics) ObserveMail(err error) {
+	m.mails.WithLabelValues(result(err)).Inc()
+}
+
+// ObserveOTPIssue учитывает выдачу кода в канал channel.
+func (m *Metrics) ObserveOTPIssue(channel string, err error) {
+	m.otpIssue.WithLabelValues(channel, result(err)).Inc()
+}
+
+// ObserveOTPVerify учитывает проверку кода при входе, ошибка - неверный код или его нет.
+func (m *Metrics) ObserveOTPVerify(err error) {
+	m.otpCheck.WithLabelValues(result(err)).Inc()
+}
+
+func result(err error) string {
+	if err != nil {
+		return resultFailed
+	}
+	return resultOK
+}
+
+var (
+	openTournamentsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "open_tournaments"),
+		"Турниры с открытой регистрацией.", nil, nil,
+	)
+	pendingApplicationsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "pending_applications"),
+		"Поданные заявки, ожидающие решения организатора.", nil, nil,
+	)
+)
+
+// statsCollector читает показатели из хранилища при сборе метрик, а не хранит их,
+// поэтому значения совпадают на всех экземплярах сервиса.
+type statsCollector struct {
+	source stats
+	log    *zap.Logger
+}
+
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- openTournamentsDesc
+	ch <- pendingApplicationsDesc
+}
+
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+	defer cancel()
+
+	st, err := c.source.GetStats(ctx)
+	if err != nil {
+		c.log.Error("failed get stats for metrics", zap.Error(err))
+		ch <- prometheus.NewInvalidMetric(openTournamentsDesc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(openTournamentsDesc, prometheus.GaugeValue, float64(st.OpenTournaments))
+	ch <- prometheus.MustNewConstMetric(pendingApplicationsDesc, prometheus.GaugeValue, float64(st.PendingApplications))
+}
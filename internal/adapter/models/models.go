@@ -6,16 +6,29 @@ import (
 	"gorm.io/gorm"
 )
 
+// OTPChannel канал доставки кода авторизации.
+type OTPChannel string
+
+const (
+	ChannelEmail    OTPChannel = "email"
+	ChannelSMS      OTPChannel = "sms"
+	ChannelTelegram OTPChannel = "telegram"
+)
+
 type User struct {
-	ID           uint `gorm:"primarykey"`
-	Login        string
-	Email        string `gorm:"index"`
-	Tournaments  []Tournament
-	Teams        []Team
-	Players      []Player
-	PasswordHash string
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID             uint `gorm:"primarykey"`
+	Login          string
+	Email          string     `gorm:"index"`
+	Phone          string     `gorm:"index"`
+	OTPChannel     OTPChannel `gorm:"not null;default:email"`
+	TelegramChatID string
+	Tournaments    []Tournament
+	Teams          []Team
+	Players        []Player
+	PasswordHash   string
+	Language       string `gorm:"not null;default:ru"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 }
 
 type OTPUser struct {
@@ -30,20 +43,23 @@ type OTPUser struct {
 }
 
 type Tournament struct {
-	ID                uint `gorm:"primarykey"`
-	UserID            uint `gorm:"index;not null"`
-	Title             string
-	Description       string
-	Organization      string
-	LogoURL           string
-	Applications      []Application
-	StartDate         *time.Time `gorm:"not null"`
-	EndDate           *time.Time `gorm:"not null"`
-	RegisterStartDate *time.Time `gorm:"not null"`
-	RegisterEndDate   *time.Time `gorm:"not null"`
-	CreatedAt         time.Time
-	UpdatedAt         time.Time
-	DeletedAt         gorm.DeletedAt `gorm:"index"`
+	ID                   uint `gorm:"primarykey"`
+	UserID               uint `gorm:"index;not null"`
+	Title                string
+	Description          string
+	Organization         string
+	LogoURL              string
+	Applications         []Application
+	StartDate            *time.Time `gorm:"not null"`
+	EndDate              *time.Time `gorm:"not null"`
+	RegisterStartDate    *time.Time `gorm:"not null"`
+	RegisterEndDate      *time.Time `gorm:"not null"`
+	RosterFreezeDate     *time.Time `gorm:"default:null"`
+	MaxLateSubstitutions *uint      `gorm:"default:null"`
+	Version              uint       `gorm:"not null;default:1"` // растет при каждом изменении, ETag и If-Match
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	DeletedAt            gorm.DeletedAt `gorm:"index"`
 }
 
 type Team struct {
@@ -54,22 +70,25 @@ type Team struct {
 	PhotoURL     string
 	Players      []Player `gorm:"many2many:team_players"`
 	Applications []Application
+	Version      uint `gorm:"not null;default:1"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	DeletedAt    gorm.DeletedAt `gorm:"index"`
 }
 
 type Player struct {
-	ID         uint `gorm:"primarykey"`
-	UserID     uint `gorm:"index;not null"`
-	FirstName  string
-	SecondName string
-	LastName   string
-	BDay       *time.Time `gorm:"default:null"`
-	PhotoURL   string
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
-	DeletedAt  gorm.DeletedAt `gorm:"index"`
+	ID                    uint `gorm:"primarykey"`
+	UserID                uint `gorm:"index;not null"`
+	FirstName             string
+	SecondName            string
+	LastName              string
+	BDay                  *time.Time `gorm:"default:null"`
+	PhotoURL              string
+	MedicalCertificateURL string
+	Version               uint `gorm:"not null;default:1"`
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	DeletedAt             gorm.DeletedAt `gorm:"index"`
 }
 
 type ApplicationStatus string
@@ -89,7 +108,130 @@ type Application struct {
 	Players      []Player          `gorm:"many2many:application_players"`
 	Status       ApplicationStatus `gorm:"index:idx_status;not null"`
 	StatusDate   time.Time
+	Version      uint `gorm:"not null;default:1"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	DeletedAt    gorm.DeletedAt `gorm:"index"`
 }
+
+type RosterChangeStatus string
+
+const (
+	RosterChangePending  RosterChangeStatus = "pending"
+	RosterChangeApproved RosterChangeStatus = "approved"
+	RosterChangeDeclined RosterChangeStatus = "declined"
+)
+
+// RosterChange запрос на замену игрока в принятой заявке после заморозки состава.
+type RosterChange struct {
+	ID            uint `gorm:"primarykey"`
+	ApplicationID uint `gorm:"index;not null"`
+	PlayerOutID   uint `gorm:"not null"`
+	PlayerInID    uint `gorm:"not null"`
+	Reason        string
+	Status        RosterChangeStatus `gorm:"index;not null"`
+	StatusDate    time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+}
+
+// NotificationSettings настройки email уведомлений пользователя.
+type NotificationSettings struct {
+	ID                   uint `gorm:"primarykey"`
+	UserID               uint `gorm:"uniqueIndex;not null"`
+	ApplicationStatus    bool `gorm:"not null"`
+	ApplicationSubmitted bool `gorm:"not null"`
+	ApplicationCanceled  bool `gorm:"not null"`
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+type OutboxStatus string
+
+const (
+	OutboxPending OutboxStatus = "pending"
+	OutboxSent    OutboxStatus = "sent"
+	OutboxDead    OutboxStatus = "dead"
+)
+
+// OutboxMessage письмо в очереди на отправку. Template - шаблон, по которому оно
+// сформировано, пусто для писем без шаблона. Письмо, не отправленное до ExpiresAt,
+// переносится в dead без отправки.
+type OutboxMessage struct {
+	ID            uint   `gorm:"primarykey"`
+	To            string `gorm:"not null"`
+	Template      string
+	Subject       string
+	Body          string
+	HTMLBody      string
+	Status        OutboxStatus `gorm:"index:idx_outbox_due,priority:1;not null"`
+	NextAttemptAt time.Time    `gorm:"index:idx_outbox_due,priority:2;not null"`
+	ExpiresAt     *time.Time   `gorm:"default:null"`
+	Attempts      uint
+	LastError     string
+	SentAt        *time.Time `gorm:"default:null"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// IdempotencyKey ключ Idempotency-Key запроса пользователя и сохраненный ответ на него.
+// StatusCode 0 - запрос с этим ключом еще выполняется.
+type IdempotencyKey struct {
+	ID          uint   `gorm:"primarykey"`
+	UserID      uint   `gorm:"index:idx_idempotency_key,unique;not null"`
+	Key         string `gorm:"index:idx_idempotency_key,unique;not null"`
+	RequestHash string `gorm:"not null"`
+	StatusCode  int    `gorm:"not null;default:0"`
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time `gorm:"index"`
+	UpdatedAt   time.Time
+}
+
+type UploadKind string
+
+const (
+	UploadImage    UploadKind = "image"
+	UploadDocument UploadKind = "document"
+)
+
+// Upload загруженный файл. URL - адрес исходного варианта, на него ссылаются
+// Team.LogoURL, Team.PhotoURL, Player.PhotoURL, Player.MedicalCertificateURL и Tournament.LogoURL.
+type Upload struct {
+	ID        uint       `gorm:"primarykey"`
+	UserID    uint       `gorm:"index;not null"`
+	Kind      UploadKind `gorm:"not null;default:image"`
+	URL       string     `gorm:"uniqueIndex;not null"`
+	Filename  string
+	MIMEType  string
+	Size      int64             `gorm:"not null"`
+	Keys      map[string]string `gorm:"serializer:json"`
+	Variants  map[string]string `gorm:"serializer:json"`
+	CreatedAt time.Time         `gorm:"index"`
+}
+
+// UploadSession загрузка документа частями. Части хранятся отдельными файлами Chunks
+// и склеиваются в Upload, когда получено Size байт.
+type UploadSession struct {
+	ID        string `gorm:"primarykey;size:32"`
+	UserID    uint   `gorm:"index;not null"`
+	Filename  string
+	MIMEType  string
+	Size      int64     `gorm:"not null"`
+	Received  int64     `gorm:"not null"`
+	Chunks    []string  `gorm:"serializer:json"`
+	UploadID  *uint     `gorm:"default:null"`
+	Upload    *Upload   `gorm:"constraint:OnDelete:SET NULL"`
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Stats показатели сервиса для метрик.
+type Stats struct {
+	// OpenTournaments турниры, регистрация в которые идет сейчас.
+	OpenTournaments int64
+	// PendingApplications поданные заявки, по которым организатор еще не принял решение.
+	PendingApplications int64
+}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Store корзины токенов по ключам.
+type Store interface {
+	// Take забирает токен из корзины key, Allowed=false если токенов нет.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Limit корзина на Burst запросов, которая полностью восполняется за Period.
+// Нулевой Limit - без ограничения.
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// UnmarshalText разбирает лимит вида `5/1m` (5 запросов за минуту), `0` - без ограничения.
+// Корзина восполняется по миллисекундам, поэтому период короче 1ms не допускается.
+func (l *Limit) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	if s == "" || s == "0" {
+		*l = Limit{}
+		return nil
+	}
+	sBurst, sPeriod, ok := strings.Cut(s, "/")
+	if !ok {
+		return fmt.Errorf("invalid rate limit `%s`, expected <requests>/<period>", s)
+	}
+	burst, err := strconv.Atoi(sBurst)
+	if err != nil || burst < 0 {
+		return fmt.Errorf("invalid rate limit requests `%s`", sBurst)
+	}
+	period, err := time.ParseDuration(sPeriod)
+	if err != nil || period < time.Millisecond {
+		return fmt.Errorf("invalid rate limit period `%s`", sPeriod)
+	}
+	*l = Limit{Burst: burst, Period: period}
+	return nil
+}
+
+// Unlimited лимит не задан.
+func (l Limit) Unlimited() bool {
+	return l.Burst <= 0 || l.Period <= 0
+}
+
+// rate токенов в миллисекунду.
+func (l Limit) rate() float64 {
+	return float64(l.Burst) / float64(l.Period.Milliseconds())
+}
+
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// Reset через сколько корзина снова будет полной.
+	Reset time.Duration
+	// RetryAfter через сколько появится токен, если запрос не пропущен.
+	RetryAfter time.Duration
+}
+
+// refill токены корзины через elapsed после того, как в ней было tokens.
+func refill(limit Limit, tokens float64, elapsed time.Duration) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(limit.Burst), tokens+float64(elapsed.Milliseconds())*limit.rate())
+}
+
+// newResult результат по числу токенов, оставшихся после запроса.
+func newResult(limit Limit, tokens float64, allowed bool) Result {
+	res := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit.Burst)-tokens)/limit.rate()) * time.Millisecond,
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1-tokens)/limit.rate()) * time.Millisecond
+	}
+	return res
+}
+
+const (
+	DriverNone   = "none"
+	DriverMemory = "memory"
+	DriverRedis  = "redis"
+)
+
+type Config struct {
+	// Driver memory - счетчики в памяти процесса, redis - общие для всех реплик,
+	// none - без ограничений.
+	Driver string `env:"RATE_LIMIT_DRIVER" envDefault:"memory"`
+	Redis  RedisConfig
+}
+
+// New создает хранилище корзин, при DriverNone возвращает nil.
+func New(ctx context.Context, cfg Config) (Store, error) {
+	switch cfg.Driver {
+	case DriverMemory, "":
+		return NewMemory(), nil
+	case DriverRedis:
+		return NewRedis(ctx, cfg.Redis)
+	case DriverNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("rate limit driver `%s` is not supported", cfg.Driver)
+	}
+}
@@ -0,0 +1,223 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestUnmarshalText(t *testing.T) {
+	cases := []struct {
+		text      string
+		want      Limit
+		err       bool
+		unlimited bool
+	}{
+		{text: "5/1m", want: Limit{Burst: 5, Period: time.Minute}},
+		{text: " 10/30s ", want: Limit{Burst: 10, Period: 30 * time.Second}},
+		{text: "1/1ms", want: Limit{Burst: 1, Period: time.Millisecond}},
+		{text: "0", unlimited: true},
+		{text: "", unlimited: true},
+		{text: "0/1m", want: Limit{Period: time.Minute}, unlimited: true},
+		{text: "5", err: true},
+		{text: "x/1m", err: true},
+		{text: "-1/1m", err: true},
+		{text: "5/x", err: true},
+		{text: "5/0s", err: true},
+		{text: "5/-1s", err: true},
+		{text: "5/999us", err: true},
+	}
+	for _, c := range cases {
+		t.Run(c.text, func(t *testing.T) {
+			var l Limit
+			err := l.UnmarshalText([]byte(c.text))
+			if c.err {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", l)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if l != c.want {
+				t.Fatalf("expected %+v, got %+v", c.want, l)
+			}
+			if l.Unlimited() != c.unlimited {
+				t.Fatalf("expected unlimited %v", c.unlimited)
+			}
+		})
+	}
+}
+
+func TestNewResult(t *testing.T) {
+	// токен восполняется за 2s
+	limit := Limit{Burst: 5, Period: 10 * time.Second}
+
+	cases := []struct {
+		name    string
+		tokens  float64
+		allowed bool
+		want    Result
+	}{
+		{"full", 5, true, Result{Allowed: true, Remaining: 5}},
+		{"one taken", 4, true, Result{Allowed: true, Remaining: 4, Reset: 2 * time.Second}},
+		{"part of token", 1.5, true, Result{Allowed: true, Remaining: 1, Reset: 7 * time.Second}},
+		{"empty", 0, true, Result{Allowed: true, Remaining: 0, Reset: 10 * time.Second}},
+		{"denied", 0, false, Result{Remaining: 0, Reset: 10 * time.Second, RetryAfter: 2 * time.Second}},
+		{"denied half token", 0.5, false, Result{Remaining: 0, Reset: 9 * time.Second, RetryAfter: time.Second}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := newResult(limit, c.tokens, c.allowed)
+			if got.Allowed != c.want.Allowed || got.Remaining != c.want.Remaining ||
+				!near(got.Reset, c.want.Reset) || !near(got.RetryAfter, c.want.RetryAfter) {
+				t.Fatalf("expected %+v, got %+v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestRefill(t *testing.T) {
+	limit := Limit{Burst: 5, Period: 10 * time.Second}
+
+	cases := []struct {
+		name    string
+		tokens  float64
+		elapsed time.Duration
+		want    float64
+	}{
+		{"no time", 2, 0, 2},
+		{"one token", 0, 2 * time.Second, 1},
+		{"half token", 0, time.Second, 0.5},
+		{"capped by burst", 4, time.Hour, 5},
+		{"clock went back", 3, -time.Second, 3},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := refill(limit, c.tokens, c.elapsed); got < c.want-1e-9 || got > c.want+1e-9 {
+				t.Fatalf("expected %v tokens, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+// near длительности совпадают с точностью до миллисекунды округления.
+func near(a, b time.Duration) bool {
+	d := a - b
+	return d > -time.Millisecond && d < time.Millisecond
+}
+
+// clock ручные часы для Memory.
+type clock struct{ t time.Time }
+
+func newClock(m *Memory) *clock {
+	c := &clock{t: time.Now()}
+	m.now = c.now
+	m.swept = c.t
+	return c
+}
+
+func (c *clock) now() time.Time { return c.t }
+
+func (c *clock) add(d time.Duration) { c.t = c.t.Add(d) }
+
+func take(m *Memory, key string, l Limit) Result {
+	res, _ := m.Take(context.Background(), key, l)
+	return res
+}
+
+func TestMemory(t *testing.T) {
+	limit := Limit{Burst: 3, Period: 3 * time.Second}
+
+	t.Run("burst", func(t *testing.T) {
+		m := NewMemory()
+		newClock(m)
+		for i := range limit.Burst {
+			res := take(m, "k", limit)
+			if !res.Allowed || res.Remaining != limit.Burst-i-1 {
+				t.Fatalf("take %d: %+v", i+1, res)
+			}
+		}
+		res := take(m, "k", limit)
+		if res.Allowed || res.Remaining != 0 || !near(res.RetryAfter, time.Second) || !near(res.Reset, 3*time.Second) {
+			t.Fatalf("expected denied, got %+v", res)
+		}
+		// у другого ключа своя корзина
+		if res = take(m, "other", limit); !res.Allowed {
+			t.Fatalf("other key is limited: %+v", res)
+		}
+	})
+
+	t.Run("refill", func(t *testing.T) {
+		m := NewMemory()
+		c := newClock(m)
+		for range limit.Burst {
+			take(m, "k", limit)
+		}
+
+		c.add(500 * time.Millisecond)
+		if res := take(m, "k", limit); res.Allowed || !near(res.RetryAfter, 500*time.Millisecond) {
+			t.Fatalf("expected denied until token refills, got %+v", res)
+		}
+		c.add(500 * time.Millisecond)
+		if res := take(m, "k", limit); !res.Allowed || res.Remaining != 0 {
+			t.Fatalf("expected refilled token, got %+v", res)
+		}
+		// корзина не переполняется сверх Burst
+		c.add(time.Hour)
+		if res := take(m, "k", limit); !res.Allowed || res.Remaining != limit.Burst-1 {
+			t.Fatalf("expected full bucket, got %+v", res)
+		}
+	})
+
+	t.Run("sweep", func(t *testing.T) {
+		m := NewMemory()
+		c := newClock(m)
+		take(m, "short", limit)
+		take(m, "long", Limit{Burst: 1, Period: time.Hour})
+
+		c.add(sweepInterval - time.Second)
+		take(m, "trigger", limit)
+		if len(m.buckets) != 3 {
+			t.Fatalf("buckets are swept before interval: %d", len(m.buckets))
+		}
+
+		c.add(time.Second)
+		take(m, "trigger", limit)
+		if _, ok := m.buckets["short"]; ok {
+			t.Fatal("full bucket is not swept")
+		}
+		if _, ok := m.buckets["long"]; !ok {
+			t.Fatal("bucket that is not full is swept")
+		}
+	})
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	r, err := NewRedis(ctx, RedisConfig{Addr: mr.Addr(), Prefix: "test:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	limit := Limit{Burst: 2, Period: time.Hour}
+	for i := range limit.Burst {
+		res, err := r.Take(ctx, "k", limit)
+		if err != nil || !res.Allowed || res.Remaining != limit.Burst-i-1 {
+			t.Fatalf("take %d: %+v, %v", i+1, res, err)
+		}
+	}
+	res, err := r.Take(ctx, "k", limit)
+	if err != nil || res.Allowed || res.RetryAfter <= 0 || res.RetryAfter > 30*time.Minute {
+		t.Fatalf("expected denied, got %+v, %v", res, err)
+	}
+	// корзина удаляется, когда снова станет полной
+	if ttl := mr.TTL("test:ratelimit:k"); ttl <= 0 || ttl > time.Hour+time.Second {
+		t.Fatalf("unexpected bucket ttl %v", ttl)
+	}
+}
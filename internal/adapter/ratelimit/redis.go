@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisConfig обычно тот же сервер, что и у кеша.
+type RedisConfig struct {
+	Addr     string `env:"REDIS_ADDR" envDefault:"localhost:6379"`
+	Password string `env:"REDIS_PASSWORD" envDefault:""`
+	DB       int    `env:"REDIS_DB" envDefault:"0"`
+	Prefix   string `env:"REDIS_PREFIX" envDefault:"sportspace:"`
+}
+
+// takeScript атомарно восполняет корзину и забирает токен. Корзина хранится
+// hash-ом tokens/updated и удаляется, когда снова становится полной.
+var takeScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local rate = burst / period
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1]) or burst
+local updated = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - updated) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate) + 1)
+return {allowed, tostring(tokens)}
+`)
+
+// Redis корзины, общие для всех реплик API.
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedis(ctx context.Context, cfg RedisConfig) (*Redis, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed connect redis: %w", err)
+	}
+	return &Redis{client: client, prefix: cfg.Prefix + "ratelimit:"}, nil
+}
+
+func (r *Redis) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	res, err := takeScript.Run(ctx, r.client, []string{r.prefix + key},
+		limit.Burst, limit.Period.Milliseconds(), time.Now().UnixMilli()).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed take token: %w", err)
+	}
+	if len(res) != 2 {
+		return Result{}, fmt.Errorf("unexpected take token result %v", res)
+	}
+	allowed, _ := res[0].(int64)
+	sTokens, _ := res[1].(string)
+	tokens, err := strconv.ParseFloat(sTokens, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed parse tokens `%s`: %w", sTokens, err)
+	}
+	return newResult(limit, tokens, allowed == 1), nil
+}
+
+func (r *Redis) Close() error {
+	if err := r.client.Close(); err != nil {
+		return fmt.Errorf("failed close redis: %w", err)
+	}
+	return nil
+}
@@ -1,5 +1,7 @@
 package sender
 
+import "time"
+
 type Config struct {
 	Host     string `env:"MAIL_SMTP_HOST" defaule:"localhost"`
 	Port     int    `env:"MAIL_SMTP_PORT" default:"1025"`
@@ -7,4 +9,23 @@ type Config struct {
 	Password string `env:"MAIL_SENDER_PASSWORD"`
 	Secure   bool   `env:"MAIL_SECURE" default:"0"`
 	Timeout  int    `env:"MAIL_SEND_TIMEOUT" default:"0"`
+
+	Workers      int           `env:"MAIL_WORKERS" envDefault:"2"`
+	MaxAttempts  uint          `env:"MAIL_MAX_ATTEMPTS" envDefault:"8"`
+	RetryBase    time.Duration `env:"MAIL_RETRY_BASE" envDefault:"30s"`
+	RetryMax     time.Duration `env:"MAIL_RETRY_MAX" envDefault:"1h"`
+	PollInterval time.Duration `env:"MAIL_POLL_INTERVAL" envDefault:"5s"`
+	// OTPTTL сколько письмо с кодом входа ждет отправки, после этого код бесполезен
+	// и письмо переносится в dead.
+	OTPTTL time.Duration `env:"MAIL_OTP_TTL" envDefault:"10m"`
+
+	Transport      string `env:"MAIL_TRANSPORT" envDefault:"smtp"`
+	MaildirPath    string `env:"MAIL_MAILDIR_PATH" envDefault:"./maildir"`
+	MemoryCapacity int    `env:"MAIL_MEMORY_CAPACITY" envDefault:"100"`
+	// DevMailbox открывает письма транспорта memory без авторизации на /api/v1/dev/mails,
+	// в них коды входа, поэтому включается только явно и только для разработки.
+	DevMailbox bool `env:"MAIL_DEV_MAILBOX" envDefault:"false"`
+
+	TemplatesDir    string `env:"MAIL_TEMPLATES_DIR"`
+	DefaultLanguage string `env:"MAIL_DEFAULT_LANGUAGE" envDefault:"ru"`
 }
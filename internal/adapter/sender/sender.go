@@ -1,19 +1,60 @@
 package sender
 
 import (
-	"crypto/tls"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"sync"
 	"time"
 
+	"sport-space/internal/adapter/models"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
-	gomail "gopkg.in/mail.v2"
 )
 
+var tracer = otel.Tracer("sport-space/internal/adapter/sender")
+
+const (
+	claimBatchSize = 10
+	// claimLease время, на которое письмо скрывается от других обработчиков во время отправки.
+	claimLease = 2 * time.Minute
+	// errExpired причина переноса в dead письма, не отправленного до ExpiresAt.
+	errExpired = "expired before delivery"
+)
+
+// ErrMessageExpired письмо с истекшим сроком нельзя вернуть в очередь.
+var ErrMessageExpired = errors.New("outbox message is expired")
+
+type store interface {
+	NewOutboxMessage(ctx context.Context, msg *models.OutboxMessage) error
+	ClaimOutboxMessages(ctx context.Context, limit int, lease time.Duration) (*[]models.OutboxMessage, error)
+	UpdOutboxMessage(ctx context.Context, msg *models.OutboxMessage) error
+	GetOutboxMessages(ctx context.Context, status models.OutboxStatus) (*[]models.OutboxMessage, error)
+	GetOutboxMessageByID(ctx context.Context, id uint) (*models.OutboxMessage, error)
+}
+
+// mailMetrics учет попыток отправки писем.
+type mailMetrics interface {
+	ObserveMail(err error)
+}
+
 type Sender struct {
-	log     *zap.Logger
-	message chan tMsg
-	cfg     Config
+	log       *zap.Logger
+	metrics   mailMetrics
+	store     store
+	cfg       Config
+	templates *templates
+	transport Transport
+	wakeup    chan struct{}
+	drain     chan struct{}
+	drainOnce sync.Once
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
 }
 
 type option func(s *Sender)
@@ -24,131 +65,364 @@ func SetLogger(l *zap.Logger) option {
 	}
 }
 
-func New(cfg Config, options ...option) (*Sender, error) {
+// SetTransport задает транспорт вместо выбранного в конфигурации.
+func SetTransport(t Transport) option {
+	return func(s *Sender) {
+		s.transport = t
+	}
+}
+
+// SetMetrics учитывает в m каждую попытку отправки письма транспортом.
+func SetMetrics(m mailMetrics) option {
+	return func(s *Sender) {
+		s.metrics = m
+	}
+}
+
+func New(cfg Config, store store, options ...option) (*Sender, error) {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxAttempts == 0 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 5 * time.Second
+	}
+	if cfg.DefaultLanguage == "" {
+		cfg.DefaultLanguage = "ru"
+	}
+	if cfg.OTPTTL <= 0 {
+		cfg.OTPTTL = 10 * time.Minute
+	}
+
+	tmpls, err := newTemplates(cfg.TemplatesDir, cfg.DefaultLanguage)
+	if err != nil {
+		return nil, fmt.Errorf("failed load email templates: %w", err)
+	}
+
 	s := &Sender{
-		log:     zap.NewNop(),
-		message: make(chan tMsg, 2),
-		cfg:     cfg,
+		log:       zap.NewNop(),
+		store:     store,
+		cfg:       cfg,
+		templates: tmpls,
+		wakeup:    make(chan struct{}, 1),
+		drain:     make(chan struct{}),
 	}
 
 	for _, opt := range options {
 		opt(s)
 	}
 
-	go s.sender()
+	if s.transport == nil {
+		s.transport, err = newTransport(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed initialize mail transport: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	for i := range cfg.Workers {
+		s.wg.Add(1)
+		go s.worker(ctx, i)
+	}
 
 	return s, nil
 }
 
+// Close останавливает обработчиков очереди, неотправленные письма остаются в outbox.
+func (s *Sender) Close() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// Shutdown отправляет письма, срок отправки которых уже наступил, и останавливает
+// обработчиков. После отмены ctx обработчики останавливаются сразу, неотправленные
+// письма остаются в outbox.
+func (s *Sender) Shutdown(ctx context.Context) error {
+	s.drainOnce.Do(func() { close(s.drain) })
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+		return fmt.Errorf("outbox is not flushed: %w", ctx.Err())
+	}
+}
+
 type Email string
 
 func (e Email) String() string {
 	return string(e)
 }
 
-type tMsg struct {
-	To      string
-	Subject string
-	Body    string
+func (s *Sender) notify() {
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
 }
 
-func (s *Sender) sender() {
+func (s *Sender) worker(ctx context.Context, id int) {
+	defer s.wg.Done()
+	draining := false
 	for {
-		m, ok := <-s.message
-		if !ok {
-			s.log.Error("Failed read from msg chan")
+		n, err := s.processBatch(ctx)
+		if err != nil {
+			s.log.Error("failed process outbox", zap.Int("worker", id), zap.Error(err))
+		}
+		if n == claimBatchSize && ctx.Err() == nil {
+			continue
+		}
+		// при остановке обработчик выходит, когда в очереди не осталось готовых писем
+		if draining || ctx.Err() != nil {
 			return
 		}
-		s.SendEmail(m.To, m.Subject, m.Body)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.drain:
+			draining = true
+		case <-s.wakeup:
+		case <-time.After(s.cfg.PollInterval):
+		}
 	}
 }
 
-func (s *Sender) AddMail(email, subject, body string) {
-	m := tMsg{
-		To:      email,
-		Subject: subject,
-		Body:    body,
+func (s *Sender) processBatch(ctx context.Context) (int, error) {
+	messages, err := s.store.ClaimOutboxMessages(ctx, claimBatchSize, claimLease)
+	if err != nil {
+		return 0, fmt.Errorf("failed claim messages: %w", err)
+	}
+
+	for i := range *messages {
+		if ctx.Err() != nil {
+			break
+		}
+		s.deliver(ctx, &(*messages)[i])
 	}
 
-	s.log.Debug("Added to chan", zap.String("To", email), zap.String("Subject", subject), zap.String("Body", body))
-	s.message <- m
+	return len(*messages), nil
 }
 
-func (s *Sender) SendEmail(to, subject, body string) (bool, error) {
+func (s *Sender) deliver(ctx context.Context, msg *models.OutboxMessage) {
+	// письма отправляются вне запроса, у каждой отправки своя трассировка
+	ctx, span := tracer.Start(ctx, "sender.deliver", trace.WithAttributes(
+		attribute.Int("outbox.id", int(msg.ID)),
+		attribute.Int("outbox.attempt", int(msg.Attempts)+1),
+	))
+	defer span.End()
+
+	now := time.Now()
+	if msg.ExpiresAt != nil && now.After(*msg.ExpiresAt) {
+		msg.Status = models.OutboxDead
+		msg.LastError = errExpired
+		s.log.Warn("email expired before delivery", zap.Uint("id", msg.ID), zap.String("to", msg.To),
+			zap.Uint("attempts", msg.Attempts))
+		s.save(ctx, msg)
+		return
+	}
 
-	start := time.Now()
-	m := gomail.NewMessage()
+	msg.Attempts++
+	_, err := s.SendEmail(ctx, msg.To, msg.Subject, msg.Body, msg.HTMLBody)
+	now = time.Now()
+	switch {
+	case err == nil:
+		msg.Status = models.OutboxSent
+		msg.SentAt = &now
+		msg.LastError = ""
+	case errors.Is(err, ErrPermanent) || msg.Attempts >= s.cfg.MaxAttempts:
+		msg.Status = models.OutboxDead
+		msg.LastError = err.Error()
+		s.log.Error("email moved to dead letter", zap.Uint("id", msg.ID), zap.String("to", msg.To),
+			zap.Uint("attempts", msg.Attempts), zap.Error(err))
+	default:
+		msg.NextAttemptAt = now.Add(s.backoff(msg.Attempts))
+		msg.LastError = err.Error()
+	}
 
-	// Set E-Mail sender
-	m.SetHeader("From", s.cfg.From)
+	s.save(ctx, msg)
+}
 
-	// Set E-Mail receivers
-	m.SetHeader("To", to)
+// save сохраняет результат отправки. Тексты писем с кодами входа после отправки или
+// переноса в dead больше не нужны и очищаются.
+func (s *Sender) save(ctx context.Context, msg *models.OutboxMessage) {
+	if msg.Template == TemplateOTP && msg.Status != models.OutboxPending {
+		msg.Body = ""
+		msg.HTMLBody = ""
+	}
 
-	// Set E-Mail subject
-	m.SetHeader("Subject", subject)
+	// статус сохраняем даже при остановке сервиса, иначе письмо будет отправлено повторно.
+	err := s.store.UpdOutboxMessage(context.WithoutCancel(ctx), msg)
+	if err != nil {
+		s.log.Error("failed save outbox message", zap.Uint("id", msg.ID), zap.Error(err))
+	}
+}
 
-	// Set E-Mail body. You can set plain text or html with text/html
-	m.SetBody("text/plain", body)
+// backoff экспоненциальная задержка перед повторной отправкой с разбросом ±20%.
+func (s *Sender) backoff(attempt uint) time.Duration {
+	d := s.cfg.RetryBase
+	for i := uint(1); i < attempt && d < s.cfg.RetryMax; i++ {
+		d *= 2
+	}
+	if s.cfg.RetryMax > 0 && d > s.cfg.RetryMax {
+		d = s.cfg.RetryMax
+	}
+	jitter := time.Duration(rand.Int64N(int64(d)/5+1)) * 2
+	return d - d/5 + jitter
+}
 
-	// Settings for SMTP server
-	d := gomail.NewDialer(s.cfg.Host, s.cfg.Port, s.cfg.From, s.cfg.Password)
+// AddMail ставит текстовое письмо в очередь на отправку.
+func (s *Sender) AddMail(ctx context.Context, email, subject, body string) error {
+	ctx, span := tracer.Start(ctx, "sender.AddMail")
+	defer span.End()
 
-	// This is only needed when SSL/TLS certificate is not valid on server.
-	// In production this should be set to false.
-	d.TLSConfig = &tls.Config{InsecureSkipVerify: !s.cfg.Secure}
+	return s.enqueue(ctx, &models.OutboxMessage{
+		To:      email,
+		Subject: subject,
+		Body:    body,
+	})
+}
 
-	// Now send E-Mail
-	if err := d.DialAndSend(m); err != nil {
-		fmt.Println(err)
-		s.log.Error("failed send email", zap.Error(err))
-		panic(err)
+// AddTemplateMail формирует письмо по шаблону на языке пользователя и ставит его в очередь.
+func (s *Sender) AddTemplateMail(ctx context.Context, email, language, template string, data any) error {
+	ctx, span := tracer.Start(ctx, "sender.AddTemplateMail", trace.WithAttributes(
+		attribute.String("mail.template", template),
+		attribute.String("mail.language", language),
+	))
+	defer span.End()
+
+	msg, err := s.templates.render(template, language, data)
+	if err != nil {
+		return fmt.Errorf("failed render email: %w", err)
 	}
 
-	duration := time.Since(start).Seconds()
-	marshaled, _ := json.MarshalIndent(map[string]interface{}{
-		"duration": duration,
-		"to":       to,
-		"subject":  subject,
-		"body":     body,
-	}, "", "  ")
-	s.log.Debug("Sended", zap.String("mail", string(marshaled)))
-	return true, nil
+	return s.enqueue(ctx, &models.OutboxMessage{
+		To:       email,
+		Template: template,
+		Subject:  msg.Subject,
+		Body:     msg.Text,
+		HTMLBody: msg.HTML,
+	})
 }
 
-func (s *Sender) SendCodeToEmail(email string, code string) (bool, error) {
-	start := time.Now()
-	m := gomail.NewMessage()
+func (s *Sender) enqueue(ctx context.Context, msg *models.OutboxMessage) error {
+	msg.Status = models.OutboxPending
+	msg.NextAttemptAt = time.Now()
+	err := s.store.NewOutboxMessage(ctx, msg)
+	if err != nil {
+		return fmt.Errorf("failed add mail to outbox: %w", err)
+	}
+
+	s.log.Debug("added to outbox", zap.String("to", msg.To), zap.String("subject", msg.Subject))
+	s.notify()
+	return nil
+}
 
-	// Set E-Mail sender
-	m.SetHeader("From", s.cfg.From)
+func (s *Sender) GetOutbox(ctx context.Context, status models.OutboxStatus) (*[]models.OutboxMessage, error) {
+	messages, err := s.store.GetOutboxMessages(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed get outbox: %w", err)
+	}
+	return messages, nil
+}
 
-	// Set E-Mail receivers
-	m.SetHeader("To", email)
+// RequeueOutbox возвращает письмо в очередь со сброшенным счетчиком попыток. Письма
+// со сроком отправки, например с кодом входа, повторно не отправляются.
+func (s *Sender) RequeueOutbox(ctx context.Context, id uint) (*models.OutboxMessage, error) {
+	msg, err := s.store.GetOutboxMessageByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed get outbox message: %w", err)
+	}
+	if msg.ExpiresAt != nil {
+		return nil, ErrMessageExpired
+	}
 
-	// Set E-Mail subject
-	m.SetHeader("Subject", "Auth code")
+	msg.Status = models.OutboxPending
+	msg.Attempts = 0
+	msg.NextAttemptAt = time.Now()
+	msg.LastError = ""
+	msg.SentAt = nil
+	err = s.store.UpdOutboxMessage(ctx, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed requeue outbox message: %w", err)
+	}
 
-	// Set E-Mail body. You can set plain text or html with text/html
-	m.SetBody("text/plain", fmt.Sprintf("Code %s", code))
+	s.notify()
+	return msg, nil
+}
 
-	// Settings for SMTP server
-	d := gomail.NewDialer(s.cfg.Host, s.cfg.Port, s.cfg.From, s.cfg.Password)
+// SendEmail отправляет письмо через транспорт, при непустом html оно добавляется альтернативной частью к тексту.
+func (s *Sender) SendEmail(ctx context.Context, to, subject, body, html string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "sender.SendEmail", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
 
-	// This is only needed when SSL/TLS certificate is not valid on server.
-	// In production this should be set to false.
-	d.TLSConfig = &tls.Config{}
-	d.TLSConfig.InsecureSkipVerify = !s.cfg.Secure
-	if s.cfg.Timeout > 0 {
-		d.Timeout = time.Duration(s.cfg.Timeout) * time.Second
+	start := time.Now()
+	err := s.transport.Send(ctx, &Message{
+		From:    s.cfg.From,
+		To:      to,
+		Subject: subject,
+		Text:    body,
+		HTML:    html,
+		Date:    start,
+	})
+	if s.metrics != nil {
+		s.metrics.ObserveMail(err)
 	}
-	// Now send E-Mail
-	if err := d.DialAndSend(m); err != nil {
-		s.log.Error("send email", zap.Error(err))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.log.Error("failed send email", zap.String("to", to), zap.Error(err))
 		return false, err
 	}
 
 	duration := time.Since(start).Seconds()
-	s.log.Debug("sended otp", zap.Float64("duration", duration), zap.String("to", email), zap.String("code", code))
+	s.log.Debug("sended", zap.Float64("duration", duration), zap.String("to", to), zap.String("subject", subject))
+	return true, nil
+}
+
+// Mailbox перехваченные письма, если используется транспорт memory.
+func (s *Sender) Mailbox() *MemoryTransport {
+	mt, _ := s.transport.(*MemoryTransport)
+	return mt
+}
+
+// SendCodeToEmail ставит письмо с кодом авторизации в очередь со сроком MAIL_OTP_TTL:
+// не отправленное за это время письмо переносится в dead, текст с кодом очищается
+// после отправки или истечения срока.
+func (s *Sender) SendCodeToEmail(ctx context.Context, email, language, code string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "sender.SendCodeToEmail", trace.WithAttributes(
+		attribute.String("mail.language", language),
+	))
+	defer span.End()
+
+	msg, err := s.templates.render(TemplateOTP, language, map[string]any{"Code": code})
+	if err != nil {
+		return false, fmt.Errorf("failed render email: %w", err)
+	}
+
+	expiresAt := time.Now().Add(s.cfg.OTPTTL)
+	err = s.enqueue(ctx, &models.OutboxMessage{
+		To:        email,
+		Template:  TemplateOTP,
+		Subject:   msg.Subject,
+		Body:      msg.Text,
+		HTMLBody:  msg.HTML,
+		ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		return false, err
+	}
 	return true, nil
 }
@@ -0,0 +1,198 @@
+package sender
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Названия шаблонов писем.
+const (
+	TemplateOTP                  = "otp"
+	TemplateApplicationStatus    = "application_status"
+	TemplateApplicationSubmitted = "application_submitted"
+	TemplateApplicationCanceled  = "application_canceled"
+)
+
+const layoutFile = "layout.html.tmpl"
+
+// required шаблоны, которые должны быть на языке по умолчанию.
+var required = []string{
+	TemplateOTP, TemplateApplicationStatus, TemplateApplicationSubmitted, TemplateApplicationCanceled,
+}
+
+var ErrTemplateNotFound = errors.New("template not found")
+
+//go:embed templates
+var embedded embed.FS
+
+// overlayFS ищет файл сначала в каталоге переопределений, затем во встроенных шаблонах.
+type overlayFS struct {
+	override fs.FS
+	base     fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if o.override != nil {
+		f, err := o.override.Open(name)
+		if err == nil {
+			return f, nil
+		}
+	}
+	return o.base.Open(name)
+}
+
+type rendered struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type compiled struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// templates шаблоны писем: для каждого языка `<lang>/<name>.subject.tmpl`,
+// `<lang>/<name>.txt.tmpl` и необязательный `<lang>/<name>.html.tmpl` с блоком content.
+// Все шаблоны разбираются при создании, ошибка в любом из них не дает запустить сервис.
+type templates struct {
+	fs          fs.FS
+	defaultLang string
+	cache       map[string]*compiled
+}
+
+func newTemplates(dir, defaultLang string) (*templates, error) {
+	base, err := fs.Sub(embedded, "templates")
+	if err != nil {
+		return nil, fmt.Errorf("failed open embedded templates: %w", err)
+	}
+
+	fsys := overlayFS{base: base}
+	if dir != "" {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed open templates dir: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("templates path `%s` is not dir", dir)
+		}
+		fsys.override = os.DirFS(dir)
+	}
+
+	t := &templates{
+		fs:          fsys,
+		defaultLang: defaultLang,
+		cache:       map[string]*compiled{},
+	}
+	if err = t.load(base, fsys.override); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// load разбирает все шаблоны из встроенных файлов и каталога переопределений.
+func (t *templates) load(dirs ...fs.FS) error {
+	var keys []string
+	for _, dir := range dirs {
+		if dir == nil {
+			continue
+		}
+		files, err := fs.Glob(dir, "*/*.subject.tmpl")
+		if err != nil {
+			return fmt.Errorf("failed list templates: %w", err)
+		}
+		keys = append(keys, files...)
+	}
+
+	for _, file := range keys {
+		key := strings.TrimSuffix(file, ".subject.tmpl")
+		if _, ok := t.cache[key]; ok {
+			continue
+		}
+		tmpl, err := t.parse(key)
+		if err != nil {
+			return err
+		}
+		t.cache[key] = tmpl
+	}
+
+	for _, name := range required {
+		if _, err := t.get(name, t.defaultLang); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// render формирует письмо на языке lang, при отсутствии перевода используется язык по умолчанию.
+func (t *templates) render(name, lang string, data any) (*rendered, error) {
+	tmpl, err := t.get(name, lang)
+	if errors.Is(err, ErrTemplateNotFound) && lang != t.defaultLang {
+		tmpl, err = t.get(name, t.defaultLang)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &rendered{}
+	buf := &bytes.Buffer{}
+	if err = tmpl.subject.Execute(buf, data); err != nil {
+		return nil, fmt.Errorf("failed render subject `%s`: %w", name, err)
+	}
+	res.Subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	buf.Reset()
+	if err = tmpl.text.Execute(buf, data); err != nil {
+		return nil, fmt.Errorf("failed render text `%s`: %w", name, err)
+	}
+	res.Text = strings.TrimSpace(buf.String())
+
+	if tmpl.html != nil {
+		buf.Reset()
+		if err = tmpl.html.ExecuteTemplate(buf, "layout", data); err != nil {
+			return nil, fmt.Errorf("failed render html `%s`: %w", name, err)
+		}
+		res.HTML = buf.String()
+	}
+
+	return res, nil
+}
+
+func (t *templates) get(name, lang string) (*compiled, error) {
+	key := lang + "/" + name
+	tmpl, ok := t.cache[key]
+	if !ok {
+		return nil, errors.Join(fmt.Errorf("template `%s`", key), ErrTemplateNotFound)
+	}
+	return tmpl, nil
+}
+
+func (t *templates) parse(key string) (*compiled, error) {
+	tmpl := &compiled{}
+	var err error
+	tmpl.subject, err = texttemplate.ParseFS(t.fs, key+".subject.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed parse subject `%s`: %w", key, err)
+	}
+	tmpl.text, err = texttemplate.ParseFS(t.fs, key+".txt.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed parse text `%s`: %w", key, err)
+	}
+
+	if _, err = fs.Stat(t.fs, key+".html.tmpl"); err == nil {
+		tmpl.html, err = htmltemplate.ParseFS(t.fs, layoutFile, key+".html.tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("failed parse html `%s`: %w", key, err)
+		}
+	}
+
+	return tmpl, nil
+}
@@ -0,0 +1,65 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	gomail "gopkg.in/mail.v2"
+)
+
+// Виды транспорта писем.
+const (
+	TransportSMTP    = "smtp"
+	TransportMaildir = "maildir"
+	TransportMemory  = "memory"
+)
+
+var (
+	// ErrPermanent ошибка доставки, при которой повтор отправки не поможет.
+	ErrPermanent             = errors.New("permanent delivery error")
+	ErrTransportNotSupported = errors.New("mail transport is not supported")
+)
+
+// Message письмо, передаваемое транспорту.
+type Message struct {
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Text    string    `json:"text"`
+	HTML    string    `json:"html,omitempty"`
+	Date    time.Time `json:"date"`
+}
+
+// Transport доставляет письмо получателю.
+type Transport interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+func newTransport(cfg Config) (Transport, error) {
+	switch cfg.Transport {
+	case TransportSMTP, "":
+		return newSMTPTransport(cfg), nil
+	case TransportMaildir:
+		return NewMaildirTransport(cfg.MaildirPath)
+	case TransportMemory:
+		return NewMemoryTransport(cfg.MemoryCapacity), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrTransportNotSupported, cfg.Transport)
+	}
+}
+
+// build собирает MIME письмо: текст и, при наличии, альтернативная HTML часть.
+func (m *Message) build() *gomail.Message {
+	gm := gomail.NewMessage()
+	gm.SetHeader("From", m.From)
+	gm.SetHeader("To", m.To)
+	gm.SetHeader("Subject", m.Subject)
+	gm.SetDateHeader("Date", m.Date)
+	gm.SetBody("text/plain", m.Text)
+	if m.HTML != "" {
+		gm.AddAlternative("text/html", m.HTML)
+	}
+	return gm
+}
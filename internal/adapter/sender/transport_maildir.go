@@ -0,0 +1,57 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// MaildirTransport складывает письма файлами в каталог формата maildir (tmp, new, cur),
+// их можно открыть любым почтовым клиентом.
+type MaildirTransport struct {
+	path     string
+	hostname string
+	counter  atomic.Uint64
+}
+
+func NewMaildirTransport(path string) (*MaildirTransport, error) {
+	for _, dir := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(path, dir), 0o750); err != nil {
+			return nil, fmt.Errorf("failed create maildir: %w", err)
+		}
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+
+	return &MaildirTransport{path: path, hostname: hostname}, nil
+}
+
+// Send пишет письмо в tmp и переносит в new, чтобы читатель не увидел его частично записанным.
+func (t *MaildirTransport) Send(_ context.Context, msg *Message) error {
+	name := fmt.Sprintf("%d.P%dQ%d.%s", time.Now().UnixNano(), os.Getpid(), t.counter.Add(1), t.hostname)
+	tmpPath := filepath.Join(t.path, "tmp", name)
+
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed create mail file: %w", err)
+	}
+	_, err = msg.build().WriteTo(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed write mail file: %w", err)
+	}
+
+	if err = os.Rename(tmpPath, filepath.Join(t.path, "new", name)); err != nil {
+		return fmt.Errorf("failed move mail file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package sender
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryTransport сохраняет письма в памяти, для разработки и тестов.
+// Хранятся последние capacity писем.
+type MemoryTransport struct {
+	mu       sync.RWMutex
+	capacity int
+	messages []Message
+}
+
+func NewMemoryTransport(capacity int) *MemoryTransport {
+	if capacity <= 0 {
+		capacity = 100
+	}
+	return &MemoryTransport{capacity: capacity}
+}
+
+func (t *MemoryTransport) Send(_ context.Context, msg *Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.messages = append(t.messages, *msg)
+	if len(t.messages) > t.capacity {
+		t.messages = t.messages[len(t.messages)-t.capacity:]
+	}
+	return nil
+}
+
+// Messages перехваченные письма, последние в конце.
+func (t *MemoryTransport) Messages() []Message {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	res := make([]Message, len(t.messages))
+	copy(res, t.messages)
+	return res
+}
+
+func (t *MemoryTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.messages = nil
+}
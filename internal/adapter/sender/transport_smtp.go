@@ -0,0 +1,50 @@
+package sender
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/textproto"
+	"time"
+
+	gomail "gopkg.in/mail.v2"
+)
+
+type smtpTransport struct {
+	dialer *gomail.Dialer
+}
+
+func newSMTPTransport(cfg Config) *smtpTransport {
+	d := gomail.NewDialer(cfg.Host, cfg.Port, cfg.From, cfg.Password)
+
+	// This is only needed when SSL/TLS certificate is not valid on server.
+	// In production this should be set to false.
+	d.TLSConfig = &tls.Config{InsecureSkipVerify: !cfg.Secure}
+	if cfg.Timeout > 0 {
+		d.Timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+
+	return &smtpTransport{dialer: d}
+}
+
+func (t *smtpTransport) Send(_ context.Context, msg *Message) error {
+	err := t.dialer.DialAndSend(msg.build())
+	if err != nil && isPermanent(err) {
+		return errors.Join(err, ErrPermanent)
+	}
+	return err
+}
+
+// isPermanent ошибка SMTP 5xx - повтор отправки не поможет. Ошибки RCPT и DATA gomail
+// оборачивает в SendError без Unwrap, код ответа сервера лежит в его Cause.
+func isPermanent(err error) bool {
+	var sendErr *gomail.SendError
+	if errors.As(err, &sendErr) {
+		err = sendErr.Cause
+	}
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) {
+		return tpErr.Code >= 500
+	}
+	return false
+}
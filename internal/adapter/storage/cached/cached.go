@@ -0,0 +1,198 @@
+package cached
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"sport-space/internal/adapter/cache"
+	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/storage"
+
+	"go.uber.org/zap"
+)
+
+// Группы закешированных чтений. Запись сбрасывает всю группу сменой поколения,
+// ключи старого поколения больше не читаются и истекают по ttl.
+const (
+	groupTournaments = "tournaments"
+)
+
+// Storage кеширует публичные чтения хранилища и сбрасывает их после записей.
+// Внутри транзакции чтения идут мимо кеша, а сброс откладывается до коммита,
+// чтобы в кеш не попали незакоммиченные или уже устаревшие данные.
+type Storage struct {
+	storage.Store
+	cache cache.Cache
+	ttl   time.Duration
+	log   *zap.Logger
+}
+
+type option func(s *Storage)
+
+func SetTTL(ttl time.Duration) option {
+	return func(s *Storage) {
+		s.ttl = ttl
+	}
+}
+
+func SetLogger(l *zap.Logger) option {
+	return func(s *Storage) {
+		s.log = l
+	}
+}
+
+// New оборачивает store кешем c, без кеша возвращает store как есть.
+func New(store storage.Store, c cache.Cache, options ...option) storage.Store {
+	if c == nil {
+		return store
+	}
+	s := &Storage{
+		Store: store,
+		cache: c,
+		ttl:   30 * time.Second,
+		log:   zap.NewNop(),
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s
+}
+
+type txKey struct{}
+
+// txChanges группы, измененные в транзакции.
+type txChanges struct {
+	mu     sync.Mutex
+	groups map[string]struct{}
+}
+
+func (s *Storage) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return s.Store.Transaction(ctx, fn)
+	}
+
+	changes := &txChanges{groups: map[string]struct{}{}}
+	err := s.Store.Transaction(ctx, func(ctx context.Context) error {
+		return fn(context.WithValue(ctx, txKey{}, changes))
+	})
+	if err != nil {
+		return err
+	}
+	for group := range changes.groups {
+		s.invalidate(ctx, group)
+	}
+	return nil
+}
+
+// changed сбрасывает группу после записи, в транзакции - после коммита.
+func (s *Storage) changed(ctx context.Context, group string) {
+	if changes, ok := ctx.Value(txKey{}).(*txChanges); ok {
+		changes.mu.Lock()
+		changes.groups[group] = struct{}{}
+		changes.mu.Unlock()
+		return
+	}
+	s.invalidate(ctx, group)
+}
+
+// invalidate начинает новое поколение группы. Время, а не счетчик, чтобы поколение,
+// вытесненное из кеша, не повторилось.
+func (s *Storage) invalidate(ctx context.Context, group string) {
+	gen := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := s.cache.Set(ctx, "gen:"+group, []byte(gen), 0); err != nil {
+		s.log.Error("failed invalidate cache", zap.String("group", group), zap.Error(err))
+	}
+}
+
+// key ключ значения name в текущем поколении группы.
+func (s *Storage) key(ctx context.Context, group, name string) (string, error) {
+	gen, ok, err := s.cache.Get(ctx, "gen:"+group)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		gen = []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+		if err = s.cache.Set(ctx, "gen:"+group, gen, 0); err != nil {
+			return "", err
+		}
+	}
+	return group + ":" + string(gen) + ":" + name, nil
+}
+
+// load читает значение из кеша или из хранилища через get. Ошибки кеша не мешают
+// чтению, запрос просто идет в хранилище.
+func load[T any](ctx context.Context, s *Storage, group, name string, get func(ctx context.Context) (T, error)) (T, error) {
+	if ctx.Value(txKey{}) != nil {
+		return get(ctx)
+	}
+
+	key, err := s.key(ctx, group, name)
+	if err != nil {
+		s.log.Warn("failed read cache", zap.Error(err))
+		return get(ctx)
+	}
+
+	var value T
+	data, ok, err := s.cache.Get(ctx, key)
+	if err != nil {
+		s.log.Warn("failed read cache", zap.Error(err))
+	}
+	if ok {
+		if err = json.Unmarshal(data, &value); err == nil {
+			return value, nil
+		}
+		s.log.Warn("failed decode cache", zap.String("key", key), zap.Error(err))
+	}
+
+	value, err = get(ctx)
+	if err != nil {
+		return value, err
+	}
+	if data, err = json.Marshal(value); err != nil {
+		s.log.Warn("failed encode cache", zap.String("key", key), zap.Error(err))
+		return value, nil
+	}
+	if err = s.cache.Set(ctx, key, data, s.ttl); err != nil {
+		s.log.Warn("failed write cache", zap.Error(err))
+	}
+	return value, nil
+}
+
+func (s *Storage) GetAllTournaments(ctx context.Context) (*[]models.Tournament, error) {
+	return load(ctx, s, groupTournaments, "all", s.Store.GetAllTournaments)
+}
+
+func (s *Storage) NewTournament(ctx context.Context, tournament *models.Tournament) (*models.Tournament, error) {
+	tournament, err := s.Store.NewTournament(ctx, tournament)
+	if err == nil {
+		s.changed(ctx, groupTournaments)
+	}
+	return tournament, err
+}
+
+func (s *Storage) UpdTournamentByUser(ctx context.Context, tournament *models.Tournament) (*models.Tournament, error) {
+	tournament, err := s.Store.UpdTournamentByUser(ctx, tournament)
+	if err == nil {
+		s.changed(ctx, groupTournaments)
+	}
+	return tournament, err
+}
+
+func (s *Storage) DelTournament(ctx context.Context, tournamentID uint, at time.Time) error {
+	err := s.Store.DelTournament(ctx, tournamentID, at)
+	if err == nil {
+		s.changed(ctx, groupTournaments)
+	}
+	return err
+}
+
+func (s *Storage) RestoreTournament(ctx context.Context, userID, tournamentID uint, after time.Time) error {
+	err := s.Store.RestoreTournament(ctx, userID, tournamentID, after)
+	if err == nil {
+		s.changed(ctx, groupTournaments)
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package cached_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sport-space/internal/adapter/cache/lru"
+	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/storage"
+	"sport-space/internal/adapter/storage/cached"
+	"sport-space/internal/adapter/storage/memory"
+)
+
+// countingStore считает чтения турниров, дошедшие до хранилища.
+type countingStore struct {
+	storage.Store
+	reads atomic.Int32
+}
+
+func (s *countingStore) GetAllTournaments(ctx context.Context) (*[]models.Tournament, error) {
+	s.reads.Add(1)
+	return s.Store.GetAllTournaments(ctx)
+}
+
+func newStore(t *testing.T) (storage.Store, *countingStore) {
+	t.Helper()
+	inner := &countingStore{Store: memory.New()}
+	return cached.New(inner, lru.New(lru.Config{Size: 100}), cached.SetTTL(time.Minute)), inner
+}
+
+func newTournament(t *testing.T, ctx context.Context, s storage.Store) {
+	t.Helper()
+	now := time.Now()
+	_, err := s.NewTournament(ctx, &models.Tournament{
+		UserID: 1, Title: "cup", StartDate: &now, EndDate: &now, RegisterStartDate: &now, RegisterEndDate: &now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// all читает турниры и проверяет их количество.
+func all(t *testing.T, ctx context.Context, s storage.Store, want int) {
+	t.Helper()
+	tournaments, err := s.GetAllTournaments(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*tournaments) != want {
+		t.Fatalf("expected %d tournaments, got %d", want, len(*tournaments))
+	}
+}
+
+func reads(t *testing.T, inner *countingStore, want int32) {
+	t.Helper()
+	if got := inner.reads.Load(); got != want {
+		t.Fatalf("expected %d store reads, got %d", want, got)
+	}
+}
+
+func TestReadThrough(t *testing.T) {
+	ctx := context.Background()
+	s, inner := newStore(t)
+	newTournament(t, ctx, s)
+
+	all(t, ctx, s, 1)
+	all(t, ctx, s, 1)
+	reads(t, inner, 1)
+}
+
+func TestWriteInvalidates(t *testing.T) {
+	ctx := context.Background()
+	s, inner := newStore(t)
+
+	all(t, ctx, s, 0)
+	newTournament(t, ctx, s)
+	all(t, ctx, s, 1)
+	reads(t, inner, 2)
+
+	// новое поколение закешировано
+	all(t, ctx, s, 1)
+	reads(t, inner, 2)
+}
+
+func TestTransactionInvalidatesAfterCommit(t *testing.T) {
+	ctx := context.Background()
+	s, inner := newStore(t)
+	all(t, ctx, s, 0)
+
+	err := s.Transaction(ctx, func(txCtx context.Context) error {
+		newTournament(t, txCtx, s)
+		// чтение в транзакции идет мимо кеша
+		all(t, txCtx, s, 1)
+		// до коммита остальные читают прежнее поколение
+		all(t, ctx, s, 0)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reads(t, inner, 2)
+
+	all(t, ctx, s, 1)
+	reads(t, inner, 3)
+}
+
+func TestTransactionRollbackKeepsCache(t *testing.T) {
+	ctx := context.Background()
+	s, inner := newStore(t)
+	all(t, ctx, s, 0)
+
+	errRollback := errors.New("rollback")
+	err := s.Transaction(ctx, func(txCtx context.Context) error {
+		newTournament(t, txCtx, s)
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("expected rollback error, got %v", err)
+	}
+
+	all(t, ctx, s, 0)
+	reads(t, inner, 1)
+}
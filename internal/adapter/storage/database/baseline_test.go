@@ -0,0 +1,112 @@
+package database_test
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Модели на момент, когда схема создавалась AutoMigrate, до перехода на миграции.
+// Базы, созданные ими, должны обновляться миграциями без потери данных.
+
+type baselineUser struct {
+	ID           uint `gorm:"primarykey"`
+	Login        string
+	Email        string               `gorm:"index"`
+	Tournaments  []baselineTournament `gorm:"foreignKey:UserID"`
+	Teams        []baselineTeam       `gorm:"foreignKey:UserID"`
+	Players      []baselinePlayer     `gorm:"foreignKey:UserID"`
+	PasswordHash string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+type baselineOTPUser struct {
+	ID        uint         `gorm:"primarykey"`
+	UserID    uint         `gorm:"index;not null"`
+	User      baselineUser `gorm:"foreignKey:UserID"`
+	Password  string
+	Attempt   uint
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineOTPUser) TableName() string { return "otp_users" }
+
+type baselineTournament struct {
+	ID                uint `gorm:"primarykey"`
+	UserID            uint `gorm:"index;not null"`
+	Title             string
+	Description       string
+	Organization      string
+	LogoURL           string
+	Applications      []baselineApplication `gorm:"foreignKey:TournamentID"`
+	StartDate         *time.Time            `gorm:"not null"`
+	EndDate           *time.Time            `gorm:"not null"`
+	RegisterStartDate *time.Time            `gorm:"not null"`
+	RegisterEndDate   *time.Time            `gorm:"not null"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	DeletedAt         gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineTournament) TableName() string { return "tournaments" }
+
+type baselineTeam struct {
+	ID           uint `gorm:"primarykey"`
+	UserID       uint `gorm:"index;not null"`
+	Title        string
+	LogoURL      string
+	PhotoURL     string
+	Players      []baselinePlayer      `gorm:"many2many:team_players;joinForeignKey:TeamID;joinReferences:PlayerID"`
+	Applications []baselineApplication `gorm:"foreignKey:TeamID"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineTeam) TableName() string { return "teams" }
+
+type baselinePlayer struct {
+	ID         uint `gorm:"primarykey"`
+	UserID     uint `gorm:"index;not null"`
+	FirstName  string
+	SecondName string
+	LastName   string
+	BDay       *time.Time `gorm:"default:null"`
+	PhotoURL   string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselinePlayer) TableName() string { return "players" }
+
+type baselineApplication struct {
+	ID           uint             `gorm:"primarykey"`
+	TeamID       uint             `gorm:"index:idx_application,unique;not null"`
+	TournamentID uint             `gorm:"index:idx_application,unique;not null"`
+	Players      []baselinePlayer `gorm:"many2many:application_players;joinForeignKey:ApplicationID;joinReferences:PlayerID"`
+	Status       string           `gorm:"index:idx_status;not null"`
+	StatusDate   time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineApplication) TableName() string { return "applications" }
+
+// autoMigrateBaseline создает схему так, как ее создавал AutoMigrate до миграций.
+func autoMigrateBaseline(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&baselineUser{},
+		&baselineOTPUser{},
+		&baselineTournament{},
+		&baselineTeam{},
+		&baselinePlayer{},
+		&baselineApplication{},
+	)
+}
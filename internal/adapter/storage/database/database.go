@@ -11,6 +11,8 @@ import (
 	"sport-space/internal/adapter/models"
 	"sport-space/internal/adapter/storage/errstore"
 
+	gosqlite "github.com/glebarez/go-sqlite"
+	"github.com/glebarez/sqlite"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5/pgconn"
 	"go.uber.org/zap"
@@ -18,23 +20,60 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const (
+	dialectPostgres = "postgres"
+	dialectSQLite   = "sqlite"
 )
 
 type Storage struct {
-	db  *gorm.DB
-	log *zap.Logger
+	db      *gorm.DB
+	log     *zap.Logger
+	metrics queryMetrics
+	dialect string
 }
 
 type Config struct {
 	DSN string `env:"DATABASE_URI"`
+	// Migrate применять миграции при запуске, иначе командой `sportspace migrate`.
+	Migrate bool `env:"DATABASE_MIGRATE" envDefault:"true"`
+}
+
+// SQLiteConfig база в одном файле для небольших установок на одном сервере.
+type SQLiteConfig struct {
+	Path    string `env:"SQLITE_PATH" envDefault:"sportspace.db"`
+	Migrate bool   `env:"DATABASE_MIGRATE" envDefault:"true"`
 }
 
 type option func(s *Storage)
 
+func SetLogger(l *zap.Logger) option {
+	return func(s *Storage) {
+		s.log = l
+	}
+}
+
 func New(ctx context.Context, cfg Config, options ...option) (*Storage, error) {
+	return open(ctx, postgres.Open(cfg.DSN), dialectPostgres, cfg.Migrate, options...)
+}
+
+// NewSQLite хранилище в файле sqlite. Транзакции сразу берут блокировку на запись,
+// поэтому параллельные запросы выполняются по очереди, а не падают с SQLITE_BUSY.
+func NewSQLite(ctx context.Context, cfg SQLiteConfig, options ...option) (*Storage, error) {
+	dsn := "file:" + cfg.Path +
+		"?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate"
+	return open(ctx, sqlite.Open(dsn), dialectSQLite, cfg.Migrate, options...)
+}
+
+func open(ctx context.Context, dialector gorm.Dialector, dialect string, migrate bool, options ...option) (
+	*Storage, error,
+) {
 	var err error
 	s := &Storage{
-		log: zap.NewNop(),
+		log:     zap.NewNop(),
+		dialect: dialect,
 	}
 	lgr := logger.New(
 		log.New(os.Stdout, "\r\n", log.LstdFlags), // io writer
@@ -46,7 +85,7 @@ func New(ctx context.Context, cfg Config, options ...option) (*Storage, error) {
 			Colorful:                  true,
 		},
 	)
-	db, err := gorm.Open(postgres.Open(cfg.DSN), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: lgr,
 	})
 	if err != nil {
@@ -59,24 +98,63 @@ func New(ctx context.Context, cfg Config, options ...option) (*Storage, error) {
 		opt(s)
 	}
 
-	err = s.db.AutoMigrate(
-		&models.User{},
-		&models.OTPUser{},
-		&models.Tournament{},
-		&models.Team{},
-		&models.Player{},
-		// &models.TeamPlayer{},
-		&models.Application{},
-		// &models.ApplicationPlayer{},
-	)
+	if err = s.registerTracing(db); err != nil {
+		return nil, fmt.Errorf("failed register query tracing: %w", err)
+	}
+	if s.metrics != nil {
+		if err = s.registerMetrics(db); err != nil {
+			return nil, fmt.Errorf("failed register query metrics: %w", err)
+		}
+	}
 
-	if err != nil {
-		return nil, fmt.Errorf("migration failed: %w", err)
+	if migrate {
+		if _, err = s.Migrate(ctx); err != nil {
+			return nil, fmt.Errorf("migration failed: %w", err)
+		}
 	}
 
 	return s, nil
 }
 
+// Close закрывает пул соединений с базой.
+func (s *Storage) Close() error {
+	db, err := s.db.DB()
+	if err != nil {
+		return fmt.Errorf("failed get database pool: %w", err)
+	}
+	if err = db.Close(); err != nil {
+		return fmt.Errorf("failed close database: %w", err)
+	}
+	return nil
+}
+
+// notUpdated ошибка обновления, которое не затронуло строк: записи нет или ее версия
+// уже изменилась.
+func notUpdated(db *gorm.DB, model any, query string, args ...any) error {
+	var count int64
+	if err := db.Model(model).Where(query, args...).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed check record: %w", err)
+	}
+	if count == 0 {
+		return errstore.ErrNotFoundData
+	}
+	return errstore.ErrVersionConflict
+}
+
+// isUniqueViolation нарушение уникального индекса в postgres или sqlite.
+func isUniqueViolation(err error) bool {
+	var pgError *pgconn.PgError
+	if errors.As(err, &pgError) {
+		return pgError.Code == pgerrcode.UniqueViolation
+	}
+	var sqliteError *gosqlite.Error
+	if errors.As(err, &sqliteError) {
+		return sqliteError.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE ||
+			sqliteError.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	return false
+}
+
 func (s *Storage) NewUser(ctx context.Context, login, email, passwordHash string) (*models.User, error) {
 	user := &models.User{
 		Login:        login,
@@ -84,7 +162,7 @@ func (s *Storage) NewUser(ctx context.Context, login, email, passwordHash string
 		PasswordHash: passwordHash,
 	}
 
-	err := s.db.WithContext(ctx).Save(user).Error
+	err := s.conn(ctx).Save(user).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed create user: %w", err)
 	}
@@ -96,7 +174,19 @@ func (s *Storage) GetUserByEmail(ctx context.Context, email string) (*models.Use
 	user := &models.User{
 		Email: email,
 	}
-	err := s.db.WithContext(ctx).Where("email = ?", email).First(user).Error
+	err := s.conn(ctx).Where("email = ?", email).First(user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Join(err, errstore.ErrNotFoundData)
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *Storage) GetUserByPhone(ctx context.Context, phone string) (*models.User, error) {
+	user := &models.User{}
+	err := s.conn(ctx).Where("phone = ?", phone).First(user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.Join(err, errstore.ErrNotFoundData)
@@ -110,7 +200,7 @@ func (s *Storage) GetUserByID(ctx context.Context, userID uint) (*models.User, e
 	user := &models.User{
 		ID: userID,
 	}
-	err := s.db.WithContext(ctx).First(user).Error
+	err := s.conn(ctx).First(user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.Join(err, errstore.ErrNotFoundData)
@@ -120,8 +210,16 @@ func (s *Storage) GetUserByID(ctx context.Context, userID uint) (*models.User, e
 	return user, nil
 }
 
+func (s *Storage) UpdUser(ctx context.Context, user *models.User) (*models.User, error) {
+	err := s.conn(ctx).Save(user).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed update user: %w", err)
+	}
+	return user, nil
+}
+
 func (s *Storage) NewOTP(ctx context.Context, otp *models.OTPUser) error {
-	err := s.db.WithContext(ctx).Save(otp).Error
+	err := s.conn(ctx).Save(otp).Error
 	if err != nil {
 		return fmt.Errorf("failed create otp: %w", err)
 	}
@@ -132,7 +230,7 @@ func (s *Storage) GetOTP(ctx context.Context, user *models.User) (*models.OTPUse
 	otp := &models.OTPUser{
 		UserID: user.ID,
 	}
-	err := s.db.WithContext(ctx).Where("user_id = ?", user.ID).First(otp).Error
+	err := s.conn(ctx).Where("user_id = ?", user.ID).First(otp).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return otp, errors.Join(err, errstore.ErrNotFoundData)
@@ -144,7 +242,7 @@ func (s *Storage) GetOTP(ctx context.Context, user *models.User) (*models.OTPUse
 
 func (s *Storage) RemoveOTP(ctx context.Context, user *models.User) error {
 	otp := &models.OTPUser{}
-	err := s.db.WithContext(ctx).Where("user_id = ?", user.ID).Delete(otp).Error
+	err := s.conn(ctx).Where("user_id = ?", user.ID).Delete(otp).Error
 	if err != nil {
 		return fmt.Errorf("failed remove opt: %w", err)
 	}
@@ -154,7 +252,7 @@ func (s *Storage) RemoveOTP(ctx context.Context, user *models.User) error {
 
 func (s *Storage) GetAllTournaments(ctx context.Context) (tournaments *[]models.Tournament, err error) {
 	tournaments = &[]models.Tournament{}
-	err = s.db.Find(tournaments).Error
+	err = s.conn(ctx).Find(tournaments).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return tournaments, errors.Join(err, errstore.ErrNotFoundData)
@@ -167,7 +265,7 @@ func (s *Storage) GetAllTournaments(ctx context.Context) (tournaments *[]models.
 func (s *Storage) NewTournament(ctx context.Context, tournament *models.Tournament) (
 	*models.Tournament, error,
 ) {
-	err := s.db.Create(tournament).Error
+	err := s.conn(ctx).Create(tournament).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed create torunament: %w", err)
 	}
@@ -176,7 +274,7 @@ func (s *Storage) NewTournament(ctx context.Context, tournament *models.Tourname
 
 func (s *Storage) GetTournaments(ctx context.Context, userID uint) (*[]models.Tournament, error) {
 	tournaments := &[]models.Tournament{}
-	err := s.db.Where("user_id = ?", userID).Find(tournaments).Error
+	err := s.conn(ctx).Where("user_id = ?", userID).Find(tournaments).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.Join(err, errstore.ErrNotFoundData)
@@ -189,7 +287,7 @@ func (s *Storage) GetTournaments(ctx context.Context, userID uint) (*[]models.To
 
 func (s *Storage) GetTournamentByID(ctx context.Context, tournamentID uint) (*models.Tournament, error) {
 	tournament := &models.Tournament{}
-	err := s.db.Where("id = ?", tournamentID).Preload("Applications").First(tournament).Error
+	err := s.conn(ctx).Where("id = ?", tournamentID).Preload("Applications").First(tournament).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.Join(err, errstore.ErrNotFoundData)
@@ -200,22 +298,29 @@ func (s *Storage) GetTournamentByID(ctx context.Context, tournamentID uint) (*mo
 	return tournament, nil
 }
 
+// UpdTournamentByUser обновляет турнир пользователя той же версии, что и tournament.Version,
+// и увеличивает версию.
 func (s *Storage) UpdTournamentByUser(ctx context.Context, tournament *models.Tournament) (*models.Tournament, error) {
-	res := s.db.Model(tournament).
-		Where("user_id = ? and id = ?", tournament.UserID, tournament.ID).
+	version := tournament.Version
+	tournament.Version++
+	// Select("*") отключает вставку в Save, если чужой турнир не обновился
+	res := s.conn(ctx).Model(tournament).
+		Where("user_id = ? and id = ? and version = ?", tournament.UserID, tournament.ID, version).
+		Select("*").
 		Save(tournament)
-	if res.RowsAffected == 0 {
-		return nil, errstore.ErrNotFoundData
-	}
 	if err := res.Error; err != nil {
 		return nil, fmt.Errorf("failed update tournament by user: %w", err)
 	}
+	if res.RowsAffected == 0 {
+		return nil, notUpdated(s.conn(ctx), &models.Tournament{},
+			"user_id = ? and id = ?", tournament.UserID, tournament.ID)
+	}
 
 	return tournament, nil
 }
 
 func (s *Storage) NewTeam(ctx context.Context, team *models.Team) (*models.Team, error) {
-	err := s.db.Create(team).Error
+	err := s.conn(ctx).Create(team).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed create team: %w", err)
 	}
@@ -224,7 +329,7 @@ func (s *Storage) NewTeam(ctx context.Context, team *models.Team) (*models.Team,
 
 func (s *Storage) GetTeams(ctx context.Context, user *models.User) (*[]models.Team, error) {
 	teams := &[]models.Team{}
-	err := s.db.Where("user_id = ?", user.ID).Find(teams).Error
+	err := s.conn(ctx).Where("user_id = ?", user.ID).Find(teams).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return teams, errstore.ErrNotFoundData
@@ -237,7 +342,7 @@ func (s *Storage) GetTeams(ctx context.Context, user *models.User) (*[]models.Te
 
 func (s *Storage) GetTeamByID(ctx context.Context, teamID uint) (*models.Team, error) {
 	team := &models.Team{}
-	err := s.db.Where("id = ?", teamID).Preload("Players").First(team).Error
+	err := s.conn(ctx).Where("id = ?", teamID).Preload("Players").First(team).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errstore.ErrNotFoundData
@@ -247,8 +352,20 @@ func (s *Storage) GetTeamByID(ctx context.Context, teamID uint) (*models.Team, e
 	return team, nil
 }
 
+// UpdTeam обновляет команду той же версии, что и team.Version, и увеличивает версию.
 func (s *Storage) UpdTeam(ctx context.Context, team *models.Team, playersIDs *[]uint) (*models.Team, *[]models.Player, error) {
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err := s.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Team{}).
+			Where("id = ? and version = ?", team.ID, team.Version).
+			Update("version", team.Version+1)
+		if res.Error != nil {
+			return fmt.Errorf("failed update team version: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return notUpdated(tx, &models.Team{}, "id = ?", team.ID)
+		}
+		team.Version++
+
 		if playersIDs != nil {
 			_players := &[]models.Player{}
 			err := tx.Where("id IN ?", *playersIDs).Find(_players).Error
@@ -269,23 +386,30 @@ func (s *Storage) UpdTeam(ctx context.Context, team *models.Team, playersIDs *[]
 		return nil
 	})
 	if err != nil {
+		if errors.Is(err, errstore.ErrVersionConflict) || errors.Is(err, errstore.ErrNotFoundData) {
+			return nil, nil, err
+		}
 		return nil, nil, fmt.Errorf("failed update team by user: %w", err)
 	}
 	return team, &team.Players, nil
 }
 
 func (s *Storage) NewPlayer(ctx context.Context, player *models.Player) (*models.Player, error) {
-	err := s.db.Create(player).Error
+	err := s.conn(ctx).Create(player).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed create player: %w", err)
 	}
 	return player, nil
 }
 func (s *Storage) NewPlayerBatch(ctx context.Context, players *[]models.Player) (*[]models.Player, error) {
-	err := s.db.Clauses(clause.OnConflict{
+	// существующие игроки обновляются с увеличением версии, RETURNING возвращает id и новую версию
+	updates := append(clause.AssignmentColumns([]string{
+		"first_name", "second_name", "last_name", "b_day", "photo_url", "medical_certificate_url",
+	}), clause.Assignment{Column: clause.Column{Name: "version"}, Value: gorm.Expr("players.version + 1")})
+	err := s.conn(ctx).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"first_name", "second_name", "last_name", "b_day", "photo_url"}),
-	}).Create(players).Error
+		DoUpdates: updates,
+	}, clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "version"}}}).Create(players).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed create batch players: %w", err)
 	}
@@ -294,7 +418,7 @@ func (s *Storage) NewPlayerBatch(ctx context.Context, players *[]models.Player)
 
 func (s *Storage) GetPlayers(ctx context.Context, userID uint) (*[]models.Player, error) {
 	players := &[]models.Player{}
-	err := s.db.Where("user_id = ?", userID).Find(players).Error
+	err := s.conn(ctx).Where("user_id = ?", userID).Find(players).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return players, errstore.ErrNotFoundData
@@ -307,7 +431,7 @@ func (s *Storage) GetPlayers(ctx context.Context, userID uint) (*[]models.Player
 
 func (s *Storage) GetPlayerByID(ctx context.Context, playerID uint) (*models.Player, error) {
 	player := &models.Player{}
-	err := s.db.Where("id = ?", playerID).First(player).Error
+	err := s.conn(ctx).Where("id = ?", playerID).First(player).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.Join(err, errstore.ErrNotFoundData)
@@ -319,7 +443,7 @@ func (s *Storage) GetPlayerByID(ctx context.Context, playerID uint) (*models.Pla
 
 func (s *Storage) GetPlayersByIDs(ctx context.Context, playerIDs []uint) (*[]models.Player, error) {
 	players := &[]models.Player{}
-	err := s.db.Where("id IN ?", playerIDs).Find(players).Error
+	err := s.conn(ctx).Where("id IN ?", playerIDs).Find(players).Error
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("failed find player: %w", err)
 	}
@@ -327,7 +451,12 @@ func (s *Storage) GetPlayersByIDs(ctx context.Context, playerIDs []uint) (*[]mod
 }
 
 func (s *Storage) UpdPlayer(ctx context.Context, player *models.Player) (*models.Player, error) {
-	res := s.db.Where("id = ? and user_id = ?", player.ID, player.UserID).Save(player)
+	version := player.Version
+	player.Version++
+	// Select("*") отключает вставку в Save, если чужой игрок не обновился
+	res := s.conn(ctx).Where("id = ? and user_id = ? and version = ?", player.ID, player.UserID, version).
+		Select("*").
+		Save(player)
 	if err := res.Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.Join(err, errstore.ErrNotFoundData)
@@ -335,14 +464,14 @@ func (s *Storage) UpdPlayer(ctx context.Context, player *models.Player) (*models
 		return nil, fmt.Errorf("failed update player: %w", err)
 	}
 	if res.RowsAffected == 0 {
-		return nil, errstore.ErrNotFoundData
+		return nil, notUpdated(s.conn(ctx), &models.Player{}, "id = ? and user_id = ?", player.ID, player.UserID)
 	}
 	return player, nil
 }
 
 func (s *Storage) GetPlayersFromTeam(ctx context.Context, teamID uint) (*[]models.Player, error) {
 	players := &[]models.Player{}
-	err := s.db.Joins("JOIN team_players on team_players.player_id = players.id", s.db.Where("team_players.team_id = ?", teamID)).Find(players).Error
+	err := s.conn(ctx).Joins("JOIN team_players on team_players.player_id = players.id", s.conn(ctx).Where("team_players.team_id = ?", teamID)).Find(players).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errstore.ErrNotFoundData
@@ -358,7 +487,7 @@ func (s *Storage) NewApplication(ctx context.Context, application *models.Applic
 ) {
 	application.Status = models.Draft
 	application.StatusDate = time.Now()
-	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err := s.conn(ctx).Transaction(func(tx *gorm.DB) error {
 		err := tx.Create(application).Error
 		if err != nil {
 			return fmt.Errorf("failed create application: %w", err)
@@ -371,8 +500,7 @@ func (s *Storage) NewApplication(ctx context.Context, application *models.Applic
 		return nil
 	})
 	if err != nil {
-		var sqlError *pgconn.PgError
-		if errors.As(err, &sqlError) && sqlError.Code == pgerrcode.UniqueViolation {
+		if isUniqueViolation(err) {
 			return nil, nil, errors.Join(err, errstore.ErrConflictData)
 		}
 		return nil, nil, fmt.Errorf("failed create application with transactions: %w", err)
@@ -383,7 +511,7 @@ func (s *Storage) NewApplication(ctx context.Context, application *models.Applic
 
 func (s *Storage) GetApplicationFromTeamTournament(ctx context.Context, tournamentID, teamID uint) (*models.Application, error) {
 	application := &models.Application{}
-	err := s.db.WithContext(ctx).Where("tournament_id = ? and team_id = ?", tournamentID, teamID).First(application).Error
+	err := s.conn(ctx).Where("tournament_id = ? and team_id = ?", tournamentID, teamID).First(application).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errstore.ErrNotFoundData
@@ -395,7 +523,7 @@ func (s *Storage) GetApplicationFromTeamTournament(ctx context.Context, tourname
 
 func (s *Storage) GetApplicationByID(ctx context.Context, applicationID uint) (*models.Application, error) {
 	application := &models.Application{}
-	err := s.db.Where("id = ?", applicationID).Preload("Players").First(application).Error
+	err := s.conn(ctx).Where("id = ?", applicationID).Preload("Players").First(application).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("not found application: %w", errors.Join(err, errstore.ErrNotFoundData))
@@ -405,16 +533,22 @@ func (s *Storage) GetApplicationByID(ctx context.Context, applicationID uint) (*
 	return application, nil
 }
 
+// UpdApplication обновляет заявку той же версии, что и application.Version, и увеличивает версию.
 func (s *Storage) UpdApplication(ctx context.Context, application *models.Application, players *[]models.Player) (
 	*models.Application, *[]models.Player, error,
 ) {
-	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		err := tx.Where("id = ?", application.ID).Updates(application).Error
-		if err != nil {
-			return fmt.Errorf("failed update application: %w", err)
+	err := s.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		version := application.Version
+		application.Version++
+		res := tx.Where("id = ? and version = ?", application.ID, version).Updates(application)
+		if res.Error != nil {
+			return fmt.Errorf("failed update application: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return notUpdated(tx, &models.Application{}, "id = ?", application.ID)
 		}
 		if *players != nil {
-			err = tx.Model(application).Association("Players").Replace(players)
+			err := tx.Model(application).Association("Players").Replace(players)
 			if err != nil {
 				return fmt.Errorf("failed create application batch players: %w", err)
 			}
@@ -426,10 +560,12 @@ func (s *Storage) UpdApplication(ctx context.Context, application *models.Applic
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil, fmt.Errorf("not found application: %w", errors.Join(err, errstore.ErrNotFoundData))
 		}
-		var sqlError *pgconn.PgError
-		if errors.As(err, &sqlError) && sqlError.Code == pgerrcode.UniqueViolation {
+		if isUniqueViolation(err) {
 			return nil, nil, errors.Join(err, errstore.ErrConflictData)
 		}
+		if errors.Is(err, errstore.ErrVersionConflict) || errors.Is(err, errstore.ErrNotFoundData) {
+			return nil, nil, err
+		}
 		return nil, nil, fmt.Errorf("failed update application with transactions: %w", err)
 	}
 
@@ -438,7 +574,7 @@ func (s *Storage) UpdApplication(ctx context.Context, application *models.Applic
 
 func (s *Storage) GetApplicationsByTeamID(ctx context.Context, teamID uint) (*[]models.Application, error) {
 	applications := &[]models.Application{}
-	err := s.db.Where("team_id = ?", teamID).Find(applications).Error
+	err := s.conn(ctx).Where("team_id = ?", teamID).Find(applications).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return applications, fmt.Errorf("not found applications: %w", errors.Join(err, errstore.ErrNotFoundData))
@@ -449,9 +585,22 @@ func (s *Storage) GetApplicationsByTeamID(ctx context.Context, teamID uint) (*[]
 	return applications, nil
 }
 
+// GetApplicationsByPlayerID заявки, в состав которых входит игрок.
+func (s *Storage) GetApplicationsByPlayerID(ctx context.Context, playerID uint) (*[]models.Application, error) {
+	applications := &[]models.Application{}
+	err := s.conn(ctx).
+		Joins("JOIN application_players ON application_players.application_id = applications.id").
+		Where("application_players.player_id = ?", playerID).
+		Find(applications).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed get player applications: %w", err)
+	}
+	return applications, nil
+}
+
 func (s *Storage) GetPlayersFromApplication(ctx context.Context, applicationID uint) (*[]models.Player, error) {
 	application := &models.Application{}
-	err := s.db.Where("id = ?", applicationID).Preload("Players").First(application).Error
+	err := s.conn(ctx).Where("id = ?", applicationID).Preload("Players").First(application).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return &application.Players, fmt.Errorf("not found players from application: %w", errors.Join(err, errstore.ErrNotFoundData))
@@ -464,7 +613,7 @@ func (s *Storage) GetPlayersFromApplication(ctx context.Context, applicationID u
 func (s *Storage) GetApplicationsFromTournament(ctx context.Context, tournamentID uint) (*[]models.Application, error) {
 	applications := &[]models.Application{}
 	statuses := []models.ApplicationStatus{models.InProgress, models.Accepted, models.Rejected}
-	err := s.db.Model(&models.Tournament{}).Where("tournament_id = ? and status in ?", tournamentID, statuses).Association("Applications").Find(applications)
+	err := s.conn(ctx).Where("tournament_id = ? and status in ?", tournamentID, statuses).Find(applications).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("not found applications: %w", errors.Join(err, errstore.ErrNotFoundData))
@@ -474,10 +623,307 @@ func (s *Storage) GetApplicationsFromTournament(ctx context.Context, tournamentI
 	return applications, nil
 }
 
+// UpdApplicationTournament обновляет заявку той же версии, что и application.Version,
+// и увеличивает версию.
 func (s *Storage) UpdApplicationTournament(ctx context.Context, application *models.Application) (*models.Application, error) {
-	err := s.db.WithContext(ctx).Where("id = ?", application.ID).Updates(application).Error
-	if err != nil {
-		return nil, fmt.Errorf("failed update application: %w", err)
+	version := application.Version
+	application.Version++
+	res := s.conn(ctx).Where("id = ? and version = ?", application.ID, version).Updates(application)
+	if res.Error != nil {
+		return nil, fmt.Errorf("failed update application: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, notUpdated(s.conn(ctx), &models.Application{}, "id = ?", application.ID)
 	}
 	return application, nil
 }
+
+func (s *Storage) GetActiveApplicationsFromTournament(ctx context.Context, tournamentID uint) (*[]models.Application, error) {
+	applications := &[]models.Application{}
+	err := s.conn(ctx).
+		Where("tournament_id = ? and status <> ?", tournamentID, models.Canceled).
+		Preload("Players").
+		Find(applications).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed get active applications: %w", err)
+	}
+	return applications, nil
+}
+
+func (s *Storage) NewRosterChange(ctx context.Context, change *models.RosterChange) (*models.RosterChange, error) {
+	err := s.conn(ctx).Create(change).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed create roster change: %w", err)
+	}
+	return change, nil
+}
+
+func (s *Storage) GetRosterChangeByID(ctx context.Context, changeID uint) (*models.RosterChange, error) {
+	change := &models.RosterChange{}
+	err := s.conn(ctx).Where("id = ?", changeID).First(change).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("not found roster change: %w", errors.Join(err, errstore.ErrNotFoundData))
+		}
+		return nil, fmt.Errorf("failed get roster change: %w", err)
+	}
+	return change, nil
+}
+
+func (s *Storage) GetRosterChangesByApplicationID(ctx context.Context, applicationID uint) (*[]models.RosterChange, error) {
+	changes := &[]models.RosterChange{}
+	err := s.conn(ctx).Where("application_id = ?", applicationID).Order("id").Find(changes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed get roster changes: %w", err)
+	}
+	return changes, nil
+}
+
+// UpdRosterChange обновляет запрос на замену, при players != nil заменяет состав заявки в той же транзакции.
+func (s *Storage) UpdRosterChange(ctx context.Context, change *models.RosterChange, players *[]models.Player) (
+	*models.RosterChange, error,
+) {
+	err := s.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Save(change).Error
+		if err != nil {
+			return fmt.Errorf("failed update roster change: %w", err)
+		}
+		if players != nil {
+			application := &models.Application{ID: change.ApplicationID}
+			err = tx.Model(application).Association("Players").Replace(players)
+			if err != nil {
+				return fmt.Errorf("failed replace application players: %w", err)
+			}
+			err = tx.Model(application).UpdateColumn("version", gorm.Expr("version + 1")).Error
+			if err != nil {
+				return fmt.Errorf("failed update application version: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed update roster change with transactions: %w", err)
+	}
+	return change, nil
+}
+
+func (s *Storage) GetNotificationSettings(ctx context.Context, userID uint) (*models.NotificationSettings, error) {
+	settings := &models.NotificationSettings{}
+	err := s.conn(ctx).Where("user_id = ?", userID).First(settings).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Join(err, errstore.ErrNotFoundData)
+		}
+		return nil, fmt.Errorf("failed get notification settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (s *Storage) SaveNotificationSettings(ctx context.Context, settings *models.NotificationSettings) (
+	*models.NotificationSettings, error,
+) {
+	err := s.conn(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"application_status", "application_submitted", "application_canceled", "updated_at",
+		}),
+	}).Create(settings).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed save notification settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (s *Storage) NewOutboxMessage(ctx context.Context, msg *models.OutboxMessage) error {
+	err := s.conn(ctx).Create(msg).Error
+	if err != nil {
+		return fmt.Errorf("failed create outbox message: %w", err)
+	}
+	return nil
+}
+
+// ClaimOutboxMessages выбирает письма готовые к отправке и откладывает их на время lease,
+// что бы их не взял другой обработчик или другая реплика.
+func (s *Storage) ClaimOutboxMessages(ctx context.Context, limit int, lease time.Duration) (
+	*[]models.OutboxMessage, error,
+) {
+	messages := &[]models.OutboxMessage{}
+	err := s.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? and next_attempt_at <= ?", models.OutboxPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(messages).Error
+		if err != nil {
+			return fmt.Errorf("failed select outbox messages: %w", err)
+		}
+		if len(*messages) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(*messages))
+		for _, m := range *messages {
+			ids = append(ids, m.ID)
+		}
+		err = tx.Model(&models.OutboxMessage{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+		if err != nil {
+			return fmt.Errorf("failed lease outbox messages: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed claim outbox messages: %w", err)
+	}
+	return messages, nil
+}
+
+func (s *Storage) UpdOutboxMessage(ctx context.Context, msg *models.OutboxMessage) error {
+	err := s.conn(ctx).Save(msg).Error
+	if err != nil {
+		return fmt.Errorf("failed update outbox message: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) GetOutboxMessages(ctx context.Context, status models.OutboxStatus) (*[]models.OutboxMessage, error) {
+	messages := &[]models.OutboxMessage{}
+	err := s.conn(ctx).Where("status = ?", status).Order("id desc").Find(messages).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed get outbox messages: %w", err)
+	}
+	return messages, nil
+}
+
+func (s *Storage) GetOutboxMessageByID(ctx context.Context, id uint) (*models.OutboxMessage, error) {
+	msg := &models.OutboxMessage{}
+	err := s.conn(ctx).Where("id = ?", id).First(msg).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Join(err, errstore.ErrNotFoundData)
+		}
+		return nil, fmt.Errorf("failed get outbox message: %w", err)
+	}
+	return msg, nil
+}
+
+func (s *Storage) NewUpload(ctx context.Context, upload *models.Upload) (*models.Upload, error) {
+	err := s.conn(ctx).Create(upload).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed create upload: %w", err)
+	}
+	return upload, nil
+}
+
+func (s *Storage) GetUploadByURL(ctx context.Context, url string) (*models.Upload, error) {
+	upload := &models.Upload{}
+	err := s.conn(ctx).Where("url = ?", url).First(upload).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Join(err, errstore.ErrNotFoundData)
+		}
+		return nil, fmt.Errorf("failed get upload: %w", err)
+	}
+	return upload, nil
+}
+
+// GetUploadsSize суммарный размер загрузок пользователя в байтах.
+func (s *Storage) GetUploadsSize(ctx context.Context, userID uint) (int64, error) {
+	var size int64
+	err := s.conn(ctx).Model(&models.Upload{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(SUM(size), 0)").
+		Scan(&size).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed get uploads size: %w", err)
+	}
+	return size, nil
+}
+
+// GetOrphanUploads загрузки старше before, на которые не ссылается ни одна запись,
+// в том числе удаленная, чтобы файлы восстановленных записей не пропадали.
+func (s *Storage) GetOrphanUploads(ctx context.Context, before time.Time, limit int) (*[]models.Upload, error) {
+	uploads := &[]models.Upload{}
+	err := s.conn(ctx).
+		Where("created_at < ?", before).
+		Where("NOT EXISTS (SELECT 1 FROM teams WHERE teams.logo_url = uploads.url OR teams.photo_url = uploads.url)").
+		Where("NOT EXISTS (SELECT 1 FROM players WHERE players.photo_url = uploads.url OR players.medical_certificate_url = uploads.url)").
+		Where("NOT EXISTS (SELECT 1 FROM tournaments WHERE tournaments.logo_url = uploads.url)").
+		Order("id").
+		Limit(limit).
+		Find(uploads).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed get orphan uploads: %w", err)
+	}
+	return uploads, nil
+}
+
+func (s *Storage) RemoveUpload(ctx context.Context, uploadID uint) error {
+	err := s.conn(ctx).Delete(&models.Upload{}, uploadID).Error
+	if err != nil {
+		return fmt.Errorf("failed remove upload: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) NewUploadSession(ctx context.Context, session *models.UploadSession) (*models.UploadSession, error) {
+	err := s.conn(ctx).Create(session).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed create upload session: %w", err)
+	}
+	return session, nil
+}
+
+func (s *Storage) GetUploadSession(ctx context.Context, sessionID string) (*models.UploadSession, error) {
+	session := &models.UploadSession{}
+	err := s.conn(ctx).Preload("Upload").Where("id = ?", sessionID).First(session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Join(err, errstore.ErrNotFoundData)
+		}
+		return nil, fmt.Errorf("failed get upload session: %w", err)
+	}
+	return session, nil
+}
+
+// UpdUploadSession сохраняет сессию, если с момента чтения в нее не дописали часть:
+// received - значение Received при чтении.
+func (s *Storage) UpdUploadSession(ctx context.Context, session *models.UploadSession, received int64) error {
+	res := s.conn(ctx).Model(session).
+		Where("received = ?", received).
+		Select("mime_type", "received", "chunks", "upload_id", "updated_at").
+		Updates(session)
+	if res.Error != nil {
+		return fmt.Errorf("failed update upload session: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return errstore.ErrConflictData
+	}
+	return nil
+}
+
+func (s *Storage) RemoveUploadSession(ctx context.Context, sessionID string) error {
+	err := s.conn(ctx).Where("id = ?", sessionID).Delete(&models.UploadSession{}).Error
+	if err != nil {
+		return fmt.Errorf("failed remove upload session: %w", err)
+	}
+	return nil
+}
+
+// GetExpiredUploadSessions сессии загрузки с истекшим сроком.
+func (s *Storage) GetExpiredUploadSessions(ctx context.Context, before time.Time, limit int) (
+	*[]models.UploadSession, error,
+) {
+	sessions := &[]models.UploadSession{}
+	err := s.conn(ctx).
+		Where("expires_at < ?", before).
+		Order("expires_at").
+		Limit(limit).
+		Find(sessions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed get expired upload sessions: %w", err)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,135 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sport-space/internal/adapter/storage"
+	"sport-space/internal/adapter/storage/database"
+	"sport-space/internal/adapter/storage/storetest"
+
+	"github.com/glebarez/sqlite"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSQLite(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storage.Store {
+		s, err := database.NewSQLite(context.Background(), database.SQLiteConfig{
+			Path:    filepath.Join(t.TempDir(), "sportspace.db"),
+			Migrate: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		return s
+	})
+}
+
+// TestSQLiteFromAutoMigrate проверяет миграции на базе, созданной AutoMigrate до их появления.
+func TestSQLiteFromAutoMigrate(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storage.Store {
+		path := filepath.Join(t.TempDir(), "sportspace.db")
+		baseline(t, sqlite.Open("file:"+path+"?_pragma=foreign_keys(1)"))
+
+		s, err := database.NewSQLite(context.Background(), database.SQLiteConfig{Path: path, Migrate: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		return s
+	})
+}
+
+// TestPostgres проверяет хранилище на базе из TEST_DATABASE_URI, каждая подпроверка
+// работает в своей схеме, которая удаляется после нее.
+func TestPostgres(t *testing.T) {
+	admin, dsn := postgresAdmin(t)
+	storetest.Run(t, func(t *testing.T) storage.Store {
+		s, err := database.New(context.Background(), database.Config{DSN: postgresSchema(t, admin, dsn), Migrate: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		return s
+	})
+}
+
+// TestPostgresFromAutoMigrate проверяет миграции на базе, созданной AutoMigrate до их появления.
+func TestPostgresFromAutoMigrate(t *testing.T) {
+	admin, dsn := postgresAdmin(t)
+	storetest.Run(t, func(t *testing.T) storage.Store {
+		schemaDSN := postgresSchema(t, admin, dsn)
+		baseline(t, postgres.Open(schemaDSN))
+
+		s, err := database.New(context.Background(), database.Config{DSN: schemaDSN, Migrate: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		return s
+	})
+}
+
+// baseline создает схему моделями до перехода на миграции.
+func baseline(t *testing.T, dialector gorm.Dialector) {
+	t.Helper()
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = autoMigrateBaseline(db); err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = sqlDB.Close()
+}
+
+func postgresAdmin(t *testing.T) (*sql.DB, string) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URI")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URI is not set")
+	}
+
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = admin.Close() })
+	return admin, dsn
+}
+
+// postgresSchema создает схему, удаляемую после проверки, и возвращает dsn с ней в search_path.
+func postgresSchema(t *testing.T, admin *sql.DB, dsn string) string {
+	t.Helper()
+	ctx := context.Background()
+	schema := fmt.Sprintf("storetest_%d", time.Now().UnixNano())
+	if _, err := admin.ExecContext(ctx, `CREATE SCHEMA "`+schema+`"`); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, _ = admin.ExecContext(context.Background(), `DROP SCHEMA "`+schema+`" CASCADE`)
+	})
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/storage/errstore"
+
+	"gorm.io/gorm"
+)
+
+// NewIdempotencyKey сохраняет ключ запроса, занятый ключ пользователя - ErrConflictData.
+func (s *Storage) NewIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	err := s.conn(ctx).Create(key).Error
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, errors.Join(err, errstore.ErrConflictData)
+		}
+		return nil, fmt.Errorf("failed create idempotency key: %w", err)
+	}
+	return key, nil
+}
+
+func (s *Storage) GetIdempotencyKey(ctx context.Context, userID uint, key string) (*models.IdempotencyKey, error) {
+	res := &models.IdempotencyKey{}
+	err := s.conn(ctx).Where("user_id = ? AND key = ?", userID, key).First(res).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Join(err, errstore.ErrNotFoundData)
+		}
+		return nil, fmt.Errorf("failed get idempotency key: %w", err)
+	}
+	return res, nil
+}
+
+// UpdIdempotencyKey сохраняет ответ на запрос с ключом.
+func (s *Storage) UpdIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error {
+	err := s.conn(ctx).Model(key).
+		Select("status_code", "content_type", "body", "updated_at").
+		Updates(key).Error
+	if err != nil {
+		return fmt.Errorf("failed update idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) RemoveIdempotencyKey(ctx context.Context, id uint) error {
+	err := s.conn(ctx).Delete(&models.IdempotencyKey{}, id).Error
+	if err != nil {
+		return fmt.Errorf("failed remove idempotency key: %w", err)
+	}
+	return nil
+}
+
+// PurgeIdempotencyKeys удаляет ключи, созданные до before.
+func (s *Storage) PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int, error) {
+	res := s.conn(ctx).Where("created_at < ?", before).Delete(&models.IdempotencyKey{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed purge idempotency keys: %w", res.Error)
+	}
+	return int(res.RowsAffected), nil
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// queryMetrics учет длительности запросов к базе.
+type queryMetrics interface {
+	ObserveQuery(operation, table string, d time.Duration, err error)
+}
+
+// SetMetrics учитывает каждый запрос gorm в m с операцией и таблицей.
+func SetMetrics(m queryMetrics) option {
+	return func(s *Storage) {
+		s.metrics = m
+	}
+}
+
+const metricsStartKey = "metrics:start"
+
+// registerMetrics добавляет в gorm callbacks вокруг всех операций, время начала
+// запроса хранится в экземпляре запроса.
+func (s *Storage) registerMetrics(db *gorm.DB) error {
+	before := func(db *gorm.DB) {
+		db.InstanceSet(metricsStartKey, time.Now())
+	}
+	after := func(operation string) func(db *gorm.DB) {
+		return func(db *gorm.DB) {
+			v, ok := db.InstanceGet(metricsStartKey)
+			start, isTime := v.(time.Time)
+			if !ok || !isTime {
+				return
+			}
+			err := db.Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				err = nil
+			}
+			s.metrics.ObserveQuery(operation, db.Statement.Table, time.Since(start), err)
+		}
+	}
+
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("*").Register("metrics:before_create", before),
+		cb.Create().After("*").Register("metrics:after_create", after("create")),
+		cb.Query().Before("*").Register("metrics:before_query", before),
+		cb.Query().After("*").Register("metrics:after_query", after("query")),
+		cb.Update().Before("*").Register("metrics:before_update", before),
+		cb.Update().After("*").Register("metrics:after_update", after("update")),
+		cb.Delete().Before("*").Register("metrics:before_delete", before),
+		cb.Delete().After("*").Register("metrics:after_delete", after("delete")),
+		cb.Row().Before("*").Register("metrics:before_row", before),
+		cb.Row().After("*").Register("metrics:after_row", after("row")),
+		cb.Raw().Before("*").Register("metrics:before_raw", before),
+		cb.Raw().After("*").Register("metrics:after_raw", after("raw")),
+	)
+}
@@ -0,0 +1,301 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// migrationLockID ключ pg_advisory_lock: миграции применяет только одна реплика,
+// остальные ждут ее и затем видят уже обновленную схему.
+const migrationLockID = 7_215_930_417
+
+// Файлы миграций `<диалект>/<версия>_<название>.up.sql` и `<диалект>/<версия>_<название>.down.sql`.
+// Любое изменение моделей сопровождается новой миграцией для каждого диалекта.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+var ErrMigrationNotFound = errors.New("migration not found")
+
+// sqliteAddColumn команда `ALTER TABLE "t" ADD COLUMN IF NOT EXISTS "c" ...;`, которой нет в SQLite.
+var sqliteAddColumn = regexp.MustCompile(`(?m)^ALTER TABLE "(\w+)" ADD COLUMN IF NOT EXISTS ("(\w+)"[^;]*;)`)
+
+type migration struct {
+	version uint
+	name    string
+	up      string
+	down    string
+}
+
+// MigrationState миграция и время ее применения, nil - не применена.
+type MigrationState struct {
+	Version   uint
+	Name      string
+	AppliedAt *time.Time
+}
+
+func loadMigrations(dialect string) ([]migration, error) {
+	files, err := fs.Glob(migrationFiles, path.Join("migrations", dialect, "*.sql"))
+	if err != nil {
+		return nil, fmt.Errorf("failed list migrations: %w", err)
+	}
+
+	byVersion := map[uint]*migration{}
+	for _, file := range files {
+		base := path.Base(file)
+		name, direction, ok := strings.Cut(strings.TrimSuffix(base, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name `%s`", base)
+		}
+		sVersion, title, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseUint(sVersion, 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version `%s`", base)
+		}
+
+		data, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed read migration `%s`: %w", base, err)
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &migration{version: uint(version), name: title}
+			byVersion[uint(version)] = m
+		}
+		if m.name != title {
+			return nil, fmt.Errorf("migration %d has different names `%s` and `%s`", version, m.name, title)
+		}
+		if direction == "up" {
+			m.up = string(data)
+		} else {
+			m.down = string(data)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have up and down files", m.version, m.name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations, nil
+}
+
+// withMigrationLock выполняет fn на отдельном соединении под advisory lock.
+// У sqlite одна реплика и блокировка не нужна.
+func (s *Storage) withMigrationLock(ctx context.Context, fn func(conn *sql.Conn, applied map[uint]time.Time) error) error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return fmt.Errorf("failed get sql db: %w", err)
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed get connection: %w", err)
+	}
+	defer conn.Close()
+
+	createTable := `CREATE TABLE IF NOT EXISTS "schema_migrations" (
+		"version" bigint PRIMARY KEY,
+		"name" text NOT NULL,
+		"applied_at" timestamptz NOT NULL DEFAULT now()
+	)`
+	if s.dialect == dialectSQLite {
+		createTable = `CREATE TABLE IF NOT EXISTS "schema_migrations" (
+		"version" integer PRIMARY KEY,
+		"name" text NOT NULL,
+		"applied_at" datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`
+	} else {
+		if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+			return fmt.Errorf("failed lock migrations: %w", err)
+		}
+		defer func() {
+			_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+			if err != nil {
+				s.log.Error("failed unlock migrations", zap.Error(err))
+			}
+		}()
+	}
+
+	_, err = conn.ExecContext(ctx, createTable)
+	if err != nil {
+		return fmt.Errorf("failed create schema_migrations: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT "version", "applied_at" FROM "schema_migrations"`)
+	if err != nil {
+		return fmt.Errorf("failed get applied migrations: %w", err)
+	}
+	defer rows.Close()
+	applied := map[uint]time.Time{}
+	for rows.Next() {
+		var version uint
+		var at time.Time
+		if err = rows.Scan(&version, &at); err != nil {
+			return fmt.Errorf("failed scan applied migration: %w", err)
+		}
+		applied[version] = at
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed get applied migrations: %w", err)
+	}
+
+	return fn(conn, applied)
+}
+
+// runMigration выполняет sql миграции и изменяет schema_migrations в одной транзакции.
+func (s *Storage) runMigration(ctx context.Context, conn *sql.Conn, query, record string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed begin transaction: %w", err)
+	}
+	if s.dialect == dialectSQLite {
+		err = execSQLite(ctx, tx, query)
+	} else {
+		_, err = tx.ExecContext(ctx, query)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, record, args...); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed update schema_migrations: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed commit transaction: %w", err)
+	}
+	return nil
+}
+
+// execSQLite выполняет миграцию по частям: ADD COLUMN IF NOT EXISTS выполняется как
+// ADD COLUMN, только если колонки еще нет в таблице.
+func execSQLite(ctx context.Context, tx *sql.Tx, query string) error {
+	last := 0
+	for _, m := range sqliteAddColumn.FindAllStringSubmatchIndex(query, -1) {
+		if _, err := tx.ExecContext(ctx, query[last:m[0]]); err != nil {
+			return err
+		}
+		last = m[1]
+
+		table, column := query[m[2]:m[3]], query[m[6]:m[7]]
+		var count int
+		err := tx.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM pragma_table_info($1) WHERE "name" = $2`, table, column).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("failed check column %s.%s: %w", table, column, err)
+		}
+		if count > 0 {
+			continue
+		}
+		if _, err = tx.ExecContext(ctx, `ALTER TABLE "`+table+`" ADD COLUMN `+query[m[4]:m[5]]); err != nil {
+			return err
+		}
+	}
+	_, err := tx.ExecContext(ctx, query[last:])
+	return err
+}
+
+// Migrate применяет все еще не примененные миграции по возрастанию версии.
+func (s *Storage) Migrate(ctx context.Context) (int, error) {
+	migrations, err := loadMigrations(s.dialect)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = s.withMigrationLock(ctx, func(conn *sql.Conn, applied map[uint]time.Time) error {
+		for _, m := range migrations {
+			if _, ok := applied[m.version]; ok {
+				continue
+			}
+			err := s.runMigration(ctx, conn, m.up,
+				`INSERT INTO "schema_migrations" ("version", "name") VALUES ($1, $2)`, m.version, m.name)
+			if err != nil {
+				return fmt.Errorf("failed apply migration %d_%s: %w", m.version, m.name, err)
+			}
+			s.log.Info("migration applied", zap.Uint("version", m.version), zap.String("name", m.name))
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// MigrateDown откатывает steps последних примененных миграций.
+func (s *Storage) MigrateDown(ctx context.Context, steps int) (int, error) {
+	migrations, err := loadMigrations(s.dialect)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = s.withMigrationLock(ctx, func(conn *sql.Conn, applied map[uint]time.Time) error {
+		versions := make([]uint, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, version := range versions {
+			if count >= steps {
+				break
+			}
+			idx := sort.Search(len(migrations), func(i int) bool { return migrations[i].version >= version })
+			if idx == len(migrations) || migrations[idx].version != version {
+				return errors.Join(fmt.Errorf("applied migration %d has no files", version), ErrMigrationNotFound)
+			}
+			m := migrations[idx]
+			err := s.runMigration(ctx, conn, m.down,
+				`DELETE FROM "schema_migrations" WHERE "version" = $1`, m.version)
+			if err != nil {
+				return fmt.Errorf("failed revert migration %d_%s: %w", m.version, m.name, err)
+			}
+			s.log.Info("migration reverted", zap.Uint("version", m.version), zap.String("name", m.name))
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Migrations все известные миграции с отметкой о применении.
+func (s *Storage) Migrations(ctx context.Context) (*[]MigrationState, error) {
+	migrations, err := loadMigrations(s.dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	states := &[]MigrationState{}
+	err = s.withMigrationLock(ctx, func(_ *sql.Conn, applied map[uint]time.Time) error {
+		for _, m := range migrations {
+			state := MigrationState{Version: m.version, Name: m.name}
+			if at, ok := applied[m.version]; ok {
+				state.AppliedAt = &at
+			}
+			*states = append(*states, state)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return states, nil
+}
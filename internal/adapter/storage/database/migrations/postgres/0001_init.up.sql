@@ -0,0 +1,212 @@
+-- Схема на момент перехода с AutoMigrate. IF NOT EXISTS позволяет применить миграцию
+-- к базе, созданной AutoMigrate: существующие таблицы не пересоздаются, а колонки,
+-- добавленные в модели после их создания, добавляются через ADD COLUMN IF NOT EXISTS.
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" bigserial,
+    "login" text,
+    "email" text,
+    "phone" text,
+    "otp_channel" text NOT NULL DEFAULT 'email',
+    "telegram_chat_id" text,
+    "password_hash" text,
+    "language" text NOT NULL DEFAULT 'ru',
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "phone" text;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "otp_channel" text NOT NULL DEFAULT 'email';
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "telegram_chat_id" text;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "language" text NOT NULL DEFAULT 'ru';
+CREATE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+CREATE INDEX IF NOT EXISTS "idx_users_phone" ON "users" ("phone");
+
+CREATE TABLE IF NOT EXISTS "otp_users" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "password" text,
+    "attempt" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_otp_users_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_otp_users_user_id" ON "otp_users" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_otp_users_deleted_at" ON "otp_users" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "tournaments" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "title" text,
+    "description" text,
+    "organization" text,
+    "logo_url" text,
+    "start_date" timestamptz NOT NULL,
+    "end_date" timestamptz NOT NULL,
+    "register_start_date" timestamptz NOT NULL,
+    "register_end_date" timestamptz NOT NULL,
+    "roster_freeze_date" timestamptz DEFAULT null,
+    "max_late_substitutions" bigint DEFAULT null,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_tournaments" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+ALTER TABLE "tournaments" ADD COLUMN IF NOT EXISTS "roster_freeze_date" timestamptz DEFAULT null;
+ALTER TABLE "tournaments" ADD COLUMN IF NOT EXISTS "max_late_substitutions" bigint DEFAULT null;
+CREATE INDEX IF NOT EXISTS "idx_tournaments_user_id" ON "tournaments" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_tournaments_deleted_at" ON "tournaments" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "teams" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "title" text,
+    "logo_url" text,
+    "photo_url" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_teams" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_teams_user_id" ON "teams" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_teams_deleted_at" ON "teams" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "players" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "first_name" text,
+    "second_name" text,
+    "last_name" text,
+    "b_day" timestamptz DEFAULT null,
+    "photo_url" text,
+    "medical_certificate_url" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_players" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+ALTER TABLE "players" ADD COLUMN IF NOT EXISTS "medical_certificate_url" text;
+CREATE INDEX IF NOT EXISTS "idx_players_user_id" ON "players" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_players_deleted_at" ON "players" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "team_players" (
+    "team_id" bigint,
+    "player_id" bigint,
+    PRIMARY KEY ("team_id", "player_id"),
+    CONSTRAINT "fk_team_players_team" FOREIGN KEY ("team_id") REFERENCES "teams"("id"),
+    CONSTRAINT "fk_team_players_player" FOREIGN KEY ("player_id") REFERENCES "players"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "applications" (
+    "id" bigserial,
+    "team_id" bigint NOT NULL,
+    "tournament_id" bigint NOT NULL,
+    "status" text NOT NULL,
+    "status_date" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_tournaments_applications" FOREIGN KEY ("tournament_id") REFERENCES "tournaments"("id"),
+    CONSTRAINT "fk_teams_applications" FOREIGN KEY ("team_id") REFERENCES "teams"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_application" ON "applications" ("team_id", "tournament_id");
+CREATE INDEX IF NOT EXISTS "idx_status" ON "applications" ("status");
+CREATE INDEX IF NOT EXISTS "idx_applications_deleted_at" ON "applications" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "application_players" (
+    "application_id" bigint,
+    "player_id" bigint,
+    PRIMARY KEY ("application_id", "player_id"),
+    CONSTRAINT "fk_application_players_application" FOREIGN KEY ("application_id") REFERENCES "applications"("id"),
+    CONSTRAINT "fk_application_players_player" FOREIGN KEY ("player_id") REFERENCES "players"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "roster_changes" (
+    "id" bigserial,
+    "application_id" bigint NOT NULL,
+    "player_out_id" bigint NOT NULL,
+    "player_in_id" bigint NOT NULL,
+    "reason" text,
+    "status" text NOT NULL,
+    "status_date" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_roster_changes_application_id" ON "roster_changes" ("application_id");
+CREATE INDEX IF NOT EXISTS "idx_roster_changes_status" ON "roster_changes" ("status");
+CREATE INDEX IF NOT EXISTS "idx_roster_changes_deleted_at" ON "roster_changes" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "notification_settings" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "application_status" boolean NOT NULL,
+    "application_submitted" boolean NOT NULL,
+    "application_canceled" boolean NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_notification_settings_user_id" ON "notification_settings" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "outbox_messages" (
+    "id" bigserial,
+    "to" text NOT NULL,
+    "subject" text,
+    "body" text,
+    "html_body" text,
+    "status" text NOT NULL,
+    "next_attempt_at" timestamptz NOT NULL,
+    "attempts" bigint,
+    "last_error" text,
+    "sent_at" timestamptz DEFAULT null,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+ALTER TABLE "outbox_messages" ADD COLUMN IF NOT EXISTS "html_body" text;
+CREATE INDEX IF NOT EXISTS "idx_outbox_due" ON "outbox_messages" ("status", "next_attempt_at");
+
+CREATE TABLE IF NOT EXISTS "uploads" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "kind" text NOT NULL DEFAULT 'image',
+    "url" text NOT NULL,
+    "filename" text,
+    "mime_type" text,
+    "size" bigint NOT NULL,
+    "keys" text,
+    "variants" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+ALTER TABLE "uploads" ADD COLUMN IF NOT EXISTS "kind" text NOT NULL DEFAULT 'image';
+ALTER TABLE "uploads" ADD COLUMN IF NOT EXISTS "filename" text;
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_uploads_url" ON "uploads" ("url");
+CREATE INDEX IF NOT EXISTS "idx_uploads_user_id" ON "uploads" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_uploads_created_at" ON "uploads" ("created_at");
+
+CREATE TABLE IF NOT EXISTS "upload_sessions" (
+    "id" varchar(32),
+    "user_id" bigint NOT NULL,
+    "filename" text,
+    "mime_type" text,
+    "size" bigint NOT NULL,
+    "received" bigint NOT NULL,
+    "chunks" text,
+    "upload_id" bigint DEFAULT null,
+    "expires_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_upload_sessions_upload" FOREIGN KEY ("upload_id") REFERENCES "uploads"("id") ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS "idx_upload_sessions_user_id" ON "upload_sessions" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_upload_sessions_expires_at" ON "upload_sessions" ("expires_at");
@@ -0,0 +1,4 @@
+ALTER TABLE "tournaments" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "teams" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "players" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "applications" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS "idempotency_keys" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "key" text NOT NULL,
+    "request_hash" text NOT NULL,
+    "status_code" bigint NOT NULL DEFAULT 0,
+    "content_type" text,
+    "body" bytea,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_idempotency_key" ON "idempotency_keys" ("user_id", "key");
+CREATE INDEX IF NOT EXISTS "idx_idempotency_keys_created_at" ON "idempotency_keys" ("created_at");
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS "upload_sessions";
+DROP TABLE IF EXISTS "uploads";
+DROP TABLE IF EXISTS "outbox_messages";
+DROP TABLE IF EXISTS "notification_settings";
+DROP TABLE IF EXISTS "roster_changes";
+DROP TABLE IF EXISTS "application_players";
+DROP TABLE IF EXISTS "applications";
+DROP TABLE IF EXISTS "team_players";
+DROP TABLE IF EXISTS "players";
+DROP TABLE IF EXISTS "teams";
+DROP TABLE IF EXISTS "tournaments";
+DROP TABLE IF EXISTS "otp_users";
+DROP TABLE IF EXISTS "users";
@@ -0,0 +1,4 @@
+ALTER TABLE "applications" DROP COLUMN "version";
+ALTER TABLE "players" DROP COLUMN "version";
+ALTER TABLE "teams" DROP COLUMN "version";
+ALTER TABLE "tournaments" DROP COLUMN "version";
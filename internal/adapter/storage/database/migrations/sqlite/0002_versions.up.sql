@@ -0,0 +1,4 @@
+ALTER TABLE "tournaments" ADD COLUMN "version" integer NOT NULL DEFAULT 1;
+ALTER TABLE "teams" ADD COLUMN "version" integer NOT NULL DEFAULT 1;
+ALTER TABLE "players" ADD COLUMN "version" integer NOT NULL DEFAULT 1;
+ALTER TABLE "applications" ADD COLUMN "version" integer NOT NULL DEFAULT 1;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "idempotency_keys" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "user_id" integer NOT NULL,
+    "key" text NOT NULL,
+    "request_hash" text NOT NULL,
+    "status_code" integer NOT NULL DEFAULT 0,
+    "content_type" text,
+    "body" blob,
+    "created_at" datetime,
+    "updated_at" datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_idempotency_key" ON "idempotency_keys" ("user_id", "key");
+CREATE INDEX IF NOT EXISTS "idx_idempotency_keys_created_at" ON "idempotency_keys" ("created_at");
@@ -0,0 +1,2 @@
+ALTER TABLE "outbox_messages" DROP COLUMN "expires_at";
+ALTER TABLE "outbox_messages" DROP COLUMN "template";
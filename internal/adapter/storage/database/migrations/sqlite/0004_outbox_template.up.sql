@@ -0,0 +1,4 @@
+-- шаблон письма и срок, после которого неотправленное письмо переносится в dead:
+-- тексты писем с кодами входа очищаются после отправки или истечения срока
+ALTER TABLE "outbox_messages" ADD COLUMN "template" text;
+ALTER TABLE "outbox_messages" ADD COLUMN "expires_at" datetime DEFAULT null;
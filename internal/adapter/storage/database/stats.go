@@ -0,0 +1,28 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sport-space/internal/adapter/models"
+)
+
+// GetStats показатели для метрик: турниры с открытой на момент at регистрацией и заявки на рассмотрении.
+func (s *Storage) GetStats(ctx context.Context, at time.Time) (*models.Stats, error) {
+	stats := &models.Stats{}
+	err := s.conn(ctx).Model(&models.Tournament{}).
+		Where("register_start_date <= ? AND register_end_date >= ?", at, at).
+		Count(&stats.OpenTournaments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed count open tournaments: %w", err)
+	}
+
+	err = s.conn(ctx).Model(&models.Application{}).
+		Where("status = ?", models.InProgress).
+		Count(&stats.PendingApplications).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed count pending applications: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+var tracer = otel.Tracer("sport-space/internal/adapter/storage/database")
+
+const tracingSpanKey = "tracing:span"
+
+// registerTracing добавляет в gorm callbacks, которые оборачивают каждый запрос в спан,
+// дочерний к спану из контекста запроса. SQL пишется без значений параметров.
+func (s *Storage) registerTracing(db *gorm.DB) error {
+	system := semconv.DBSystemPostgreSQL
+	if s.dialect == dialectSQLite {
+		system = semconv.DBSystemSqlite
+	}
+
+	before := func(operation string) func(db *gorm.DB) {
+		return func(db *gorm.DB) {
+			// запросы вне трассировки (опрос очереди писем, миграции) не пишутся
+			if !trace.SpanContextFromContext(db.Statement.Context).IsValid() {
+				return
+			}
+			_, span := tracer.Start(db.Statement.Context, "db."+operation,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(system, semconv.DBOperationName(operation)),
+			)
+			db.InstanceSet(tracingSpanKey, span)
+		}
+	}
+	after := func(db *gorm.DB) {
+		v, ok := db.InstanceGet(tracingSpanKey)
+		span, isSpan := v.(trace.Span)
+		if !ok || !isSpan {
+			return
+		}
+		defer span.End()
+
+		span.SetAttributes(
+			semconv.DBCollectionName(db.Statement.Table),
+			semconv.DBQueryText(db.Statement.SQL.String()),
+			attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+		)
+		if err := db.Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+			setError(span, err)
+		}
+	}
+
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("*").Register("tracing:before_create", before("create")),
+		cb.Create().After("*").Register("tracing:after_create", after),
+		cb.Query().Before("*").Register("tracing:before_query", before("query")),
+		cb.Query().After("*").Register("tracing:after_query", after),
+		cb.Update().Before("*").Register("tracing:before_update", before("update")),
+		cb.Update().After("*").Register("tracing:after_update", after),
+		cb.Delete().Before("*").Register("tracing:before_delete", before("delete")),
+		cb.Delete().After("*").Register("tracing:after_delete", after),
+		cb.Row().Before("*").Register("tracing:before_row", before("row")),
+		cb.Row().After("*").Register("tracing:after_row", after),
+		cb.Raw().Before("*").Register("tracing:before_raw", before("raw")),
+		cb.Raw().After("*").Register("tracing:after_raw", after),
+	)
+}
+
+// startTx спан транзакции, запросы из нее становятся его дочерними спанами.
+func startTx(ctx context.Context) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "db.transaction", trace.WithSpanKind(trace.SpanKindClient))
+}
+
+// endSpan завершает спан, ошибка err отмечается в нем.
+func endSpan(span trace.Span, err error) {
+	setError(span, err)
+	span.End()
+}
+
+func setError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
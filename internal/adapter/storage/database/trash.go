@@ -0,0 +1,219 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/storage/errstore"
+
+	"gorm.io/gorm"
+)
+
+// softDelete помечает запись удаленной в at, удаленная ранее запись не найдена.
+func softDelete(tx *gorm.DB, model any, id uint, at time.Time) error {
+	res := tx.Model(model).Where("id = ?", id).Update("deleted_at", at)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errstore.ErrNotFoundData
+	}
+	return nil
+}
+
+// DelTournament удаляет турнир в корзину вместе с его заявками, у всех одно время удаления.
+func (s *Storage) DelTournament(ctx context.Context, tournamentID uint, at time.Time) error {
+	err := s.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := softDelete(tx, &models.Tournament{}, tournamentID, at); err != nil {
+			return err
+		}
+		return tx.Model(&models.Application{}).Where("tournament_id = ?", tournamentID).Update("deleted_at", at).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed delete tournament: %w", err)
+	}
+	return nil
+}
+
+// DelTeam удаляет команду в корзину вместе с ее заявками, у всех одно время удаления.
+func (s *Storage) DelTeam(ctx context.Context, teamID uint, at time.Time) error {
+	err := s.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := softDelete(tx, &models.Team{}, teamID, at); err != nil {
+			return err
+		}
+		return tx.Model(&models.Application{}).Where("team_id = ?", teamID).Update("deleted_at", at).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed delete team: %w", err)
+	}
+	return nil
+}
+
+// DelPlayer удаляет игрока в корзину, из составов он пропадает до восстановления.
+func (s *Storage) DelPlayer(ctx context.Context, playerID uint, at time.Time) error {
+	if err := softDelete(s.conn(ctx), &models.Player{}, playerID, at); err != nil {
+		return fmt.Errorf("failed delete player: %w", err)
+	}
+	return nil
+}
+
+// getDeleted удаленные после after записи пользователя, последние удаленные первыми.
+func (s *Storage) getDeleted(ctx context.Context, dest any, userID uint, after time.Time) error {
+	return s.conn(ctx).Unscoped().
+		Where("user_id = ? and deleted_at > ?", userID, after).
+		Order("deleted_at desc, id").
+		Find(dest).Error
+}
+
+func (s *Storage) GetDeletedTournaments(ctx context.Context, userID uint, after time.Time) (*[]models.Tournament, error) {
+	tournaments := &[]models.Tournament{}
+	if err := s.getDeleted(ctx, tournaments, userID, after); err != nil {
+		return nil, fmt.Errorf("failed get deleted tournaments: %w", err)
+	}
+	return tournaments, nil
+}
+
+func (s *Storage) GetDeletedTeams(ctx context.Context, userID uint, after time.Time) (*[]models.Team, error) {
+	teams := &[]models.Team{}
+	if err := s.getDeleted(ctx, teams, userID, after); err != nil {
+		return nil, fmt.Errorf("failed get deleted teams: %w", err)
+	}
+	return teams, nil
+}
+
+func (s *Storage) GetDeletedPlayers(ctx context.Context, userID uint, after time.Time) (*[]models.Player, error) {
+	players := &[]models.Player{}
+	if err := s.getDeleted(ctx, players, userID, after); err != nil {
+		return nil, fmt.Errorf("failed get deleted players: %w", err)
+	}
+	return players, nil
+}
+
+// deleted проверяет, что запись пользователя удалена после after и ее можно восстановить.
+func deleted(tx *gorm.DB, model any, userID, id uint, after time.Time) error {
+	var count int64
+	err := tx.Unscoped().Model(model).
+		Where("id = ? and user_id = ? and deleted_at > ?", id, userID, after).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errstore.ErrNotFoundData
+	}
+	return nil
+}
+
+// RestoreTournament восстанавливает турнир пользователя, удаленный после after, и заявки,
+// удаленные вместе с ним, если команда заявки не в корзине.
+func (s *Storage) RestoreTournament(ctx context.Context, userID, tournamentID uint, after time.Time) error {
+	err := s.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleted(tx, &models.Tournament{}, userID, tournamentID, after); err != nil {
+			return err
+		}
+		err := tx.Unscoped().Model(&models.Application{}).
+			Where("tournament_id = ?", tournamentID).
+			Where("deleted_at = (SELECT deleted_at FROM tournaments WHERE id = ?)", tournamentID).
+			Where("team_id IN (SELECT id FROM teams WHERE deleted_at IS NULL)").
+			Update("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.Tournament{}).Where("id = ?", tournamentID).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed restore tournament: %w", err)
+	}
+	return nil
+}
+
+// RestoreTeam восстанавливает команду пользователя, удаленную после after, и заявки,
+// удаленные вместе с ней, если турнир заявки не в корзине.
+func (s *Storage) RestoreTeam(ctx context.Context, userID, teamID uint, after time.Time) error {
+	err := s.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleted(tx, &models.Team{}, userID, teamID, after); err != nil {
+			return err
+		}
+		err := tx.Unscoped().Model(&models.Application{}).
+			Where("team_id = ?", teamID).
+			Where("deleted_at = (SELECT deleted_at FROM teams WHERE id = ?)", teamID).
+			Where("tournament_id IN (SELECT id FROM tournaments WHERE deleted_at IS NULL)").
+			Update("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.Team{}).Where("id = ?", teamID).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed restore team: %w", err)
+	}
+	return nil
+}
+
+// RestorePlayer восстанавливает игрока пользователя, удаленного после after.
+func (s *Storage) RestorePlayer(ctx context.Context, userID, playerID uint, after time.Time) error {
+	err := s.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleted(tx, &models.Player{}, userID, playerID, after); err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.Player{}).Where("id = ?", playerID).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed restore player: %w", err)
+	}
+	return nil
+}
+
+// PurgeDeleted окончательно удаляет турниры, команды и игроков, удаленные до before,
+// вместе с их заявками, составами и запросами на замену. Возвращает число удаленных записей.
+func (s *Storage) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	purged := 0
+	err := s.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var tournamentIDs, teamIDs, playerIDs, applicationIDs []uint
+		for _, q := range []struct {
+			model any
+			ids   *[]uint
+		}{
+			{&models.Tournament{}, &tournamentIDs},
+			{&models.Team{}, &teamIDs},
+			{&models.Player{}, &playerIDs},
+		} {
+			err := tx.Unscoped().Model(q.model).Where("deleted_at < ?", before).Pluck("id", q.ids).Error
+			if err != nil {
+				return err
+			}
+		}
+		err := tx.Unscoped().Model(&models.Application{}).
+			Where("deleted_at < ? or tournament_id in ? or team_id in ?", before, tournamentIDs, teamIDs).
+			Pluck("id", &applicationIDs).Error
+		if err != nil {
+			return err
+		}
+
+		// зависимые записи удаляются раньше записей, на которые ссылаются
+		for _, q := range []struct {
+			sql  string
+			args []any
+		}{
+			{"DELETE FROM roster_changes WHERE application_id IN ?", []any{applicationIDs}},
+			{"DELETE FROM application_players WHERE application_id IN ? OR player_id IN ?", []any{applicationIDs, playerIDs}},
+			{"DELETE FROM applications WHERE id IN ?", []any{applicationIDs}},
+			{"DELETE FROM team_players WHERE team_id IN ? OR player_id IN ?", []any{teamIDs, playerIDs}},
+			{"DELETE FROM teams WHERE id IN ?", []any{teamIDs}},
+			{"DELETE FROM players WHERE id IN ?", []any{playerIDs}},
+			{"DELETE FROM tournaments WHERE id IN ?", []any{tournamentIDs}},
+		} {
+			if err := tx.Exec(q.sql, q.args...).Error; err != nil {
+				return err
+			}
+		}
+		purged = len(tournamentIDs) + len(teamIDs) + len(playerIDs)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed purge deleted: %w", err)
+	}
+	return purged, nil
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/storage/errstore"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type txKey struct{}
+
+// conn транзакция из ctx, если метод вызван внутри Transaction, иначе общее подключение.
+func (s *Storage) conn(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return s.db.WithContext(ctx)
+}
+
+// Transaction выполняет fn в одной транзакции: методы хранилища, вызванные с ctx из fn,
+// работают в ней. Ошибка fn откатывает изменения и возвращается без оберток.
+// Вложенный вызов использует savepoint внешней транзакции.
+func (s *Storage) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, span := startTx(ctx)
+	err := s.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+	endSpan(span, err)
+	return err
+}
+
+// lock блокирует строку до конца транзакции (SELECT ... FOR UPDATE). В sqlite блокировок строк нет,
+// транзакции и так выполняются по очереди.
+func (s *Storage) lock(ctx context.Context, model any, id uint) error {
+	err := s.conn(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("id = ?", id).
+		Take(model).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.Join(err, errstore.ErrNotFoundData)
+		}
+		return err
+	}
+	return nil
+}
+
+// LockTournament блокирует турнир: заявки турнира меняются по очереди.
+func (s *Storage) LockTournament(ctx context.Context, tournamentID uint) error {
+	if err := s.lock(ctx, &models.Tournament{}, tournamentID); err != nil {
+		return fmt.Errorf("failed lock tournament: %w", err)
+	}
+	return nil
+}
+
+// LockApplication блокирует заявку. Если нужны обе блокировки, турнир блокируется первым.
+func (s *Storage) LockApplication(ctx context.Context, applicationID uint) error {
+	if err := s.lock(ctx, &models.Application{}, applicationID); err != nil {
+		return fmt.Errorf("failed lock application: %w", err)
+	}
+	return nil
+}
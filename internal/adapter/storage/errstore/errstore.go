@@ -8,4 +8,6 @@ var (
 	ErrConflictData    = errors.New("conflict data")
 	ErrForbidden       = errors.New("forbidden")
 	ErrInvalidImageExt = errors.New("invalid image extension")
+	// ErrVersionConflict запись изменили после чтения, версия не совпала с If-Match.
+	ErrVersionConflict = errors.New("version conflict")
 )
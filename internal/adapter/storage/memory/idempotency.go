@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/storage/errstore"
+)
+
+// NewIdempotencyKey сохраняет ключ запроса, занятый ключ пользователя - ErrConflictData.
+func (s *Storage) NewIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	defer s.lock(ctx)()
+
+	for _, k := range s.idempotencyKeys {
+		if k.UserID == key.UserID && k.Key == key.Key {
+			return nil, errors.Join(errors.New("idempotency key already exists"), errstore.ErrConflictData)
+		}
+	}
+	key.ID = s.nextID("idempotency_keys", key.ID)
+	touch(&key.CreatedAt, &key.UpdatedAt)
+	saved := *key
+	saved.Body = slices.Clone(key.Body)
+	s.idempotencyKeys[key.ID] = saved
+	return key, nil
+}
+
+func (s *Storage) GetIdempotencyKey(ctx context.Context, userID uint, key string) (*models.IdempotencyKey, error) {
+	defer s.lock(ctx)()
+
+	for _, id := range sortedKeys(s.idempotencyKeys) {
+		k := s.idempotencyKeys[id]
+		if k.UserID == userID && k.Key == key {
+			k.Body = slices.Clone(k.Body)
+			return &k, nil
+		}
+	}
+	return nil, notFound("idempotency key")
+}
+
+// UpdIdempotencyKey сохраняет ответ на запрос с ключом.
+func (s *Storage) UpdIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error {
+	defer s.lock(ctx)()
+
+	current, ok := s.idempotencyKeys[key.ID]
+	if !ok {
+		return nil
+	}
+	current.StatusCode = key.StatusCode
+	current.ContentType = key.ContentType
+	current.Body = slices.Clone(key.Body)
+	current.UpdatedAt = time.Now()
+	key.UpdatedAt = current.UpdatedAt
+	s.idempotencyKeys[key.ID] = current
+	return nil
+}
+
+func (s *Storage) RemoveIdempotencyKey(ctx context.Context, id uint) error {
+	defer s.lock(ctx)()
+
+	delete(s.idempotencyKeys, id)
+	return nil
+}
+
+// PurgeIdempotencyKeys удаляет ключи, созданные до before.
+func (s *Storage) PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int, error) {
+	defer s.lock(ctx)()
+
+	purged := 0
+	for id, k := range s.idempotencyKeys {
+		if k.CreatedAt.Before(before) {
+			delete(s.idempotencyKeys, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
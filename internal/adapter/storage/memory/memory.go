@@ -0,0 +1,1051 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/storage/errstore"
+)
+
+// Storage хранит данные в памяти процесса, повторяя поведение database.Storage:
+// мягко удаленные записи не возвращаются, заявка команды в турнир уникальна,
+// ошибки - из errstore. Данные теряются при перезапуске, подходит для тестов и демо.
+type Storage struct {
+	// mu защищает таблицы, транзакция держит его до завершения.
+	mu sync.Mutex
+	data
+}
+
+// data таблицы хранилища, копия снимается в начале транзакции для отката.
+type data struct {
+	seq                map[string]uint
+	users              map[uint]models.User
+	otps               map[uint]models.OTPUser
+	tournaments        map[uint]models.Tournament
+	teams              map[uint]models.Team
+	teamPlayers        map[uint][]uint
+	players            map[uint]models.Player
+	applications       map[uint]models.Application
+	applicationPlayers map[uint][]uint
+	rosterChanges      map[uint]models.RosterChange
+	settings           map[uint]models.NotificationSettings
+	outbox             map[uint]models.OutboxMessage
+	uploads            map[uint]models.Upload
+	uploadSessions     map[string]models.UploadSession
+	idempotencyKeys    map[uint]models.IdempotencyKey
+}
+
+func New() *Storage {
+	return &Storage{
+		data: data{
+			seq:                map[string]uint{},
+			users:              map[uint]models.User{},
+			otps:               map[uint]models.OTPUser{},
+			tournaments:        map[uint]models.Tournament{},
+			teams:              map[uint]models.Team{},
+			teamPlayers:        map[uint][]uint{},
+			players:            map[uint]models.Player{},
+			applications:       map[uint]models.Application{},
+			applicationPlayers: map[uint][]uint{},
+			rosterChanges:      map[uint]models.RosterChange{},
+			settings:           map[uint]models.NotificationSettings{},
+			outbox:             map[uint]models.OutboxMessage{},
+			uploads:            map[uint]models.Upload{},
+			uploadSessions:     map[string]models.UploadSession{},
+			idempotencyKeys:    map[uint]models.IdempotencyKey{},
+		},
+	}
+}
+
+// clone копия таблиц. Записи и списки id при изменении заменяются целиком, поэтому
+// достаточно копировать map.
+func (d data) clone() data {
+	return data{
+		seq:                maps.Clone(d.seq),
+		users:              maps.Clone(d.users),
+		otps:               maps.Clone(d.otps),
+		tournaments:        maps.Clone(d.tournaments),
+		teams:              maps.Clone(d.teams),
+		teamPlayers:        maps.Clone(d.teamPlayers),
+		players:            maps.Clone(d.players),
+		applications:       maps.Clone(d.applications),
+		applicationPlayers: maps.Clone(d.applicationPlayers),
+		rosterChanges:      maps.Clone(d.rosterChanges),
+		settings:           maps.Clone(d.settings),
+		outbox:             maps.Clone(d.outbox),
+		uploads:            maps.Clone(d.uploads),
+		uploadSessions:     maps.Clone(d.uploadSessions),
+		idempotencyKeys:    maps.Clone(d.idempotencyKeys),
+	}
+}
+
+type txKey struct{}
+
+// Transaction выполняет fn под блокировкой всего хранилища: другие запросы ждут ее
+// завершения, поэтому ошибка fn откатывает только изменения самой транзакции.
+func (s *Storage) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.inTx(ctx) {
+		return fn(ctx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.data.clone()
+	if err := fn(context.WithValue(ctx, txKey{}, s)); err != nil {
+		s.data = snapshot
+		return err
+	}
+	return nil
+}
+
+// inTx запрос выполняется внутри транзакции этого хранилища, блокировка уже взята.
+func (s *Storage) inTx(ctx context.Context) bool {
+	return ctx.Value(txKey{}) == s
+}
+
+// lock блокирует хранилище на время запроса вне транзакции, возвращает разблокировку.
+func (s *Storage) lock(ctx context.Context) func() {
+	if s.inTx(ctx) {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+// LockTournament проверяет наличие турнира, очередность обеспечивает Transaction.
+func (s *Storage) LockTournament(ctx context.Context, tournamentID uint) error {
+	defer s.lock(ctx)()
+
+	if t, ok := s.tournaments[tournamentID]; !ok || t.DeletedAt.Valid {
+		return notFound("tournament")
+	}
+	return nil
+}
+
+// LockApplication проверяет наличие заявки, очередность обеспечивает Transaction.
+func (s *Storage) LockApplication(ctx context.Context, applicationID uint) error {
+	defer s.lock(ctx)()
+
+	if a, ok := s.applications[applicationID]; !ok || a.DeletedAt.Valid {
+		return notFound("application")
+	}
+	return nil
+}
+
+// nextID следующий id таблицы, как bigserial в postgres. Явно заданный id сдвигает счетчик.
+func (s *Storage) nextID(table string, id uint) uint {
+	if id > 0 {
+		if id > s.seq[table] {
+			s.seq[table] = id
+		}
+		return id
+	}
+	s.seq[table]++
+	return s.seq[table]
+}
+
+// touch проставляет CreatedAt при создании и UpdatedAt, как это делает gorm.
+func touch(createdAt, updatedAt *time.Time) {
+	now := time.Now()
+	if createdAt.IsZero() {
+		*createdAt = now
+	}
+	*updatedAt = now
+}
+
+// initVersion версия новой записи, как default:1 в gorm.
+func initVersion(version *uint) {
+	if *version == 0 {
+		*version = 1
+	}
+}
+
+func notFound(entity string) error {
+	return errors.Join(fmt.Errorf("not found %s", entity), errstore.ErrNotFoundData)
+}
+
+// sortedKeys ключи map по возрастанию, чтобы выборки шли в порядке id.
+func sortedKeys[V any](m map[uint]V) []uint {
+	keys := slices.Collect(maps.Keys(m))
+	slices.Sort(keys)
+	return keys
+}
+
+// linkedPlayers не удаленные игроки из связи many2many.
+func (s *Storage) linkedPlayers(ids []uint) []models.Player {
+	players := []models.Player{}
+	for _, id := range ids {
+		if p, ok := s.players[id]; ok && !p.DeletedAt.Valid {
+			players = append(players, p)
+		}
+	}
+	return players
+}
+
+func playerIDs(players []models.Player) []uint {
+	ids := make([]uint, 0, len(players))
+	for _, p := range players {
+		if !slices.Contains(ids, p.ID) {
+			ids = append(ids, p.ID)
+		}
+	}
+	return ids
+}
+
+func copyUpload(u models.Upload) models.Upload {
+	u.Keys = maps.Clone(u.Keys)
+	u.Variants = maps.Clone(u.Variants)
+	return u
+}
+
+func (s *Storage) NewUser(ctx context.Context, login, email, passwordHash string) (*models.User, error) {
+	defer s.lock(ctx)()
+
+	user := models.User{
+		ID:           s.nextID("users", 0),
+		Login:        login,
+		Email:        email,
+		PasswordHash: passwordHash,
+		OTPChannel:   models.ChannelEmail,
+		Language:     "ru",
+	}
+	touch(&user.CreatedAt, &user.UpdatedAt)
+	s.users[user.ID] = user
+	return &user, nil
+}
+
+func (s *Storage) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	defer s.lock(ctx)()
+
+	for _, id := range sortedKeys(s.users) {
+		if user := s.users[id]; user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, notFound("user")
+}
+
+func (s *Storage) GetUserByPhone(ctx context.Context, phone string) (*models.User, error) {
+	defer s.lock(ctx)()
+
+	for _, id := range sortedKeys(s.users) {
+		if user := s.users[id]; user.Phone == phone {
+			return &user, nil
+		}
+	}
+	return nil, notFound("user")
+}
+
+func (s *Storage) GetUserByID(ctx context.Context, userID uint) (*models.User, error) {
+	defer s.lock(ctx)()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return nil, notFound("user")
+	}
+	return &user, nil
+}
+
+func (s *Storage) UpdUser(ctx context.Context, user *models.User) (*models.User, error) {
+	defer s.lock(ctx)()
+
+	user.ID = s.nextID("users", user.ID)
+	if user.OTPChannel == "" {
+		user.OTPChannel = models.ChannelEmail
+	}
+	if user.Language == "" {
+		user.Language = "ru"
+	}
+	touch(&user.CreatedAt, &user.UpdatedAt)
+	saved := *user
+	saved.Tournaments, saved.Teams, saved.Players = nil, nil, nil
+	s.users[user.ID] = saved
+	return user, nil
+}
+
+func (s *Storage) NewOTP(ctx context.Context, otp *models.OTPUser) error {
+	defer s.lock(ctx)()
+
+	otp.ID = s.nextID("otp_users", otp.ID)
+	touch(&otp.CreatedAt, &otp.UpdatedAt)
+	saved := *otp
+	saved.User = models.User{}
+	s.otps[otp.ID] = saved
+	return nil
+}
+
+func (s *Storage) GetOTP(ctx context.Context, user *models.User) (*models.OTPUser, error) {
+	defer s.lock(ctx)()
+
+	for _, id := range sortedKeys(s.otps) {
+		if otp := s.otps[id]; otp.UserID == user.ID && !otp.DeletedAt.Valid {
+			return &otp, nil
+		}
+	}
+	return &models.OTPUser{UserID: user.ID}, notFound("otp")
+}
+
+func (s *Storage) RemoveOTP(ctx context.Context, user *models.User) error {
+	defer s.lock(ctx)()
+
+	now := time.Now()
+	for id, otp := range s.otps {
+		if otp.UserID == user.ID && !otp.DeletedAt.Valid {
+			otp.DeletedAt.Time, otp.DeletedAt.Valid = now, true
+			s.otps[id] = otp
+		}
+	}
+	return nil
+}
+
+func (s *Storage) findTournaments(match func(t models.Tournament) bool) *[]models.Tournament {
+	tournaments := &[]models.Tournament{}
+	for _, id := range sortedKeys(s.tournaments) {
+		if t := s.tournaments[id]; !t.DeletedAt.Valid && match(t) {
+			*tournaments = append(*tournaments, t)
+		}
+	}
+	return tournaments
+}
+
+func (s *Storage) GetAllTournaments(ctx context.Context) (*[]models.Tournament, error) {
+	defer s.lock(ctx)()
+
+	return s.findTournaments(func(models.Tournament) bool { return true }), nil
+}
+
+func (s *Storage) NewTournament(ctx context.Context, tournament *models.Tournament) (*models.Tournament, error) {
+	defer s.lock(ctx)()
+
+	if _, ok := s.tournaments[tournament.ID]; ok {
+		return nil, errors.Join(errors.New("tournament already exists"), errstore.ErrConflictData)
+	}
+	tournament.ID = s.nextID("tournaments", tournament.ID)
+	initVersion(&tournament.Version)
+	touch(&tournament.CreatedAt, &tournament.UpdatedAt)
+	saved := *tournament
+	saved.Applications = nil
+	s.tournaments[tournament.ID] = saved
+	return tournament, nil
+}
+
+func (s *Storage) GetTournaments(ctx context.Context, userID uint) (*[]models.Tournament, error) {
+	defer s.lock(ctx)()
+
+	return s.findTournaments(func(t models.Tournament) bool { return t.UserID == userID }), nil
+}
+
+func (s *Storage) GetTournamentByID(ctx context.Context, tournamentID uint) (*models.Tournament, error) {
+	defer s.lock(ctx)()
+
+	tournament, ok := s.tournaments[tournamentID]
+	if !ok || tournament.DeletedAt.Valid {
+		return nil, notFound("tournament")
+	}
+	tournament.Applications = *s.findApplications(func(a models.Application) bool {
+		return a.TournamentID == tournamentID
+	}, false)
+	return &tournament, nil
+}
+
+func (s *Storage) UpdTournamentByUser(ctx context.Context, tournament *models.Tournament) (*models.Tournament, error) {
+	defer s.lock(ctx)()
+
+	current, ok := s.tournaments[tournament.ID]
+	if !ok || current.DeletedAt.Valid || current.UserID != tournament.UserID {
+		return nil, errstore.ErrNotFoundData
+	}
+	if current.Version != tournament.Version {
+		return nil, errstore.ErrVersionConflict
+	}
+	tournament.Version++
+	touch(&tournament.CreatedAt, &tournament.UpdatedAt)
+	saved := *tournament
+	saved.Applications = nil
+	s.tournaments[tournament.ID] = saved
+	return tournament, nil
+}
+
+func (s *Storage) NewTeam(ctx context.Context, team *models.Team) (*models.Team, error) {
+	defer s.lock(ctx)()
+
+	if _, ok := s.teams[team.ID]; ok {
+		return nil, errors.Join(errors.New("team already exists"), errstore.ErrConflictData)
+	}
+	team.ID = s.nextID("teams", team.ID)
+	initVersion(&team.Version)
+	touch(&team.CreatedAt, &team.UpdatedAt)
+	s.teamPlayers[team.ID] = playerIDs(team.Players)
+	saved := *team
+	saved.Players, saved.Applications = nil, nil
+	s.teams[team.ID] = saved
+	return team, nil
+}
+
+func (s *Storage) GetTeams(ctx context.Context, user *models.User) (*[]models.Team, error) {
+	defer s.lock(ctx)()
+
+	teams := &[]models.Team{}
+	for _, id := range sortedKeys(s.teams) {
+		if team := s.teams[id]; team.UserID == user.ID && !team.DeletedAt.Valid {
+			*teams = append(*teams, team)
+		}
+	}
+	return teams, nil
+}
+
+func (s *Storage) GetTeamByID(ctx context.Context, teamID uint) (*models.Team, error) {
+	defer s.lock(ctx)()
+
+	team, ok := s.teams[teamID]
+	if !ok || team.DeletedAt.Valid {
+		return nil, errstore.ErrNotFoundData
+	}
+	team.Players = s.linkedPlayers(s.teamPlayers[teamID])
+	return &team, nil
+}
+
+func (s *Storage) UpdTeam(ctx context.Context, team *models.Team, playersIDs *[]uint) (
+	*models.Team, *[]models.Player, error,
+) {
+	defer s.lock(ctx)()
+
+	current, ok := s.teams[team.ID]
+	if !ok || current.DeletedAt.Valid {
+		return nil, nil, errstore.ErrNotFoundData
+	}
+	if current.Version != team.Version {
+		return nil, nil, errstore.ErrVersionConflict
+	}
+	team.Version++
+	if playersIDs != nil {
+		players := []models.Player{}
+		for _, id := range sortedKeys(s.players) {
+			if p := s.players[id]; slices.Contains(*playersIDs, id) && !p.DeletedAt.Valid {
+				players = append(players, p)
+			}
+		}
+		s.teamPlayers[team.ID] = playerIDs(players)
+		team.Players = players
+	}
+	touch(&team.CreatedAt, &team.UpdatedAt)
+	saved := *team
+	saved.Players, saved.Applications = nil, nil
+	s.teams[team.ID] = saved
+	return team, &team.Players, nil
+}
+
+func (s *Storage) NewPlayer(ctx context.Context, player *models.Player) (*models.Player, error) {
+	defer s.lock(ctx)()
+
+	if _, ok := s.players[player.ID]; ok {
+		return nil, errors.Join(errors.New("player already exists"), errstore.ErrConflictData)
+	}
+	player.ID = s.nextID("players", player.ID)
+	initVersion(&player.Version)
+	touch(&player.CreatedAt, &player.UpdatedAt)
+	s.players[player.ID] = *player
+	return player, nil
+}
+
+// NewPlayerBatch создает игроков, существующие по id обновляет, как upsert в database.Storage.
+func (s *Storage) NewPlayerBatch(ctx context.Context, players *[]models.Player) (*[]models.Player, error) {
+	defer s.lock(ctx)()
+
+	for i := range *players {
+		p := &(*players)[i]
+		current, ok := s.players[p.ID]
+		if !ok {
+			p.ID = s.nextID("players", p.ID)
+			initVersion(&p.Version)
+			touch(&p.CreatedAt, &p.UpdatedAt)
+			s.players[p.ID] = *p
+			continue
+		}
+		current.FirstName = p.FirstName
+		current.SecondName = p.SecondName
+		current.LastName = p.LastName
+		current.BDay = p.BDay
+		current.PhotoURL = p.PhotoURL
+		current.MedicalCertificateURL = p.MedicalCertificateURL
+		current.Version++
+		p.Version = current.Version
+		s.players[p.ID] = current
+		touch(&p.CreatedAt, &p.UpdatedAt)
+	}
+	return players, nil
+}
+
+func (s *Storage) GetPlayers(ctx context.Context, userID uint) (*[]models.Player, error) {
+	defer s.lock(ctx)()
+
+	players := &[]models.Player{}
+	for _, id := range sortedKeys(s.players) {
+		if p := s.players[id]; p.UserID == userID && !p.DeletedAt.Valid {
+			*players = append(*players, p)
+		}
+	}
+	return players, nil
+}
+
+func (s *Storage) GetPlayerByID(ctx context.Context, playerID uint) (*models.Player, error) {
+	defer s.lock(ctx)()
+
+	player, ok := s.players[playerID]
+	if !ok || player.DeletedAt.Valid {
+		return nil, notFound("player")
+	}
+	return &player, nil
+}
+
+func (s *Storage) GetPlayersByIDs(ctx context.Context, playerIDs []uint) (*[]models.Player, error) {
+	defer s.lock(ctx)()
+
+	players := &[]models.Player{}
+	for _, id := range sortedKeys(s.players) {
+		if p := s.players[id]; slices.Contains(playerIDs, id) && !p.DeletedAt.Valid {
+			*players = append(*players, p)
+		}
+	}
+	return players, nil
+}
+
+func (s *Storage) GetPlayersFromTeam(ctx context.Context, teamID uint) (*[]models.Player, error) {
+	defer s.lock(ctx)()
+
+	players := s.linkedPlayers(s.teamPlayers[teamID])
+	return &players, nil
+}
+
+func (s *Storage) UpdPlayer(ctx context.Context, player *models.Player) (*models.Player, error) {
+	defer s.lock(ctx)()
+
+	current, ok := s.players[player.ID]
+	if !ok || current.DeletedAt.Valid || current.UserID != player.UserID {
+		return nil, errstore.ErrNotFoundData
+	}
+	if current.Version != player.Version {
+		return nil, errstore.ErrVersionConflict
+	}
+	player.Version++
+	touch(&player.CreatedAt, &player.UpdatedAt)
+	s.players[player.ID] = *player
+	return player, nil
+}
+
+func (s *Storage) findApplications(match func(a models.Application) bool, withPlayers bool) *[]models.Application {
+	applications := &[]models.Application{}
+	for _, id := range sortedKeys(s.applications) {
+		a := s.applications[id]
+		if a.DeletedAt.Valid || !match(a) {
+			continue
+		}
+		if withPlayers {
+			a.Players = s.linkedPlayers(s.applicationPlayers[id])
+		}
+		*applications = append(*applications, a)
+	}
+	return applications
+}
+
+// NewApplication создает заявку в статусе черновика. Уникальный индекс (team_id, tournament_id)
+// в postgres учитывает и удаленные заявки, поэтому они здесь тоже проверяются.
+func (s *Storage) NewApplication(ctx context.Context, application *models.Application, players *[]models.Player) (
+	*models.Application, *[]models.Player, error,
+) {
+	defer s.lock(ctx)()
+
+	for _, a := range s.applications {
+		if a.TeamID == application.TeamID && a.TournamentID == application.TournamentID {
+			return nil, nil, errors.Join(errors.New("application already exists"), errstore.ErrConflictData)
+		}
+	}
+
+	application.Status = models.Draft
+	application.StatusDate = time.Now()
+	application.ID = s.nextID("applications", application.ID)
+	initVersion(&application.Version)
+	touch(&application.CreatedAt, &application.UpdatedAt)
+	if players != nil {
+		s.applicationPlayers[application.ID] = playerIDs(*players)
+	}
+	saved := *application
+	saved.Players = nil
+	s.applications[application.ID] = saved
+	return application, players, nil
+}
+
+func (s *Storage) GetApplicationFromTeamTournament(ctx context.Context, tournamentID, teamID uint) (
+	*models.Application, error,
+) {
+	defer s.lock(ctx)()
+
+	applications := s.findApplications(func(a models.Application) bool {
+		return a.TournamentID == tournamentID && a.TeamID == teamID
+	}, false)
+	if len(*applications) == 0 {
+		return nil, errstore.ErrNotFoundData
+	}
+	return &(*applications)[0], nil
+}
+
+func (s *Storage) GetApplicationByID(ctx context.Context, applicationID uint) (*models.Application, error) {
+	defer s.lock(ctx)()
+
+	application, ok := s.applications[applicationID]
+	if !ok || application.DeletedAt.Valid {
+		return nil, notFound("application")
+	}
+	application.Players = s.linkedPlayers(s.applicationPlayers[applicationID])
+	return &application, nil
+}
+
+// updApplication обновляет только заданные поля, как Updates в gorm, если версия совпадает.
+func (s *Storage) updApplication(application *models.Application) error {
+	current, ok := s.applications[application.ID]
+	if !ok || current.DeletedAt.Valid {
+		return errstore.ErrNotFoundData
+	}
+	if current.Version != application.Version {
+		return errstore.ErrVersionConflict
+	}
+	current.Version++
+	application.Version = current.Version
+	if application.TeamID != 0 {
+		current.TeamID = application.TeamID
+	}
+	if application.TournamentID != 0 {
+		current.TournamentID = application.TournamentID
+	}
+	if application.Status != "" {
+		current.Status = application.Status
+	}
+	if !application.StatusDate.IsZero() {
+		current.StatusDate = application.StatusDate
+	}
+	current.UpdatedAt = time.Now()
+	application.UpdatedAt = current.UpdatedAt
+	s.applications[application.ID] = current
+	return nil
+}
+
+func (s *Storage) UpdApplication(ctx context.Context, application *models.Application, players *[]models.Player) (
+	*models.Application, *[]models.Player, error,
+) {
+	defer s.lock(ctx)()
+
+	for _, a := range s.applications {
+		if a.ID != application.ID && application.TeamID != 0 && application.TournamentID != 0 &&
+			a.TeamID == application.TeamID && a.TournamentID == application.TournamentID {
+			return nil, nil, errors.Join(errors.New("application already exists"), errstore.ErrConflictData)
+		}
+	}
+
+	if err := s.updApplication(application); err != nil {
+		return nil, nil, err
+	}
+	if players != nil && *players != nil {
+		s.applicationPlayers[application.ID] = playerIDs(*players)
+	}
+	return application, players, nil
+}
+
+func (s *Storage) GetApplicationsByTeamID(ctx context.Context, teamID uint) (*[]models.Application, error) {
+	defer s.lock(ctx)()
+
+	return s.findApplications(func(a models.Application) bool { return a.TeamID == teamID }, false), nil
+}
+
+// GetApplicationsByPlayerID заявки, в состав которых входит игрок.
+func (s *Storage) GetApplicationsByPlayerID(ctx context.Context, playerID uint) (*[]models.Application, error) {
+	defer s.lock(ctx)()
+
+	return s.findApplications(func(a models.Application) bool {
+		return slices.Contains(s.applicationPlayers[a.ID], playerID)
+	}, false), nil
+}
+
+func (s *Storage) GetPlayersFromApplication(ctx context.Context, applicationID uint) (*[]models.Player, error) {
+	defer s.lock(ctx)()
+
+	application, ok := s.applications[applicationID]
+	if !ok || application.DeletedAt.Valid {
+		return &[]models.Player{}, notFound("players from application")
+	}
+	players := s.linkedPlayers(s.applicationPlayers[applicationID])
+	return &players, nil
+}
+
+func (s *Storage) GetApplicationsFromTournament(ctx context.Context, tournamentID uint) (
+	*[]models.Application, error,
+) {
+	defer s.lock(ctx)()
+
+	statuses := []models.ApplicationStatus{models.InProgress, models.Accepted, models.Rejected}
+	return s.findApplications(func(a models.Application) bool {
+		return a.TournamentID == tournamentID && slices.Contains(statuses, a.Status)
+	}, false), nil
+}
+
+func (s *Storage) UpdApplicationTournament(ctx context.Context, application *models.Application) (
+	*models.Application, error,
+) {
+	defer s.lock(ctx)()
+
+	if err := s.updApplication(application); err != nil {
+		return nil, err
+	}
+	return application, nil
+}
+
+func (s *Storage) GetActiveApplicationsFromTournament(ctx context.Context, tournamentID uint) (
+	*[]models.Application, error,
+) {
+	defer s.lock(ctx)()
+
+	return s.findApplications(func(a models.Application) bool {
+		return a.TournamentID == tournamentID && a.Status != models.Canceled
+	}, true), nil
+}
+
+func (s *Storage) NewRosterChange(ctx context.Context, change *models.RosterChange) (*models.RosterChange, error) {
+	defer s.lock(ctx)()
+
+	change.ID = s.nextID("roster_changes", change.ID)
+	touch(&change.CreatedAt, &change.UpdatedAt)
+	s.rosterChanges[change.ID] = *change
+	return change, nil
+}
+
+func (s *Storage) GetRosterChangeByID(ctx context.Context, changeID uint) (*models.RosterChange, error) {
+	defer s.lock(ctx)()
+
+	change, ok := s.rosterChanges[changeID]
+	if !ok || change.DeletedAt.Valid {
+		return nil, notFound("roster change")
+	}
+	return &change, nil
+}
+
+func (s *Storage) GetRosterChangesByApplicationID(ctx context.Context, applicationID uint) (
+	*[]models.RosterChange, error,
+) {
+	defer s.lock(ctx)()
+
+	changes := &[]models.RosterChange{}
+	for _, id := range sortedKeys(s.rosterChanges) {
+		if c := s.rosterChanges[id]; c.ApplicationID == applicationID && !c.DeletedAt.Valid {
+			*changes = append(*changes, c)
+		}
+	}
+	return changes, nil
+}
+
+func (s *Storage) UpdRosterChange(ctx context.Context, change *models.RosterChange, players *[]models.Player) (
+	*models.RosterChange, error,
+) {
+	defer s.lock(ctx)()
+
+	change.ID = s.nextID("roster_changes", change.ID)
+	touch(&change.CreatedAt, &change.UpdatedAt)
+	s.rosterChanges[change.ID] = *change
+	if players != nil {
+		s.applicationPlayers[change.ApplicationID] = playerIDs(*players)
+		if application, ok := s.applications[change.ApplicationID]; ok {
+			application.Version++
+			s.applications[change.ApplicationID] = application
+		}
+	}
+	return change, nil
+}
+
+func (s *Storage) GetNotificationSettings(ctx context.Context, userID uint) (*models.NotificationSettings, error) {
+	defer s.lock(ctx)()
+
+	for _, id := range sortedKeys(s.settings) {
+		if settings := s.settings[id]; settings.UserID == userID {
+			return &settings, nil
+		}
+	}
+	return nil, notFound("notification settings")
+}
+
+// SaveNotificationSettings создает настройки или обновляет существующие настройки пользователя.
+func (s *Storage) SaveNotificationSettings(ctx context.Context, settings *models.NotificationSettings) (
+	*models.NotificationSettings, error,
+) {
+	defer s.lock(ctx)()
+
+	for id, current := range s.settings {
+		if current.UserID != settings.UserID {
+			continue
+		}
+		current.ApplicationStatus = settings.ApplicationStatus
+		current.ApplicationSubmitted = settings.ApplicationSubmitted
+		current.ApplicationCanceled = settings.ApplicationCanceled
+		touch(&current.CreatedAt, &current.UpdatedAt)
+		s.settings[id] = current
+		*settings = current
+		return settings, nil
+	}
+
+	settings.ID = s.nextID("notification_settings", settings.ID)
+	touch(&settings.CreatedAt, &settings.UpdatedAt)
+	s.settings[settings.ID] = *settings
+	return settings, nil
+}
+
+func (s *Storage) NewOutboxMessage(ctx context.Context, msg *models.OutboxMessage) error {
+	defer s.lock(ctx)()
+
+	msg.ID = s.nextID("outbox_messages", msg.ID)
+	touch(&msg.CreatedAt, &msg.UpdatedAt)
+	s.outbox[msg.ID] = *msg
+	return nil
+}
+
+// ClaimOutboxMessages выбирает письма готовые к отправке и откладывает их на время lease.
+func (s *Storage) ClaimOutboxMessages(ctx context.Context, limit int, lease time.Duration) (
+	*[]models.OutboxMessage, error,
+) {
+	defer s.lock(ctx)()
+
+	now := time.Now()
+	messages := &[]models.OutboxMessage{}
+	for _, msg := range s.outbox {
+		if msg.Status == models.OutboxPending && !msg.NextAttemptAt.After(now) {
+			*messages = append(*messages, msg)
+		}
+	}
+	sort.SliceStable(*messages, func(i, j int) bool {
+		a, b := (*messages)[i], (*messages)[j]
+		if a.NextAttemptAt.Equal(b.NextAttemptAt) {
+			return a.ID < b.ID
+		}
+		return a.NextAttemptAt.Before(b.NextAttemptAt)
+	})
+	if len(*messages) > limit {
+		*messages = (*messages)[:limit]
+	}
+
+	for _, msg := range *messages {
+		msg.NextAttemptAt = now.Add(lease)
+		s.outbox[msg.ID] = msg
+	}
+	return messages, nil
+}
+
+func (s *Storage) UpdOutboxMessage(ctx context.Context, msg *models.OutboxMessage) error {
+	defer s.lock(ctx)()
+
+	msg.ID = s.nextID("outbox_messages", msg.ID)
+	touch(&msg.CreatedAt, &msg.UpdatedAt)
+	s.outbox[msg.ID] = *msg
+	return nil
+}
+
+func (s *Storage) GetOutboxMessages(ctx context.Context, status models.OutboxStatus) (*[]models.OutboxMessage, error) {
+	defer s.lock(ctx)()
+
+	messages := &[]models.OutboxMessage{}
+	keys := sortedKeys(s.outbox)
+	slices.Reverse(keys)
+	for _, id := range keys {
+		if msg := s.outbox[id]; msg.Status == status {
+			*messages = append(*messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+func (s *Storage) GetOutboxMessageByID(ctx context.Context, id uint) (*models.OutboxMessage, error) {
+	defer s.lock(ctx)()
+
+	msg, ok := s.outbox[id]
+	if !ok {
+		return nil, notFound("outbox message")
+	}
+	return &msg, nil
+}
+
+func (s *Storage) NewUpload(ctx context.Context, upload *models.Upload) (*models.Upload, error) {
+	defer s.lock(ctx)()
+
+	for _, u := range s.uploads {
+		if u.URL == upload.URL {
+			return nil, errors.Join(fmt.Errorf("upload `%s` already exists", upload.URL), errstore.ErrConflictData)
+		}
+	}
+	upload.ID = s.nextID("uploads", upload.ID)
+	if upload.Kind == "" {
+		upload.Kind = models.UploadImage
+	}
+	if upload.CreatedAt.IsZero() {
+		upload.CreatedAt = time.Now()
+	}
+	s.uploads[upload.ID] = copyUpload(*upload)
+	return upload, nil
+}
+
+func (s *Storage) GetUploadByURL(ctx context.Context, url string) (*models.Upload, error) {
+	defer s.lock(ctx)()
+
+	for _, u := range s.uploads {
+		if u.URL == url {
+			upload := copyUpload(u)
+			return &upload, nil
+		}
+	}
+	return nil, notFound("upload")
+}
+
+// GetUploadsSize суммарный размер загрузок пользователя в байтах.
+func (s *Storage) GetUploadsSize(ctx context.Context, userID uint) (int64, error) {
+	defer s.lock(ctx)()
+
+	var size int64
+	for _, u := range s.uploads {
+		if u.UserID == userID {
+			size += u.Size
+		}
+	}
+	return size, nil
+}
+
+// uploadUsed ссылается ли на url какая-либо запись, в том числе удаленная.
+func (s *Storage) uploadUsed(url string) bool {
+	for _, t := range s.teams {
+		if t.LogoURL == url || t.PhotoURL == url {
+			return true
+		}
+	}
+	for _, p := range s.players {
+		if p.PhotoURL == url || p.MedicalCertificateURL == url {
+			return true
+		}
+	}
+	for _, t := range s.tournaments {
+		if t.LogoURL == url {
+			return true
+		}
+	}
+	return false
+}
+
+// GetOrphanUploads загрузки старше before, на которые не ссылается ни одна запись.
+func (s *Storage) GetOrphanUploads(ctx context.Context, before time.Time, limit int) (*[]models.Upload, error) {
+	defer s.lock(ctx)()
+
+	uploads := &[]models.Upload{}
+	for _, id := range sortedKeys(s.uploads) {
+		if len(*uploads) >= limit {
+			break
+		}
+		if u := s.uploads[id]; u.CreatedAt.Before(before) && !s.uploadUsed(u.URL) {
+			*uploads = append(*uploads, copyUpload(u))
+		}
+	}
+	return uploads, nil
+}
+
+func (s *Storage) RemoveUpload(ctx context.Context, uploadID uint) error {
+	defer s.lock(ctx)()
+
+	delete(s.uploads, uploadID)
+	// ON DELETE SET NULL у upload_sessions.upload_id
+	for id, session := range s.uploadSessions {
+		if session.UploadID != nil && *session.UploadID == uploadID {
+			session.UploadID = nil
+			s.uploadSessions[id] = session
+		}
+	}
+	return nil
+}
+
+func (s *Storage) NewUploadSession(ctx context.Context, session *models.UploadSession) (
+	*models.UploadSession, error,
+) {
+	defer s.lock(ctx)()
+
+	if _, ok := s.uploadSessions[session.ID]; ok {
+		return nil, errors.Join(errors.New("upload session already exists"), errstore.ErrConflictData)
+	}
+	touch(&session.CreatedAt, &session.UpdatedAt)
+	saved := *session
+	saved.Chunks = slices.Clone(session.Chunks)
+	saved.Upload = nil
+	s.uploadSessions[session.ID] = saved
+	return session, nil
+}
+
+func (s *Storage) GetUploadSession(ctx context.Context, sessionID string) (*models.UploadSession, error) {
+	defer s.lock(ctx)()
+
+	session, ok := s.uploadSessions[sessionID]
+	if !ok {
+		return nil, notFound("upload session")
+	}
+	session.Chunks = slices.Clone(session.Chunks)
+	if session.UploadID != nil {
+		if u, ok := s.uploads[*session.UploadID]; ok {
+			upload := copyUpload(u)
+			session.Upload = &upload
+		}
+	}
+	return &session, nil
+}
+
+// UpdUploadSession сохраняет сессию, если с момента чтения в нее не дописали часть.
+func (s *Storage) UpdUploadSession(ctx context.Context, session *models.UploadSession, received int64) error {
+	defer s.lock(ctx)()
+
+	current, ok := s.uploadSessions[session.ID]
+	if !ok || current.Received != received {
+		return errstore.ErrConflictData
+	}
+	current.MIMEType = session.MIMEType
+	current.Received = session.Received
+	current.Chunks = slices.Clone(session.Chunks)
+	current.UploadID = session.UploadID
+	current.UpdatedAt = time.Now()
+	session.UpdatedAt = current.UpdatedAt
+	s.uploadSessions[session.ID] = current
+	return nil
+}
+
+func (s *Storage) RemoveUploadSession(ctx context.Context, sessionID string) error {
+	defer s.lock(ctx)()
+
+	delete(s.uploadSessions, sessionID)
+	return nil
+}
+
+// GetExpiredUploadSessions сессии загрузки с истекшим сроком.
+func (s *Storage) GetExpiredUploadSessions(ctx context.Context, before time.Time, limit int) (
+	*[]models.UploadSession, error,
+) {
+	defer s.lock(ctx)()
+
+	sessions := &[]models.UploadSession{}
+	for _, session := range s.uploadSessions {
+		if session.ExpiresAt.Before(before) {
+			session.Chunks = slices.Clone(session.Chunks)
+			*sessions = append(*sessions, session)
+		}
+	}
+	sort.Slice(*sessions, func(i, j int) bool {
+		return (*sessions)[i].ExpiresAt.Before((*sessions)[j].ExpiresAt)
+	})
+	if len(*sessions) > limit {
+		*sessions = (*sessions)[:limit]
+	}
+	return sessions, nil
+}
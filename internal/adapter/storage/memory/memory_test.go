@@ -0,0 +1,66 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/storage"
+	"sport-space/internal/adapter/storage/memory"
+	"sport-space/internal/adapter/storage/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storage.Store { return memory.New() })
+}
+
+// TestRollbackKeepsConcurrentWrites запись вне транзакции ждет ее завершения и не
+// теряется при откате.
+func TestRollbackKeepsConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+
+	errRollback := errors.New("rollback")
+	written := make(chan *models.Team, 1)
+	err := s.Transaction(ctx, func(txCtx context.Context) error {
+		if _, err := s.NewTeam(txCtx, &models.Team{UserID: 1, Title: "tx"}); err != nil {
+			return err
+		}
+		go func() {
+			team, err := s.NewTeam(ctx, &models.Team{UserID: 2, Title: "outside"})
+			if err != nil {
+				t.Error(err)
+			}
+			written <- team
+		}()
+
+		select {
+		case <-written:
+			t.Error("write outside transaction is not blocked")
+		case <-time.After(50 * time.Millisecond):
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("expected rollback error, got %v", err)
+	}
+
+	team := <-written
+	if team == nil {
+		t.Fatal("write outside transaction failed")
+	}
+	got, err := s.GetTeamByID(ctx, team.ID)
+	if err != nil {
+		t.Fatalf("write outside transaction is lost: %v", err)
+	}
+	if got.Title != "outside" {
+		t.Fatalf("expected team `outside`, got `%s`", got.Title)
+	}
+
+	teams, err := s.GetTeams(ctx, &models.User{ID: 1})
+	if err == nil && len(*teams) != 0 {
+		t.Fatalf("transaction write is not rolled back: %d teams", len(*teams))
+	}
+}
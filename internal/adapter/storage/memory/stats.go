@@ -0,0 +1,29 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"sport-space/internal/adapter/models"
+)
+
+// GetStats показатели для метрик: турниры с открытой на момент at регистрацией и заявки на рассмотрении.
+func (s *Storage) GetStats(ctx context.Context, at time.Time) (*models.Stats, error) {
+	defer s.lock(ctx)()
+
+	stats := &models.Stats{}
+	for _, t := range s.tournaments {
+		if t.DeletedAt.Valid || t.RegisterStartDate == nil || t.RegisterEndDate == nil {
+			continue
+		}
+		if !t.RegisterStartDate.After(at) && !t.RegisterEndDate.Before(at) {
+			stats.OpenTournaments++
+		}
+	}
+	for _, a := range s.applications {
+		if !a.DeletedAt.Valid && a.Status == models.InProgress {
+			stats.PendingApplications++
+		}
+	}
+	return stats, nil
+}
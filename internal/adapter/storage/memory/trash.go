@@ -0,0 +1,227 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/storage/errstore"
+
+	"gorm.io/gorm"
+)
+
+func deletedAt(at time.Time) gorm.DeletedAt {
+	return gorm.DeletedAt{Time: at, Valid: true}
+}
+
+// restorable запись удалена после after и ее можно восстановить.
+func restorable(userID, ownerID uint, d gorm.DeletedAt, after time.Time) bool {
+	return userID == ownerID && d.Valid && d.Time.After(after)
+}
+
+// DelTournament удаляет турнир в корзину вместе с его заявками, у всех одно время удаления.
+func (s *Storage) DelTournament(ctx context.Context, tournamentID uint, at time.Time) error {
+	defer s.lock(ctx)()
+
+	t, ok := s.tournaments[tournamentID]
+	if !ok || t.DeletedAt.Valid {
+		return errstore.ErrNotFoundData
+	}
+	t.DeletedAt = deletedAt(at)
+	s.tournaments[tournamentID] = t
+	s.deleteApplications(func(a models.Application) bool { return a.TournamentID == tournamentID }, at)
+	return nil
+}
+
+// DelTeam удаляет команду в корзину вместе с ее заявками, у всех одно время удаления.
+func (s *Storage) DelTeam(ctx context.Context, teamID uint, at time.Time) error {
+	defer s.lock(ctx)()
+
+	team, ok := s.teams[teamID]
+	if !ok || team.DeletedAt.Valid {
+		return errstore.ErrNotFoundData
+	}
+	team.DeletedAt = deletedAt(at)
+	s.teams[teamID] = team
+	s.deleteApplications(func(a models.Application) bool { return a.TeamID == teamID }, at)
+	return nil
+}
+
+func (s *Storage) deleteApplications(match func(a models.Application) bool, at time.Time) {
+	for id, a := range s.applications {
+		if !a.DeletedAt.Valid && match(a) {
+			a.DeletedAt = deletedAt(at)
+			s.applications[id] = a
+		}
+	}
+}
+
+// DelPlayer удаляет игрока в корзину, из составов он пропадает до восстановления.
+func (s *Storage) DelPlayer(ctx context.Context, playerID uint, at time.Time) error {
+	defer s.lock(ctx)()
+
+	player, ok := s.players[playerID]
+	if !ok || player.DeletedAt.Valid {
+		return errstore.ErrNotFoundData
+	}
+	player.DeletedAt = deletedAt(at)
+	s.players[playerID] = player
+	return nil
+}
+
+// deletedFirst порядок корзины: последние удаленные первыми.
+func deletedFirst(a, b gorm.DeletedAt) int {
+	return b.Time.Compare(a.Time)
+}
+
+func (s *Storage) GetDeletedTournaments(ctx context.Context, userID uint, after time.Time) (*[]models.Tournament, error) {
+	defer s.lock(ctx)()
+
+	tournaments := []models.Tournament{}
+	for _, id := range sortedKeys(s.tournaments) {
+		if t := s.tournaments[id]; restorable(userID, t.UserID, t.DeletedAt, after) {
+			tournaments = append(tournaments, t)
+		}
+	}
+	slices.SortStableFunc(tournaments, func(a, b models.Tournament) int { return deletedFirst(a.DeletedAt, b.DeletedAt) })
+	return &tournaments, nil
+}
+
+func (s *Storage) GetDeletedTeams(ctx context.Context, userID uint, after time.Time) (*[]models.Team, error) {
+	defer s.lock(ctx)()
+
+	teams := []models.Team{}
+	for _, id := range sortedKeys(s.teams) {
+		if t := s.teams[id]; restorable(userID, t.UserID, t.DeletedAt, after) {
+			teams = append(teams, t)
+		}
+	}
+	slices.SortStableFunc(teams, func(a, b models.Team) int { return deletedFirst(a.DeletedAt, b.DeletedAt) })
+	return &teams, nil
+}
+
+func (s *Storage) GetDeletedPlayers(ctx context.Context, userID uint, after time.Time) (*[]models.Player, error) {
+	defer s.lock(ctx)()
+
+	players := []models.Player{}
+	for _, id := range sortedKeys(s.players) {
+		if p := s.players[id]; restorable(userID, p.UserID, p.DeletedAt, after) {
+			players = append(players, p)
+		}
+	}
+	slices.SortStableFunc(players, func(a, b models.Player) int { return deletedFirst(a.DeletedAt, b.DeletedAt) })
+	return &players, nil
+}
+
+// RestoreTournament восстанавливает турнир пользователя, удаленный после after, и заявки,
+// удаленные вместе с ним, если команда заявки не в корзине.
+func (s *Storage) RestoreTournament(ctx context.Context, userID, tournamentID uint, after time.Time) error {
+	defer s.lock(ctx)()
+
+	t, ok := s.tournaments[tournamentID]
+	if !ok || !restorable(userID, t.UserID, t.DeletedAt, after) {
+		return errstore.ErrNotFoundData
+	}
+	for id, a := range s.applications {
+		team, ok := s.teams[a.TeamID]
+		if a.TournamentID == tournamentID && a.DeletedAt.Time.Equal(t.DeletedAt.Time) && ok && !team.DeletedAt.Valid {
+			a.DeletedAt = gorm.DeletedAt{}
+			s.applications[id] = a
+		}
+	}
+	t.DeletedAt = gorm.DeletedAt{}
+	s.tournaments[tournamentID] = t
+	return nil
+}
+
+// RestoreTeam восстанавливает команду пользователя, удаленную после after, и заявки,
+// удаленные вместе с ней, если турнир заявки не в корзине.
+func (s *Storage) RestoreTeam(ctx context.Context, userID, teamID uint, after time.Time) error {
+	defer s.lock(ctx)()
+
+	team, ok := s.teams[teamID]
+	if !ok || !restorable(userID, team.UserID, team.DeletedAt, after) {
+		return errstore.ErrNotFoundData
+	}
+	for id, a := range s.applications {
+		t, ok := s.tournaments[a.TournamentID]
+		if a.TeamID == teamID && a.DeletedAt.Time.Equal(team.DeletedAt.Time) && ok && !t.DeletedAt.Valid {
+			a.DeletedAt = gorm.DeletedAt{}
+			s.applications[id] = a
+		}
+	}
+	team.DeletedAt = gorm.DeletedAt{}
+	s.teams[teamID] = team
+	return nil
+}
+
+// RestorePlayer восстанавливает игрока пользователя, удаленного после after.
+func (s *Storage) RestorePlayer(ctx context.Context, userID, playerID uint, after time.Time) error {
+	defer s.lock(ctx)()
+
+	player, ok := s.players[playerID]
+	if !ok || !restorable(userID, player.UserID, player.DeletedAt, after) {
+		return errstore.ErrNotFoundData
+	}
+	player.DeletedAt = gorm.DeletedAt{}
+	s.players[playerID] = player
+	return nil
+}
+
+// PurgeDeleted окончательно удаляет турниры, команды и игроков, удаленные до before,
+// вместе с их заявками, составами и запросами на замену. Возвращает число удаленных записей.
+func (s *Storage) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	defer s.lock(ctx)()
+
+	expired := func(d gorm.DeletedAt) bool { return d.Valid && d.Time.Before(before) }
+	purged := 0
+	tournaments := map[uint]bool{}
+	for id, t := range s.tournaments {
+		if expired(t.DeletedAt) {
+			tournaments[id] = true
+			delete(s.tournaments, id)
+			purged++
+		}
+	}
+	teams := map[uint]bool{}
+	for id, t := range s.teams {
+		if expired(t.DeletedAt) {
+			teams[id] = true
+			delete(s.teams, id)
+			delete(s.teamPlayers, id)
+			purged++
+		}
+	}
+	players := map[uint]bool{}
+	for id, p := range s.players {
+		if expired(p.DeletedAt) {
+			players[id] = true
+			delete(s.players, id)
+			purged++
+		}
+	}
+
+	for id, a := range s.applications {
+		if !expired(a.DeletedAt) && !tournaments[a.TournamentID] && !teams[a.TeamID] {
+			continue
+		}
+		delete(s.applications, id)
+		delete(s.applicationPlayers, id)
+		for cid, change := range s.rosterChanges {
+			if change.ApplicationID == id {
+				delete(s.rosterChanges, cid)
+			}
+		}
+	}
+
+	// игроки удаляются из оставшихся составов, списки заменяются целиком ради clone
+	purgedPlayer := func(id uint) bool { return players[id] }
+	for id, ids := range s.teamPlayers {
+		s.teamPlayers[id] = slices.DeleteFunc(slices.Clone(ids), purgedPlayer)
+	}
+	for id, ids := range s.applicationPlayers {
+		s.applicationPlayers[id] = slices.DeleteFunc(slices.Clone(ids), purgedPlayer)
+	}
+	return purged, nil
+}
@@ -3,14 +3,26 @@ package storage
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"sport-space/internal/adapter/models"
 	"sport-space/internal/adapter/storage/database"
+	"sport-space/internal/adapter/storage/memory"
+
+	"go.uber.org/zap"
 )
 
 type Store interface {
+	// Transaction выполняет fn атомарно, методы с ctx из fn работают в транзакции.
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+	// LockTournament, LockApplication блокируют запись до конца транзакции, турнир первым.
+	LockTournament(ctx context.Context, tournamentID uint) error
+	LockApplication(ctx context.Context, applicationID uint) error
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByPhone(ctx context.Context, phone string) (*models.User, error)
 	GetUserByID(ctx context.Context, userID uint) (*models.User, error)
+	UpdUser(ctx context.Context, user *models.User) (*models.User, error)
 	GetAllTournaments(ctx context.Context) (*[]models.Tournament, error)
 	NewUser(ctx context.Context, login, email, passwordHash string) (*models.User, error)
 	NewOTP(ctx context.Context, otp *models.OTPUser) error
@@ -40,19 +52,96 @@ type Store interface {
 	GetApplicationFromTeamTournament(ctx context.Context, tournamentID, teamID uint) (*models.Application, error)
 	GetApplicationByID(ctx context.Context, applicationID uint) (*models.Application, error)
 	GetApplicationsByTeamID(ctx context.Context, teamID uint) (*[]models.Application, error)
+	GetApplicationsByPlayerID(ctx context.Context, playerID uint) (*[]models.Application, error)
 	GetPlayersFromApplication(ctx context.Context, applicationID uint) (*[]models.Player, error)
 	GetApplicationsFromTournament(ctx context.Context, tournamentID uint) (*[]models.Application, error)
 	UpdApplicationTournament(ctx context.Context, application *models.Application) (*models.Application, error)
+	GetActiveApplicationsFromTournament(ctx context.Context, tournamentID uint) (*[]models.Application, error)
+	NewRosterChange(ctx context.Context, change *models.RosterChange) (*models.RosterChange, error)
+	GetRosterChangeByID(ctx context.Context, changeID uint) (*models.RosterChange, error)
+	GetRosterChangesByApplicationID(ctx context.Context, applicationID uint) (*[]models.RosterChange, error)
+	UpdRosterChange(ctx context.Context, change *models.RosterChange, players *[]models.Player) (
+		*models.RosterChange, error,
+	)
+	GetNotificationSettings(ctx context.Context, userID uint) (*models.NotificationSettings, error)
+	SaveNotificationSettings(ctx context.Context, settings *models.NotificationSettings) (
+		*models.NotificationSettings, error,
+	)
+	NewUpload(ctx context.Context, upload *models.Upload) (*models.Upload, error)
+	GetUploadByURL(ctx context.Context, url string) (*models.Upload, error)
+	GetUploadsSize(ctx context.Context, userID uint) (int64, error)
+	GetOrphanUploads(ctx context.Context, before time.Time, limit int) (*[]models.Upload, error)
+	RemoveUpload(ctx context.Context, uploadID uint) error
+	NewUploadSession(ctx context.Context, session *models.UploadSession) (*models.UploadSession, error)
+	GetUploadSession(ctx context.Context, sessionID string) (*models.UploadSession, error)
+	UpdUploadSession(ctx context.Context, session *models.UploadSession, received int64) error
+	RemoveUploadSession(ctx context.Context, sessionID string) error
+	GetExpiredUploadSessions(ctx context.Context, before time.Time, limit int) (*[]models.UploadSession, error)
+	NewOutboxMessage(ctx context.Context, msg *models.OutboxMessage) error
+	ClaimOutboxMessages(ctx context.Context, limit int, lease time.Duration) (*[]models.OutboxMessage, error)
+	UpdOutboxMessage(ctx context.Context, msg *models.OutboxMessage) error
+	GetOutboxMessages(ctx context.Context, status models.OutboxStatus) (*[]models.OutboxMessage, error)
+	GetOutboxMessageByID(ctx context.Context, id uint) (*models.OutboxMessage, error)
+	// DelTournament, DelTeam, DelPlayer удаляют запись в корзину с временем at,
+	// турнир и команда - вместе с заявками.
+	DelTournament(ctx context.Context, tournamentID uint, at time.Time) error
+	DelTeam(ctx context.Context, teamID uint, at time.Time) error
+	DelPlayer(ctx context.Context, playerID uint, at time.Time) error
+	GetDeletedTournaments(ctx context.Context, userID uint, after time.Time) (*[]models.Tournament, error)
+	GetDeletedTeams(ctx context.Context, userID uint, after time.Time) (*[]models.Team, error)
+	GetDeletedPlayers(ctx context.Context, userID uint, after time.Time) (*[]models.Player, error)
+	// RestoreTournament, RestoreTeam, RestorePlayer восстанавливают запись пользователя,
+	// удаленную после after, вместе с заявками, удаленными одновременно с ней.
+	RestoreTournament(ctx context.Context, userID, tournamentID uint, after time.Time) error
+	RestoreTeam(ctx context.Context, userID, teamID uint, after time.Time) error
+	RestorePlayer(ctx context.Context, userID, playerID uint, after time.Time) error
+	// PurgeDeleted окончательно удаляет записи, удаленные в корзину до before.
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
+	NewIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error)
+	GetIdempotencyKey(ctx context.Context, userID uint, key string) (*models.IdempotencyKey, error)
+	UpdIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error
+	RemoveIdempotencyKey(ctx context.Context, id uint) error
+	// PurgeIdempotencyKeys удаляет ключи запросов, созданные до before.
+	PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int, error)
+	// GetStats показатели для метрик на момент at.
+	GetStats(ctx context.Context, at time.Time) (*models.Stats, error)
 }
 
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
 type Config struct {
+	// Driver хранилище: postgres, sqlite - файл для одного сервера
+	// или memory - в памяти процесса, данные теряются при перезапуске.
+	Driver   string `env:"STORAGE_DRIVER" envDefault:"postgres"`
 	Database *database.Config
+	SQLite   *database.SQLiteConfig
 }
 
-func New(ctx context.Context, cfg Config) (Store, error) {
-	if cfg.Database != nil {
-		return database.New(ctx, *cfg.Database)
-	}
+// queryMetrics учет запросов к базе, хранилище memory его не использует.
+type queryMetrics interface {
+	ObserveQuery(operation, table string, d time.Duration, err error)
+}
 
-	return nil, errors.New("storage setting is empty")
+// New хранилище по cfg.Driver, запросы к базе учитываются в m, если он задан.
+func New(ctx context.Context, cfg Config, lgr *zap.Logger, m queryMetrics) (Store, error) {
+	switch cfg.Driver {
+	case DriverPostgres, "":
+		if cfg.Database == nil {
+			return nil, errors.New("storage setting is empty")
+		}
+		return database.New(ctx, *cfg.Database, database.SetLogger(lgr), database.SetMetrics(m))
+	case DriverSQLite:
+		if cfg.SQLite == nil {
+			return nil, errors.New("sqlite setting is empty")
+		}
+		return database.NewSQLite(ctx, *cfg.SQLite, database.SetLogger(lgr), database.SetMetrics(m))
+	case DriverMemory:
+		return memory.New(), nil
+	default:
+		return nil, fmt.Errorf("storage driver `%s` is not supported", cfg.Driver)
+	}
 }
@@ -0,0 +1,696 @@
+// Package storetest общий набор проверок реализаций storage.Store. Каждая реализация
+// запускает его из своего теста, например для хранилища в памяти:
+//
+//	func TestStore(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) storage.Store { return memory.New() })
+//	}
+//
+// newStore должен возвращать пустое хранилище для каждой подпроверки.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/storage"
+	"sport-space/internal/adapter/storage/errstore"
+)
+
+func Run(t *testing.T, newStore func(t *testing.T) storage.Store) {
+	t.Helper()
+
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, s storage.Store)
+	}{
+		{"Users", testUsers},
+		{"OTP", testOTP},
+		{"Tournaments", testTournaments},
+		{"TeamPlayers", testTeamPlayers},
+		{"PlayerBatch", testPlayerBatch},
+		{"Applications", testApplications},
+		{"Versions", testVersions},
+		{"Trash", testTrash},
+		{"RosterChanges", testRosterChanges},
+		{"NotificationSettings", testNotificationSettings},
+		{"Outbox", testOutbox},
+		{"IdempotencyKeys", testIdempotencyKeys},
+		{"Stats", testStats},
+		{"Uploads", testUploads},
+		{"UploadSessions", testUploadSessions},
+		{"Transaction", testTransaction},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.fn(t, newStore(t))
+		})
+	}
+}
+
+// ok прерывает проверку при ошибке: ok(s.GetUserByID(ctx, id))(t).
+func ok[T any](v T, err error) func(t *testing.T) T {
+	return func(t *testing.T) T {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return v
+	}
+}
+
+func requireNoErr(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func requireErr(t *testing.T, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("expected %v, got %v", target, err)
+	}
+}
+
+func newUser(t *testing.T, s storage.Store, email string) *models.User {
+	t.Helper()
+	return ok(s.NewUser(context.Background(), "", email, ""))(t)
+}
+
+func date(days int) *time.Time {
+	d := time.Now().AddDate(0, 0, days).Truncate(time.Second)
+	return &d
+}
+
+func newTournament(t *testing.T, s storage.Store, userID uint) *models.Tournament {
+	t.Helper()
+	return ok(s.NewTournament(context.Background(), &models.Tournament{
+		UserID:            userID,
+		Title:             "cup",
+		StartDate:         date(10),
+		EndDate:           date(20),
+		RegisterStartDate: date(-1),
+		RegisterEndDate:   date(5),
+	}))(t)
+}
+
+func testUsers(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+
+	user := newUser(t, s, "a@test.ru")
+	if user.ID == 0 {
+		t.Fatal("user id is not set")
+	}
+
+	got := ok(s.GetUserByEmail(ctx, "a@test.ru"))(t)
+	if got.ID != user.ID {
+		t.Fatalf("expected user %d, got %d", user.ID, got.ID)
+	}
+	_, err := s.GetUserByEmail(ctx, "b@test.ru")
+	requireErr(t, err, errstore.ErrNotFoundData)
+	_, err = s.GetUserByID(ctx, user.ID+100)
+	requireErr(t, err, errstore.ErrNotFoundData)
+
+	got.Phone = "+79990000000"
+	got.Language = "en"
+	ok(s.UpdUser(ctx, got))(t)
+	got = ok(s.GetUserByPhone(ctx, "+79990000000"))(t)
+	if got.ID != user.ID || got.Language != "en" {
+		t.Fatalf("user is not updated: %+v", got)
+	}
+	_, err = s.GetUserByPhone(ctx, "+70000000000")
+	requireErr(t, err, errstore.ErrNotFoundData)
+}
+
+func testOTP(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	user := newUser(t, s, "a@test.ru")
+
+	_, err := s.GetOTP(ctx, user)
+	requireErr(t, err, errstore.ErrNotFoundData)
+
+	if err = s.NewOTP(ctx, &models.OTPUser{UserID: user.ID, Password: "hash"}); err != nil {
+		t.Fatal(err)
+	}
+	otp := ok(s.GetOTP(ctx, user))(t)
+	if otp.Password != "hash" {
+		t.Fatalf("unexpected otp %+v", otp)
+	}
+
+	// удаление мягкое, но удаленный код не возвращается
+	if err = s.RemoveOTP(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.GetOTP(ctx, user)
+	requireErr(t, err, errstore.ErrNotFoundData)
+}
+
+func testTournaments(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	owner := newUser(t, s, "owner@test.ru")
+	other := newUser(t, s, "other@test.ru")
+
+	tournament := newTournament(t, s, owner.ID)
+	newTournament(t, s, other.ID)
+
+	if all := ok(s.GetAllTournaments(ctx))(t); len(*all) != 2 {
+		t.Fatalf("expected 2 tournaments, got %d", len(*all))
+	}
+	if own := ok(s.GetTournaments(ctx, owner.ID))(t); len(*own) != 1 || (*own)[0].ID != tournament.ID {
+		t.Fatalf("unexpected tournaments of owner: %+v", own)
+	}
+
+	tournament.Title = "updated"
+	ok(s.UpdTournamentByUser(ctx, tournament))(t)
+	if got := ok(s.GetTournamentByID(ctx, tournament.ID))(t); got.Title != "updated" {
+		t.Fatalf("tournament is not updated: %+v", got)
+	}
+
+	// чужой турнир не обновляется
+	foreign := *tournament
+	foreign.UserID = other.ID
+	_, err := s.UpdTournamentByUser(ctx, &foreign)
+	requireErr(t, err, errstore.ErrNotFoundData)
+
+	_, err = s.GetTournamentByID(ctx, tournament.ID+100)
+	requireErr(t, err, errstore.ErrNotFoundData)
+}
+
+func testTeamPlayers(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	user := newUser(t, s, "a@test.ru")
+
+	team := ok(s.NewTeam(ctx, &models.Team{UserID: user.ID, Title: "team"}))(t)
+	p1 := ok(s.NewPlayer(ctx, &models.Player{UserID: user.ID, FirstName: "a", LastName: "a"}))(t)
+	p2 := ok(s.NewPlayer(ctx, &models.Player{UserID: user.ID, FirstName: "b", LastName: "b"}))(t)
+
+	team.Title = "renamed"
+	_, players, err := s.UpdTeam(ctx, team, &[]uint{p1.ID, p2.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*players) != 2 {
+		t.Fatalf("expected 2 players, got %d", len(*players))
+	}
+
+	got := ok(s.GetTeamByID(ctx, team.ID))(t)
+	if got.Title != "renamed" || len(got.Players) != 2 {
+		t.Fatalf("unexpected team %+v", got)
+	}
+	if teamPlayers := ok(s.GetPlayersFromTeam(ctx, team.ID))(t); len(*teamPlayers) != 2 {
+		t.Fatalf("expected 2 team players, got %d", len(*teamPlayers))
+	}
+
+	// состав заменяется целиком
+	_, _, err = s.UpdTeam(ctx, got, &[]uint{p2.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if teamPlayers := ok(s.GetPlayersFromTeam(ctx, team.ID))(t); len(*teamPlayers) != 1 || (*teamPlayers)[0].ID != p2.ID {
+		t.Fatalf("unexpected team players %+v", teamPlayers)
+	}
+
+	if teams := ok(s.GetTeams(ctx, user))(t); len(*teams) != 1 {
+		t.Fatalf("expected 1 team, got %d", len(*teams))
+	}
+	_, err = s.GetTeamByID(ctx, team.ID+100)
+	requireErr(t, err, errstore.ErrNotFoundData)
+
+	p1.LastName = "updated"
+	ok(s.UpdPlayer(ctx, p1))(t)
+	if got := ok(s.GetPlayerByID(ctx, p1.ID))(t); got.LastName != "updated" {
+		t.Fatalf("player is not updated: %+v", got)
+	}
+	foreign := *p1
+	foreign.UserID = user.ID + 100
+	_, err = s.UpdPlayer(ctx, &foreign)
+	requireErr(t, err, errstore.ErrNotFoundData)
+
+	if byIDs := ok(s.GetPlayersByIDs(ctx, []uint{p1.ID, p2.ID + 100}))(t); len(*byIDs) != 1 {
+		t.Fatalf("expected 1 player by ids, got %d", len(*byIDs))
+	}
+	if all := ok(s.GetPlayers(ctx, user.ID))(t); len(*all) != 2 {
+		t.Fatalf("expected 2 players, got %d", len(*all))
+	}
+}
+
+func testPlayerBatch(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	user := newUser(t, s, "a@test.ru")
+
+	existing := ok(s.NewPlayer(ctx, &models.Player{UserID: user.ID, FirstName: "a", LastName: "a"}))(t)
+	batch := &[]models.Player{
+		{ID: existing.ID, UserID: user.ID, FirstName: "a", LastName: "updated"},
+		{UserID: user.ID, FirstName: "b", LastName: "b"},
+	}
+	batch = ok(s.NewPlayerBatch(ctx, batch))(t)
+	if (*batch)[1].ID == 0 {
+		t.Fatal("new player id is not set")
+	}
+
+	if got := ok(s.GetPlayerByID(ctx, existing.ID))(t); got.LastName != "updated" {
+		t.Fatalf("existing player is not updated: %+v", got)
+	}
+	if all := ok(s.GetPlayers(ctx, user.ID))(t); len(*all) != 2 {
+		t.Fatalf("expected 2 players, got %d", len(*all))
+	}
+}
+
+func testApplications(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	user := newUser(t, s, "a@test.ru")
+	tournament := newTournament(t, s, user.ID)
+	team := ok(s.NewTeam(ctx, &models.Team{UserID: user.ID, Title: "team"}))(t)
+	player := ok(s.NewPlayer(ctx, &models.Player{UserID: user.ID, FirstName: "a", LastName: "a"}))(t)
+
+	application, _, err := s.NewApplication(ctx,
+		&models.Application{TeamID: team.ID, TournamentID: tournament.ID}, &[]models.Player{*player})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if application.Status != models.Draft {
+		t.Fatalf("expected draft, got %s", application.Status)
+	}
+
+	// заявка команды в турнир уникальна
+	_, _, err = s.NewApplication(ctx,
+		&models.Application{TeamID: team.ID, TournamentID: tournament.ID}, &[]models.Player{})
+	requireErr(t, err, errstore.ErrConflictData)
+
+	got := ok(s.GetApplicationByID(ctx, application.ID))(t)
+	if len(got.Players) != 1 || got.Players[0].ID != player.ID {
+		t.Fatalf("unexpected application players %+v", got.Players)
+	}
+	if found := ok(s.GetApplicationFromTeamTournament(ctx, tournament.ID, team.ID))(t); found.ID != application.ID {
+		t.Fatalf("expected application %d, got %d", application.ID, found.ID)
+	}
+	_, err = s.GetApplicationFromTeamTournament(ctx, tournament.ID, team.ID+100)
+	requireErr(t, err, errstore.ErrNotFoundData)
+
+	// черновик не виден организатору
+	if list := ok(s.GetApplicationsFromTournament(ctx, tournament.ID))(t); len(*list) != 0 {
+		t.Fatalf("draft is visible to tournament: %+v", list)
+	}
+
+	updated, _, err := s.UpdApplication(ctx, &models.Application{
+		ID: application.ID, Version: application.Version, Status: models.InProgress, StatusDate: time.Now(),
+	}, &[]models.Player{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list := ok(s.GetApplicationsFromTournament(ctx, tournament.ID))(t); len(*list) != 1 {
+		t.Fatalf("expected 1 application, got %d", len(*list))
+	}
+	if players := ok(s.GetPlayersFromApplication(ctx, application.ID))(t); len(*players) != 0 {
+		t.Fatalf("players are not replaced: %+v", players)
+	}
+
+	ok(s.UpdApplicationTournament(ctx,
+		&models.Application{ID: application.ID, Version: updated.Version, Status: models.Canceled}))(t)
+	if active := ok(s.GetActiveApplicationsFromTournament(ctx, tournament.ID))(t); len(*active) != 0 {
+		t.Fatalf("canceled application is active: %+v", active)
+	}
+	if byTeam := ok(s.GetApplicationsByTeamID(ctx, team.ID))(t); len(*byTeam) != 1 {
+		t.Fatalf("expected 1 team application, got %d", len(*byTeam))
+	}
+	_, err = s.GetApplicationByID(ctx, application.ID+100)
+	requireErr(t, err, errstore.ErrNotFoundData)
+}
+
+func testVersions(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	user := newUser(t, s, "a@test.ru")
+
+	tournament := newTournament(t, s, user.ID)
+	if tournament.Version != 1 {
+		t.Fatalf("expected version 1, got %d", tournament.Version)
+	}
+	stale := *tournament
+	ok(s.UpdTournamentByUser(ctx, tournament))(t)
+	if got := ok(s.GetTournamentByID(ctx, tournament.ID))(t); got.Version != 2 {
+		t.Fatalf("expected version 2, got %d", got.Version)
+	}
+	_, err := s.UpdTournamentByUser(ctx, &stale)
+	requireErr(t, err, errstore.ErrVersionConflict)
+
+	team := ok(s.NewTeam(ctx, &models.Team{UserID: user.ID, Title: "team"}))(t)
+	staleTeam := *team
+	_, _, err = s.UpdTeam(ctx, team, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = s.UpdTeam(ctx, &staleTeam, nil)
+	requireErr(t, err, errstore.ErrVersionConflict)
+	_, _, err = s.UpdTeam(ctx, &models.Team{ID: team.ID + 100, Version: 1}, nil)
+	requireErr(t, err, errstore.ErrNotFoundData)
+
+	player := ok(s.NewPlayer(ctx, &models.Player{UserID: user.ID, FirstName: "a", LastName: "a"}))(t)
+	stalePlayer := *player
+	ok(s.UpdPlayer(ctx, player))(t)
+	_, err = s.UpdPlayer(ctx, &stalePlayer)
+	requireErr(t, err, errstore.ErrVersionConflict)
+
+	// пакетная загрузка тоже меняет версию
+	batch := ok(s.NewPlayerBatch(ctx, &[]models.Player{
+		{ID: player.ID, UserID: user.ID, FirstName: "a", LastName: "b"},
+	}))(t)
+	if got := ok(s.GetPlayerByID(ctx, player.ID))(t); got.Version != 3 || (*batch)[0].Version != 3 {
+		t.Fatalf("expected version 3, got %d", got.Version)
+	}
+}
+
+func testTrash(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	user := newUser(t, s, "a@test.ru")
+	tournament := newTournament(t, s, user.ID)
+	team := ok(s.NewTeam(ctx, &models.Team{UserID: user.ID, Title: "team"}))(t)
+	player := ok(s.NewPlayer(ctx, &models.Player{UserID: user.ID, FirstName: "a", LastName: "a"}))(t)
+	if _, _, err := s.UpdTeam(ctx, team, &[]uint{player.ID}); err != nil {
+		t.Fatal(err)
+	}
+	application, _, err := s.NewApplication(ctx,
+		&models.Application{TeamID: team.ID, TournamentID: tournament.ID}, &[]models.Player{*player})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byPlayer := ok(s.GetApplicationsByPlayerID(ctx, player.ID))(t); len(*byPlayer) != 1 {
+		t.Fatalf("expected 1 player application, got %d", len(*byPlayer))
+	}
+
+	now := time.Now()
+	window := now.Add(-24 * time.Hour)
+
+	// игрок пропадает из составов до восстановления
+	requireNoErr(t, s.DelPlayer(ctx, player.ID, now))
+	requireErr(t, s.DelPlayer(ctx, player.ID, now), errstore.ErrNotFoundData)
+	if players := ok(s.GetPlayersFromApplication(ctx, application.ID))(t); len(*players) != 0 {
+		t.Fatalf("deleted player is in application: %+v", players)
+	}
+	if deleted := ok(s.GetDeletedPlayers(ctx, user.ID, window))(t); len(*deleted) != 1 {
+		t.Fatalf("expected 1 deleted player, got %d", len(*deleted))
+	}
+	requireErr(t, s.RestorePlayer(ctx, user.ID+100, player.ID, window), errstore.ErrNotFoundData)
+	requireNoErr(t, s.RestorePlayer(ctx, user.ID, player.ID, window))
+	if players := ok(s.GetPlayersFromApplication(ctx, application.ID))(t); len(*players) != 1 {
+		t.Fatalf("restored player is not in application: %+v", players)
+	}
+
+	// заявки удаляются и восстанавливаются вместе с турниром
+	requireNoErr(t, s.DelTournament(ctx, tournament.ID, now))
+	_, err = s.GetApplicationByID(ctx, application.ID)
+	requireErr(t, err, errstore.ErrNotFoundData)
+	if deleted := ok(s.GetDeletedTournaments(ctx, user.ID, window))(t); len(*deleted) != 1 {
+		t.Fatalf("expected 1 deleted tournament, got %d", len(*deleted))
+	}
+	requireNoErr(t, s.RestoreTournament(ctx, user.ID, tournament.ID, window))
+	ok(s.GetApplicationByID(ctx, application.ID))(t)
+
+	requireNoErr(t, s.DelTeam(ctx, team.ID, now))
+	_, err = s.GetApplicationByID(ctx, application.ID)
+	requireErr(t, err, errstore.ErrNotFoundData)
+	requireNoErr(t, s.RestoreTeam(ctx, user.ID, team.ID, window))
+	ok(s.GetApplicationByID(ctx, application.ID))(t)
+
+	// после окна восстановления записи удаляются окончательно
+	old := now.Add(-48 * time.Hour)
+	requireNoErr(t, s.DelTeam(ctx, team.ID, old))
+	requireNoErr(t, s.DelPlayer(ctx, player.ID, old))
+	if deleted := ok(s.GetDeletedTeams(ctx, user.ID, window))(t); len(*deleted) != 0 {
+		t.Fatalf("expired team is in trash: %+v", deleted)
+	}
+	requireErr(t, s.RestoreTeam(ctx, user.ID, team.ID, window), errstore.ErrNotFoundData)
+	if purged := ok(s.PurgeDeleted(ctx, window))(t); purged != 2 {
+		t.Fatalf("expected 2 purged, got %d", purged)
+	}
+	if deleted := ok(s.GetDeletedTeams(ctx, user.ID, time.Time{}))(t); len(*deleted) != 0 {
+		t.Fatalf("purged team is in trash: %+v", deleted)
+	}
+	_, err = s.GetApplicationFromTeamTournament(ctx, tournament.ID, team.ID)
+	requireErr(t, err, errstore.ErrNotFoundData)
+	ok(s.GetTournamentByID(ctx, tournament.ID))(t)
+}
+
+func testRosterChanges(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	user := newUser(t, s, "a@test.ru")
+	tournament := newTournament(t, s, user.ID)
+	team := ok(s.NewTeam(ctx, &models.Team{UserID: user.ID, Title: "team"}))(t)
+	out := ok(s.NewPlayer(ctx, &models.Player{UserID: user.ID, FirstName: "a", LastName: "a"}))(t)
+	in := ok(s.NewPlayer(ctx, &models.Player{UserID: user.ID, FirstName: "b", LastName: "b"}))(t)
+	application, _, err := s.NewApplication(ctx,
+		&models.Application{TeamID: team.ID, TournamentID: tournament.ID}, &[]models.Player{*out})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	change := ok(s.NewRosterChange(ctx, &models.RosterChange{
+		ApplicationID: application.ID,
+		PlayerOutID:   out.ID,
+		PlayerInID:    in.ID,
+		Status:        models.RosterChangePending,
+	}))(t)
+
+	change.Status = models.RosterChangeApproved
+	ok(s.UpdRosterChange(ctx, change, &[]models.Player{*in}))(t)
+
+	if got := ok(s.GetRosterChangeByID(ctx, change.ID))(t); got.Status != models.RosterChangeApproved {
+		t.Fatalf("roster change is not updated: %+v", got)
+	}
+	if players := ok(s.GetPlayersFromApplication(ctx, application.ID))(t); len(*players) != 1 || (*players)[0].ID != in.ID {
+		t.Fatalf("application players are not replaced: %+v", players)
+	}
+	if changes := ok(s.GetRosterChangesByApplicationID(ctx, application.ID))(t); len(*changes) != 1 {
+		t.Fatalf("expected 1 roster change, got %d", len(*changes))
+	}
+	_, err = s.GetRosterChangeByID(ctx, change.ID+100)
+	requireErr(t, err, errstore.ErrNotFoundData)
+}
+
+func testNotificationSettings(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	user := newUser(t, s, "a@test.ru")
+
+	_, err := s.GetNotificationSettings(ctx, user.ID)
+	requireErr(t, err, errstore.ErrNotFoundData)
+
+	ok(s.SaveNotificationSettings(ctx, &models.NotificationSettings{UserID: user.ID, ApplicationStatus: true}))(t)
+	// повторное сохранение обновляет настройки пользователя
+	ok(s.SaveNotificationSettings(ctx, &models.NotificationSettings{UserID: user.ID, ApplicationCanceled: true}))(t)
+
+	got := ok(s.GetNotificationSettings(ctx, user.ID))(t)
+	if got.ApplicationStatus || !got.ApplicationCanceled {
+		t.Fatalf("unexpected settings %+v", got)
+	}
+}
+
+func testIdempotencyKeys(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+
+	key := ok(s.NewIdempotencyKey(ctx, &models.IdempotencyKey{UserID: 1, Key: "k", RequestHash: "h"}))(t)
+	_, err := s.NewIdempotencyKey(ctx, &models.IdempotencyKey{UserID: 1, Key: "k", RequestHash: "h2"})
+	requireErr(t, err, errstore.ErrConflictData)
+	// ключи разных пользователей не пересекаются
+	ok(s.NewIdempotencyKey(ctx, &models.IdempotencyKey{UserID: 2, Key: "k", RequestHash: "h"}))(t)
+
+	key.StatusCode = 201
+	key.ContentType = "application/json"
+	key.Body = []byte(`{"id":1}`)
+	requireNoErr(t, s.UpdIdempotencyKey(ctx, key))
+	saved := ok(s.GetIdempotencyKey(ctx, 1, "k"))(t)
+	if saved.StatusCode != 201 || saved.RequestHash != "h" || string(saved.Body) != `{"id":1}` {
+		t.Fatalf("unexpected idempotency key %+v", saved)
+	}
+
+	requireNoErr(t, s.RemoveIdempotencyKey(ctx, key.ID))
+	_, err = s.GetIdempotencyKey(ctx, 1, "k")
+	requireErr(t, err, errstore.ErrNotFoundData)
+
+	if purged := ok(s.PurgeIdempotencyKeys(ctx, time.Now().Add(time.Minute)))(t); purged != 1 {
+		t.Fatalf("expected 1 purged, got %d", purged)
+	}
+	_, err = s.GetIdempotencyKey(ctx, 2, "k")
+	requireErr(t, err, errstore.ErrNotFoundData)
+}
+
+func testStats(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	user := newUser(t, s, "stats@test.ru")
+	open := newTournament(t, s, user.ID)
+	ok(s.NewTournament(ctx, &models.Tournament{
+		UserID:            user.ID,
+		Title:             "closed",
+		StartDate:         date(10),
+		EndDate:           date(20),
+		RegisterStartDate: date(2),
+		RegisterEndDate:   date(5),
+	}))(t)
+	deleted := newTournament(t, s, user.ID)
+	requireNoErr(t, s.DelTournament(ctx, deleted.ID, time.Now()))
+
+	team := ok(s.NewTeam(ctx, &models.Team{UserID: user.ID, Title: "team"}))(t)
+	application, _, err := s.NewApplication(ctx,
+		&models.Application{TeamID: team.ID, TournamentID: open.ID}, &[]models.Player{})
+	requireNoErr(t, err)
+
+	stats := ok(s.GetStats(ctx, time.Now()))(t)
+	if stats.OpenTournaments != 1 || stats.PendingApplications != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	// черновик становится заявкой на рассмотрении после подачи
+	_, _, err = s.UpdApplication(ctx, &models.Application{
+		ID: application.ID, Version: application.Version, Status: models.InProgress, StatusDate: time.Now(),
+	}, &[]models.Player{})
+	requireNoErr(t, err)
+	if stats := ok(s.GetStats(ctx, time.Now()))(t); stats.PendingApplications != 1 {
+		t.Fatalf("expected 1 pending application, got %d", stats.PendingApplications)
+	}
+}
+
+func testOutbox(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+
+	now := time.Now()
+	for _, msg := range []*models.OutboxMessage{
+		{To: "a@test.ru", Status: models.OutboxPending, NextAttemptAt: now.Add(-time.Minute)},
+		{To: "b@test.ru", Status: models.OutboxPending, NextAttemptAt: now.Add(time.Hour)},
+		{To: "c@test.ru", Status: models.OutboxDead, NextAttemptAt: now.Add(-time.Minute)},
+	} {
+		if err := s.NewOutboxMessage(ctx, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	claimed := ok(s.ClaimOutboxMessages(ctx, 10, time.Minute))(t)
+	if len(*claimed) != 1 || (*claimed)[0].To != "a@test.ru" {
+		t.Fatalf("unexpected claimed messages %+v", claimed)
+	}
+	// письмо отложено на время lease
+	if again := ok(s.ClaimOutboxMessages(ctx, 10, time.Minute))(t); len(*again) != 0 {
+		t.Fatalf("message claimed twice: %+v", again)
+	}
+
+	msg := (*claimed)[0]
+	msg.Status = models.OutboxSent
+	if err := s.UpdOutboxMessage(ctx, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if sent := ok(s.GetOutboxMessages(ctx, models.OutboxSent))(t); len(*sent) != 1 {
+		t.Fatalf("expected 1 sent message, got %d", len(*sent))
+	}
+	if got := ok(s.GetOutboxMessageByID(ctx, msg.ID))(t); got.Status != models.OutboxSent {
+		t.Fatalf("message is not updated: %+v", got)
+	}
+	_, err := s.GetOutboxMessageByID(ctx, msg.ID+100)
+	requireErr(t, err, errstore.ErrNotFoundData)
+}
+
+func testUploads(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	user := newUser(t, s, "a@test.ru")
+
+	used := ok(s.NewUpload(ctx, &models.Upload{UserID: user.ID, URL: "http://x/used.png", Size: 10}))(t)
+	orphan := ok(s.NewUpload(ctx, &models.Upload{UserID: user.ID, URL: "http://x/orphan.png", Size: 5}))(t)
+	ok(s.NewPlayer(ctx, &models.Player{UserID: user.ID, FirstName: "a", LastName: "a", PhotoURL: used.URL}))(t)
+
+	if size := ok(s.GetUploadsSize(ctx, user.ID))(t); size != 15 {
+		t.Fatalf("expected 15 bytes, got %d", size)
+	}
+	if got := ok(s.GetUploadByURL(ctx, orphan.URL))(t); got.ID != orphan.ID {
+		t.Fatalf("expected upload %d, got %d", orphan.ID, got.ID)
+	}
+
+	orphans := ok(s.GetOrphanUploads(ctx, time.Now().Add(time.Minute), 10))(t)
+	if len(*orphans) != 1 || (*orphans)[0].ID != orphan.ID {
+		t.Fatalf("unexpected orphan uploads %+v", orphans)
+	}
+	if fresh := ok(s.GetOrphanUploads(ctx, time.Now().Add(-time.Minute), 10))(t); len(*fresh) != 0 {
+		t.Fatalf("uploads newer than grace are orphans: %+v", fresh)
+	}
+
+	if err := s.RemoveUpload(ctx, orphan.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err := s.GetUploadByURL(ctx, orphan.URL)
+	requireErr(t, err, errstore.ErrNotFoundData)
+}
+
+func testUploadSessions(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	user := newUser(t, s, "a@test.ru")
+
+	session := ok(s.NewUploadSession(ctx, &models.UploadSession{
+		ID:        "session",
+		UserID:    user.ID,
+		Size:      10,
+		Chunks:    []string{},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))(t)
+
+	session.Received = 4
+	session.Chunks = []string{"chunk-0"}
+	if err := s.UpdUploadSession(ctx, session, 0); err != nil {
+		t.Fatal(err)
+	}
+	// часть с тем же offset уже записана другим запросом
+	requireErr(t, s.UpdUploadSession(ctx, session, 0), errstore.ErrConflictData)
+
+	got := ok(s.GetUploadSession(ctx, "session"))(t)
+	if got.Received != 4 || len(got.Chunks) != 1 {
+		t.Fatalf("session is not updated: %+v", got)
+	}
+
+	if expired := ok(s.GetExpiredUploadSessions(ctx, time.Now(), 10))(t); len(*expired) != 0 {
+		t.Fatalf("active session is expired: %+v", expired)
+	}
+	if expired := ok(s.GetExpiredUploadSessions(ctx, time.Now().Add(2*time.Hour), 10))(t); len(*expired) != 1 {
+		t.Fatalf("expected 1 expired session, got %d", len(*expired))
+	}
+
+	if err := s.RemoveUploadSession(ctx, "session"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := s.GetUploadSession(ctx, "session")
+	requireErr(t, err, errstore.ErrNotFoundData)
+}
+
+func testTransaction(t *testing.T, s storage.Store) {
+	ctx := context.Background()
+	user := newUser(t, s, "a@test.ru")
+	tournament := newTournament(t, s, user.ID)
+
+	errRollback := errors.New("rollback")
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.LockTournament(ctx, tournament.ID); err != nil {
+			return err
+		}
+		ok(s.NewTeam(ctx, &models.Team{UserID: user.ID, Title: "rolled back"}))(t)
+		return errRollback
+	})
+	// ошибка fn возвращается без изменений, записи откатываются
+	requireErr(t, err, errRollback)
+	if teams := ok(s.GetTeams(ctx, user))(t); len(*teams) != 0 {
+		t.Fatalf("team is not rolled back: %+v", teams)
+	}
+
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		team := ok(s.NewTeam(ctx, &models.Team{UserID: user.ID, Title: "committed"}))(t)
+		_, _, err := s.NewApplication(ctx,
+			&models.Application{TeamID: team.ID, TournamentID: tournament.ID}, &[]models.Player{})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if teams := ok(s.GetTeams(ctx, user))(t); len(*teams) != 1 {
+		t.Fatalf("expected 1 team, got %d", len(*teams))
+	}
+
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		return s.LockApplication(ctx, tournament.ID+100)
+	})
+	requireErr(t, err, errstore.ErrNotFoundData)
+}
@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+type Config struct {
+	// Exporter куда отправляются спаны: none - никуда (по умолчанию), stdout - в вывод
+	// процесса для локальной отладки, otlp - коллектору по OTLP/HTTP. Адрес коллектора
+	// и заголовки задаются стандартными OTEL_EXPORTER_OTLP_* переменными.
+	Exporter    string  `env:"TRACING_EXPORTER" envDefault:"none"`
+	ServiceName string  `env:"OTEL_SERVICE_NAME" envDefault:"sportspace"`
+	SampleRatio float64 `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
+}
+
+// Provider глобальный TracerProvider OpenTelemetry, Shutdown отправляет накопленные спаны.
+type Provider struct {
+	tp *sdktrace.TracerProvider
+}
+
+// New настраивает глобальные TracerProvider и распространение контекста W3C (traceparent,
+// tracestate, baggage). При Exporter=none спаны не пишутся, но входящий контекст
+// трассировки по-прежнему передается дальше.
+func New(ctx context.Context, cfg Config) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return &Provider{}, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("tracing exporter `%s` is not supported", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed create %s exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed create tracing resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// решение о записи принимает тот, кто начал трассировку
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	return &Provider{tp: tp}, nil
+}
+
+// Shutdown отправляет оставшиеся спаны и останавливает экспортер.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.tp == nil {
+		return nil
+	}
+	if err := p.tp.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed shutdown tracer provider: %w", err)
+	}
+	return nil
+}
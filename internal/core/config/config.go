@@ -4,11 +4,17 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"sport-space/internal/adapter/api/rest"
+	"sport-space/internal/adapter/blob"
+	"sport-space/internal/adapter/cache"
+	"sport-space/internal/adapter/channel"
+	"sport-space/internal/adapter/ratelimit"
 	"sport-space/internal/adapter/sender"
 	"sport-space/internal/adapter/storage"
 	"sport-space/internal/adapter/storage/database"
+	"sport-space/internal/adapter/tracing"
 	"sport-space/internal/core/sportspace"
 
 	"github.com/caarlos0/env/v11"
@@ -16,22 +22,29 @@ import (
 )
 
 type Config struct {
-	Store      storage.Config
-	Sender     sender.Config
-	Sport      sportspace.Config
-	Rest       rest.Config
-	Address    string `env:"HTTP_ADDRESS" envDefault:":8080"`
-	BaseURL    string `env:"BASE_URL" envDefault:"http://localhost:8080"`
-	SecretKey  string `env:"SECRET_KEY" envDefault:""`
-	UploadPath string `env:"UPLOAD_PATH" envDefault:"/uploads"`
-	LogLevel   string `env:"LOG_LEVEL" envDefault:"debug"`
-	Source     string `env:"SOURCE" envDefault:"dev"`
+	Store     storage.Config
+	Sender    sender.Config
+	Channel   channel.Config
+	Blob      blob.Config
+	Cache     cache.Config
+	RateLimit ratelimit.Config
+	Tracing   tracing.Config
+	Sport     sportspace.Config
+	Rest      rest.Config
+	Address   string `env:"HTTP_ADDRESS" envDefault:":8080"`
+	BaseURL   string `env:"BASE_URL" envDefault:"http://localhost:8080"`
+	SecretKey string `env:"SECRET_KEY" envDefault:""`
+	LogLevel  string `env:"LOG_LEVEL" envDefault:"debug"`
+	Source    string `env:"SOURCE" envDefault:"dev"`
+	// ShutdownTimeout сколько ждать завершения запросов и отправки писем при остановке
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"30s"`
 }
 
 func Init() (*Config, error) {
 	cfg := &Config{
 		Store: storage.Config{
 			Database: &database.Config{},
+			SQLite:   &database.SQLiteConfig{},
 		},
 		Sender: sender.Config{},
 		Rest:   rest.Config{},
@@ -1,5 +1,18 @@
 package sportspace
 
+import "time"
+
 type Config struct {
-	OTPLength uint `env:"OTP_LENGTH" envDefault:"6"`
+	OTPLength                uint          `env:"OTP_LENGTH" envDefault:"6"`
+	RosterConflictPolicy     string        `env:"ROSTER_CONFLICT_POLICY" envDefault:"block"`
+	LateSubstitutionsLimit   uint          `env:"LATE_SUBSTITUTIONS_LIMIT" envDefault:"3"`
+	UploadQuota              int64         `env:"UPLOAD_QUOTA" envDefault:"52428800"`
+	UploadGCInterval         time.Duration `env:"UPLOAD_GC_INTERVAL" envDefault:"1h"`
+	UploadGCGrace            time.Duration `env:"UPLOAD_GC_GRACE" envDefault:"24h"`
+	UploadSessionTTL         time.Duration `env:"UPLOAD_SESSION_TTL" envDefault:"24h"`
+	TrashRetention           time.Duration `env:"TRASH_RETENTION" envDefault:"720h"`
+	TrashPurgeInterval       time.Duration `env:"TRASH_PURGE_INTERVAL" envDefault:"1h"`
+	IdempotencyTTL           time.Duration `env:"IDEMPOTENCY_TTL" envDefault:"24h"`
+	IdempotencyLease         time.Duration `env:"IDEMPOTENCY_LEASE" envDefault:"1m"`
+	IdempotencyPurgeInterval time.Duration `env:"IDEMPOTENCY_PURGE_INTERVAL" envDefault:"1h"`
 }
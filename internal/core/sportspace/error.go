@@ -7,4 +7,10 @@ var (
 	ErrLoginNotValid       = errors.New("login is not valid")
 	ErrPasswordNotEquale   = errors.New("password not equale")
 	ErrOrderNumberNotValid = errors.New("order number not valid")
+
+	ErrRosterConflictPolicyNotValid = errors.New("roster conflict policy is not valid")
+	ErrLanguageNotSupported         = errors.New("language is not supported")
+	ErrChannelNotSupported          = errors.New("otp channel is not supported")
+	ErrTelegramNotLinked            = errors.New("telegram chat is not linked, share phone number with the bot")
+	ErrUploadsDisabled              = errors.New("file storage is not configured")
 )
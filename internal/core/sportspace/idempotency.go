@@ -0,0 +1,124 @@
+package sportspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sport-space/internal/adapter/errsport"
+	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/storage/errstore"
+
+	"go.uber.org/zap"
+)
+
+// BeginIdempotent занимает ключ key пользователя под запрос с хешем hash. Новый ключ
+// возвращается со StatusCode 0, ключ выполненного запроса - с сохраненным ответом.
+// Ключ с другим запросом - ErrIdempotencyKeyReused, ключ запроса, который еще
+// выполняется, - ErrIdempotencyInProgress. Ключ старше срока хранения и ключ без ответа
+// старше idempotencyLease (запрос упал, не освободив его) занимаются заново.
+func (s *SportSpace) BeginIdempotent(ctx context.Context, userID uint, key, hash string) (
+	*models.IdempotencyKey, error,
+) {
+	ctx, span := tracer.Start(ctx, "SportSpace.BeginIdempotent")
+	defer span.End()
+
+	for range 2 {
+		saved, err := s.store.NewIdempotencyKey(ctx, &models.IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: hash,
+		})
+		if err == nil {
+			return saved, nil
+		}
+		if !errors.Is(err, errstore.ErrConflictData) {
+			return nil, fmt.Errorf("failed create idempotency key: %w", err)
+		}
+
+		saved, err = s.store.GetIdempotencyKey(ctx, userID, key)
+		if err != nil {
+			if errors.Is(err, errstore.ErrNotFoundData) {
+				// ключ удалили между попытками, занимаем снова
+				continue
+			}
+			return nil, fmt.Errorf("failed get idempotency key: %w", err)
+		}
+		expired := time.Since(saved.CreatedAt) > s.idempotencyTTL
+		abandoned := saved.StatusCode == 0 && time.Since(saved.CreatedAt) > s.idempotencyLease
+		if expired || abandoned {
+			if err = s.store.RemoveIdempotencyKey(ctx, saved.ID); err != nil {
+				return nil, fmt.Errorf("failed remove idempotency key: %w", err)
+			}
+			continue
+		}
+		if saved.RequestHash != hash {
+			return nil, errsport.ErrIdempotencyKeyReused
+		}
+		if saved.StatusCode == 0 {
+			return nil, errsport.ErrIdempotencyInProgress
+		}
+		return saved, nil
+	}
+	return nil, errsport.ErrIdempotencyInProgress
+}
+
+// FinishIdempotent сохраняет ответ на запрос, повторы с ключом получат его.
+func (s *SportSpace) FinishIdempotent(ctx context.Context, key *models.IdempotencyKey) error {
+	ctx, span := tracer.Start(ctx, "SportSpace.FinishIdempotent")
+	defer span.End()
+
+	if err := s.store.UpdIdempotencyKey(ctx, key); err != nil {
+		return fmt.Errorf("failed save idempotency key: %w", err)
+	}
+	return nil
+}
+
+// CancelIdempotent освобождает ключ запроса, который не выполнился, повтор выполнит его заново.
+func (s *SportSpace) CancelIdempotent(ctx context.Context, key *models.IdempotencyKey) error {
+	ctx, span := tracer.Start(ctx, "SportSpace.CancelIdempotent")
+	defer span.End()
+
+	if err := s.store.RemoveIdempotencyKey(ctx, key.ID); err != nil {
+		return fmt.Errorf("failed remove idempotency key: %w", err)
+	}
+	return nil
+}
+
+// PurgeIdempotencyKeys удаляет ключи запросов старше срока хранения.
+func (s *SportSpace) PurgeIdempotencyKeys(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.PurgeIdempotencyKeys")
+	defer span.End()
+
+	purged, err := s.store.PurgeIdempotencyKeys(ctx, time.Now().Add(-s.idempotencyTTL))
+	if err != nil {
+		return 0, fmt.Errorf("failed purge idempotency keys: %w", err)
+	}
+	return purged, nil
+}
+
+// RunIdempotencyPurge периодически удаляет старые ключи запросов до отмены ctx.
+func (s *SportSpace) RunIdempotencyPurge(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, err := s.PurgeIdempotencyKeys(ctx)
+		if err != nil {
+			s.log.Error("failed purge idempotency keys", zap.Error(err))
+		}
+		if purged > 0 {
+			s.log.Info("idempotency keys purged", zap.Int("count", purged))
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"sport-space/internal/adapter/errsport"
@@ -11,12 +12,23 @@ import (
 	"sport-space/internal/adapter/storage/errstore"
 	"sport-space/pkg/tools"
 
+	"go.opentelemetry.io/otel"
 	"go.uber.org/zap"
 )
 
+// tracer спаны методов сервиса, дочерние к спану запроса из ctx.
+var tracer = otel.Tracer("sport-space/internal/core/sportspace")
+
 type storage interface {
+	// Transaction выполняет fn атомарно, методы с ctx из fn работают в транзакции.
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+	// LockTournament, LockApplication блокируют запись до конца транзакции, турнир первым.
+	LockTournament(ctx context.Context, tournamentID uint) error
+	LockApplication(ctx context.Context, applicationID uint) error
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByPhone(ctx context.Context, phone string) (*models.User, error)
 	GetUserByID(ctx context.Context, userID uint) (*models.User, error)
+	UpdUser(ctx context.Context, user *models.User) (*models.User, error)
 	GetAllTournaments(ctx context.Context) (*[]models.Tournament, error)
 	NewUser(ctx context.Context, login, email, passwordHash string) (*models.User, error)
 	NewOTP(ctx context.Context, otp *models.OTPUser) error
@@ -46,20 +58,106 @@ type storage interface {
 	GetApplicationFromTeamTournament(ctx context.Context, tournamentID, teamID uint) (*models.Application, error)
 	GetApplicationByID(ctx context.Context, applicationID uint) (*models.Application, error)
 	GetApplicationsByTeamID(ctx context.Context, teamID uint) (*[]models.Application, error)
+	GetApplicationsByPlayerID(ctx context.Context, playerID uint) (*[]models.Application, error)
 	GetPlayersFromApplication(ctx context.Context, applicationID uint) (*[]models.Player, error)
 	GetApplicationsFromTournament(ctx context.Context, tournamentID uint) (*[]models.Application, error)
 	UpdApplicationTournament(ctx context.Context, application *models.Application) (*models.Application, error)
+	GetActiveApplicationsFromTournament(ctx context.Context, tournamentID uint) (*[]models.Application, error)
+	NewRosterChange(ctx context.Context, change *models.RosterChange) (*models.RosterChange, error)
+	GetRosterChangeByID(ctx context.Context, changeID uint) (*models.RosterChange, error)
+	GetRosterChangesByApplicationID(ctx context.Context, applicationID uint) (*[]models.RosterChange, error)
+	UpdRosterChange(ctx context.Context, change *models.RosterChange, players *[]models.Player) (
+		*models.RosterChange, error,
+	)
+	GetNotificationSettings(ctx context.Context, userID uint) (*models.NotificationSettings, error)
+	SaveNotificationSettings(ctx context.Context, settings *models.NotificationSettings) (
+		*models.NotificationSettings, error,
+	)
+	NewUpload(ctx context.Context, upload *models.Upload) (*models.Upload, error)
+	GetUploadByURL(ctx context.Context, url string) (*models.Upload, error)
+	GetUploadsSize(ctx context.Context, userID uint) (int64, error)
+	GetOrphanUploads(ctx context.Context, before time.Time, limit int) (*[]models.Upload, error)
+	RemoveUpload(ctx context.Context, uploadID uint) error
+	NewUploadSession(ctx context.Context, session *models.UploadSession) (*models.UploadSession, error)
+	GetUploadSession(ctx context.Context, sessionID string) (*models.UploadSession, error)
+	UpdUploadSession(ctx context.Context, session *models.UploadSession, received int64) error
+	RemoveUploadSession(ctx context.Context, sessionID string) error
+	GetExpiredUploadSessions(ctx context.Context, before time.Time, limit int) (*[]models.UploadSession, error)
+	// DelTournament, DelTeam, DelPlayer удаляют запись в корзину с временем at,
+	// турнир и команда - вместе с заявками.
+	DelTournament(ctx context.Context, tournamentID uint, at time.Time) error
+	DelTeam(ctx context.Context, teamID uint, at time.Time) error
+	DelPlayer(ctx context.Context, playerID uint, at time.Time) error
+	GetDeletedTournaments(ctx context.Context, userID uint, after time.Time) (*[]models.Tournament, error)
+	GetDeletedTeams(ctx context.Context, userID uint, after time.Time) (*[]models.Team, error)
+	GetDeletedPlayers(ctx context.Context, userID uint, after time.Time) (*[]models.Player, error)
+	// RestoreTournament, RestoreTeam, RestorePlayer восстанавливают запись пользователя,
+	// удаленную после after, вместе с заявками, удаленными одновременно с ней.
+	RestoreTournament(ctx context.Context, userID, tournamentID uint, after time.Time) error
+	RestoreTeam(ctx context.Context, userID, teamID uint, after time.Time) error
+	RestorePlayer(ctx context.Context, userID, playerID uint, after time.Time) error
+	// PurgeDeleted окончательно удаляет записи, удаленные в корзину до before.
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
+	NewIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error)
+	GetIdempotencyKey(ctx context.Context, userID uint, key string) (*models.IdempotencyKey, error)
+	UpdIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error
+	RemoveIdempotencyKey(ctx context.Context, id uint) error
+	// PurgeIdempotencyKeys удаляет ключи запросов, созданные до before.
+	PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int, error)
+	// GetStats показатели для метрик на момент at.
+	GetStats(ctx context.Context, at time.Time) (*models.Stats, error)
 }
 
 type sender interface {
-	SendCodeToEmail(email string, code string) (bool, error)
+	SendCodeToEmail(ctx context.Context, email, language, code string) (bool, error)
+	AddTemplateMail(ctx context.Context, email, language, template string, data any) error
+}
+
+// otpMetrics учет выдачи и проверки кодов авторизации.
+type otpMetrics interface {
+	ObserveOTPIssue(channel string, err error)
+	ObserveOTPVerify(err error)
+}
+
+// codeChannel доставка кода авторизации по телефону: SMS или Telegram.
+type codeChannel interface {
+	SendCode(ctx context.Context, to, language, code string) error
 }
 
+// названия шаблонов писем в sender.
+const (
+	mailApplicationStatus    = "application_status"
+	mailApplicationSubmitted = "application_submitted"
+	mailApplicationCanceled  = "application_canceled"
+)
+
+// Languages поддерживаемые языки писем, первый используется по умолчанию.
+var Languages = []string{"ru", "en"}
+
+// RosterConflictPolicy поведение при заявке игрока в несколько команд одного турнира.
+type RosterConflictPolicy string
+
+const (
+	RosterConflictBlock RosterConflictPolicy = "block"
+	RosterConflictWarn  RosterConflictPolicy = "warn"
+	RosterConflictAllow RosterConflictPolicy = "allow"
+)
+
 type SportSpace struct {
-	log       *zap.Logger
-	store     storage
-	sender    sender
-	otpLength uint
+	log                    *zap.Logger
+	metrics                otpMetrics
+	store                  storage
+	sender                 sender
+	codeChannels           map[models.OTPChannel]codeChannel
+	blob                   blob
+	uploadQuota            int64
+	uploadSessionTTL       time.Duration
+	trashRetention         time.Duration
+	idempotencyTTL         time.Duration
+	idempotencyLease       time.Duration
+	otpLength              uint
+	rosterConflictPolicy   RosterConflictPolicy
+	lateSubstitutionsLimit uint
 }
 
 type option func(s *SportSpace)
@@ -70,32 +168,137 @@ func SetLogger(l *zap.Logger) option {
 	}
 }
 
+// SetMetrics учитывает в m выданные и проверенные коды авторизации.
+func SetMetrics(m otpMetrics) option {
+	return func(s *SportSpace) {
+		s.metrics = m
+	}
+}
+
+// SetCodeChannel подключает канал доставки кодов, nil - канал выключен.
+func SetCodeChannel(name models.OTPChannel, ch codeChannel) option {
+	return func(s *SportSpace) {
+		if ch != nil {
+			s.codeChannels[name] = ch
+		}
+	}
+}
+
+func SetBlob(b blob) option {
+	return func(s *SportSpace) {
+		s.blob = b
+	}
+}
+
+// SetUploadQuota лимит суммарного размера загрузок пользователя в байтах, 0 - без лимита.
+func SetUploadQuota(q int64) option {
+	return func(s *SportSpace) {
+		s.uploadQuota = q
+	}
+}
+
+// SetUploadSessionTTL сколько живет незавершенная загрузка документа частями.
+func SetUploadSessionTTL(ttl time.Duration) option {
+	return func(s *SportSpace) {
+		s.uploadSessionTTL = ttl
+	}
+}
+
+// SetTrashRetention сколько удаленные записи хранятся в корзине до окончательного удаления.
+func SetTrashRetention(d time.Duration) option {
+	return func(s *SportSpace) {
+		s.trashRetention = d
+	}
+}
+
+// SetIdempotencyTTL сколько хранится ответ на запрос с Idempotency-Key.
+func SetIdempotencyTTL(ttl time.Duration) option {
+	return func(s *SportSpace) {
+		s.idempotencyTTL = ttl
+	}
+}
+
+// SetIdempotencyLease сколько ключ занят выполняющимся запросом, после этого
+// ключ упавшего запроса можно занять повтором.
+func SetIdempotencyLease(lease time.Duration) option {
+	return func(s *SportSpace) {
+		s.idempotencyLease = lease
+	}
+}
+
 func SetOTPLength(l uint) option {
 	return func(s *SportSpace) {
 		s.otpLength = l
 	}
 }
 
+func SetRosterConflictPolicy(p string) option {
+	return func(s *SportSpace) {
+		s.rosterConflictPolicy = RosterConflictPolicy(p)
+	}
+}
+
+func SetLateSubstitutionsLimit(l uint) option {
+	return func(s *SportSpace) {
+		s.lateSubstitutionsLimit = l
+	}
+}
+
 func New(store storage, sender sender, options ...option) (*SportSpace, error) {
 	s := &SportSpace{
-		log:       zap.NewNop(),
-		store:     store,
-		sender:    sender,
-		otpLength: 6,
+		log:                    zap.NewNop(),
+		store:                  store,
+		sender:                 sender,
+		codeChannels:           map[models.OTPChannel]codeChannel{},
+		uploadSessionTTL:       24 * time.Hour,
+		trashRetention:         30 * 24 * time.Hour,
+		idempotencyTTL:         24 * time.Hour,
+		idempotencyLease:       time.Minute,
+		otpLength:              6,
+		rosterConflictPolicy:   RosterConflictBlock,
+		lateSubstitutionsLimit: 3,
 	}
 
 	for _, opt := range options {
 		opt(s)
 	}
 
+	switch s.rosterConflictPolicy {
+	case RosterConflictBlock, RosterConflictWarn, RosterConflictAllow:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrRosterConflictPolicyNotValid, s.rosterConflictPolicy)
+	}
+
 	return s, nil
 }
 
 func (s *SportSpace) LoginWithOTP(ctx context.Context, email, otp string) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.LoginWithOTP")
+	defer span.End()
+
 	user, err := s.store.GetUserByEmail(ctx, email)
 	if err != nil {
 		return nil, fmt.Errorf("failed getting user: %w", err)
 	}
+	return s.loginWithOTP(ctx, user, otp)
+}
+
+// LoginWithPhoneOTP вход по номеру телефона и коду из SMS или Telegram.
+func (s *SportSpace) LoginWithPhoneOTP(ctx context.Context, phone, otp string) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.LoginWithPhoneOTP")
+	defer span.End()
+
+	user, err := s.store.GetUserByPhone(ctx, phone)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting user: %w", err)
+	}
+	return s.loginWithOTP(ctx, user, otp)
+}
+
+func (s *SportSpace) loginWithOTP(ctx context.Context, user *models.User, otp string) (_ *models.User, err error) {
+	if s.metrics != nil {
+		defer func() { s.metrics.ObserveOTPVerify(err) }()
+	}
 
 	otpStored, err := s.store.GetOTP(ctx, user)
 	if err != nil {
@@ -109,13 +312,17 @@ func (s *SportSpace) LoginWithOTP(ctx context.Context, email, otp string) (*mode
 	err = s.store.RemoveOTP(ctx, user)
 	if err != nil {
 		// позволяем пользователю войти, пишем в лог ошибку
-		s.log.Error("failed remove otp", zap.Uint("userID", user.ID), zap.String("email", email), zap.Error(err))
+		s.log.Error("failed remove otp", zap.Uint("userID", user.ID), zap.Error(err))
 	}
 
 	return user, nil
 }
 
-func (s *SportSpace) NewOTP(ctx context.Context, email string) error {
+// NewOTP отправляет код авторизации, новый пользователь создается с языком language.
+func (s *SportSpace) NewOTP(ctx context.Context, email, language string) error {
+	ctx, span := tracer.Start(ctx, "SportSpace.NewOTP")
+	defer span.End()
+
 	user, err := s.store.GetUserByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, errstore.ErrNotFoundData) {
@@ -124,12 +331,108 @@ func (s *SportSpace) NewOTP(ctx context.Context, email string) error {
 			if err != nil {
 				return fmt.Errorf("failed create user: %w", err)
 			}
+			if language != "" && language != user.Language && slices.Contains(Languages, language) {
+				user.Language = language
+				user, err = s.store.UpdUser(ctx, user)
+				if err != nil {
+					return fmt.Errorf("failed set user language: %w", err)
+				}
+			}
 		} else {
 			s.log.Error("getting user", zap.String("email", email))
 			return fmt.Errorf("failed getting user by email: %w", err)
 		}
 	}
 
+	return s.issueOTP(ctx, user)
+}
+
+// NewOTPByPhone отправляет код авторизации по номеру телефона. Новый пользователь регистрируется
+// с каналом channel (по умолчанию SMS), дальше коды приходят в канал, выбранный при регистрации.
+func (s *SportSpace) NewOTPByPhone(ctx context.Context, phone string, channel models.OTPChannel,
+	language string,
+) error {
+	ctx, span := tracer.Start(ctx, "SportSpace.NewOTPByPhone")
+	defer span.End()
+
+	user, err := s.store.GetUserByPhone(ctx, phone)
+	if err != nil {
+		if !errors.Is(err, errstore.ErrNotFoundData) {
+			return fmt.Errorf("failed getting user by phone: %w", err)
+		}
+		if channel == "" {
+			channel = models.ChannelSMS
+		}
+		if channel == models.ChannelEmail || s.codeChannels[channel] == nil {
+			return fmt.Errorf("%w: %s", ErrChannelNotSupported, channel)
+		}
+		user, err = s.newPhoneUser(ctx, phone, channel, language)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.issueOTP(ctx, user)
+}
+
+// LinkTelegram привязывает чат Telegram к пользователю с номером phone, которым поделились в боте.
+// Если пользователя нет, он регистрируется с каналом Telegram.
+func (s *SportSpace) LinkTelegram(ctx context.Context, phone, chatID, language string) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.LinkTelegram")
+	defer span.End()
+
+	user, err := s.store.GetUserByPhone(ctx, phone)
+	if err != nil {
+		if !errors.Is(err, errstore.ErrNotFoundData) {
+			return nil, fmt.Errorf("failed getting user by phone: %w", err)
+		}
+		user, err = s.newPhoneUser(ctx, phone, models.ChannelTelegram, language)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	user.TelegramChatID = chatID
+	user, err = s.store.UpdUser(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed link telegram: %w", err)
+	}
+	return user, nil
+}
+
+func (s *SportSpace) newPhoneUser(ctx context.Context, phone string, channel models.OTPChannel,
+	language string,
+) (*models.User, error) {
+	// задаем хеш пароль пустым что бы нельзя было авторизоваться по постоянному паролю
+	user, err := s.store.NewUser(ctx, phone, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed create user: %w", err)
+	}
+
+	user.Phone = phone
+	user.OTPChannel = channel
+	if slices.Contains(Languages, language) {
+		user.Language = language
+	}
+	user, err = s.store.UpdUser(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed save user phone: %w", err)
+	}
+	return user, nil
+}
+
+// issueOTP сохраняет новый код и отправляет его в канал пользователя.
+func (s *SportSpace) issueOTP(ctx context.Context, user *models.User) (err error) {
+	if s.metrics != nil {
+		defer func() {
+			channel := user.OTPChannel
+			if channel == "" {
+				channel = models.ChannelEmail
+			}
+			s.metrics.ObserveOTPIssue(string(channel), err)
+		}()
+	}
+
 	otpStore, err := s.store.GetOTP(ctx, user)
 	if err != nil {
 		if errors.Is(err, errstore.ErrNotFoundData) {
@@ -146,30 +449,101 @@ func (s *SportSpace) NewOTP(ctx context.Context, email string) error {
 
 	err = s.store.NewOTP(ctx, otpStore)
 	if err != nil {
-		s.log.Error("save otp", zap.Error(err), zap.String("email", email), zap.Uint("userID", user.ID))
+		s.log.Error("save otp", zap.Error(err), zap.Uint("userID", user.ID))
 		return fmt.Errorf("failed save otp: %w", err)
 	}
 
-	_, err = s.sender.SendCodeToEmail(email, otpStore.Password)
+	switch user.OTPChannel {
+	case models.ChannelEmail, "":
+		_, err = s.sender.SendCodeToEmail(ctx, user.Email, user.Language, otpStore.Password)
+		if err != nil {
+			return fmt.Errorf("failed send otp to email `%s`: %w", user.Email, err)
+		}
+	case models.ChannelSMS:
+		err = s.sendCode(ctx, user.OTPChannel, user.Phone, user.Language, otpStore.Password)
+	case models.ChannelTelegram:
+		if user.TelegramChatID == "" {
+			return ErrTelegramNotLinked
+		}
+		err = s.sendCode(ctx, user.OTPChannel, user.TelegramChatID, user.Language, otpStore.Password)
+	default:
+		return fmt.Errorf("%w: %s", ErrChannelNotSupported, user.OTPChannel)
+	}
 	if err != nil {
-		return fmt.Errorf("failed send otp to email `%s`: %w", email, err)
+		return fmt.Errorf("failed send otp to %s: %w", user.OTPChannel, err)
 	}
 
 	return nil
 }
 
+func (s *SportSpace) sendCode(ctx context.Context, channel models.OTPChannel, to, language, code string) error {
+	ch := s.codeChannels[channel]
+	if ch == nil {
+		return fmt.Errorf("%w: %s", ErrChannelNotSupported, channel)
+	}
+	return ch.SendCode(ctx, to, language, code)
+}
+
+// GetStats текущие показатели сервиса для метрик.
+func (s *SportSpace) GetStats(ctx context.Context) (*models.Stats, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.GetStats")
+	defer span.End()
+
+	stats, err := s.store.GetStats(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed get stats: %w", err)
+	}
+	return stats, nil
+}
+
 func (s *SportSpace) GetAllTournaments(ctx context.Context) (*[]models.Tournament, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.GetAllTournaments")
+	defer span.End()
+
 	return s.store.GetAllTournaments(ctx)
 }
 
 func (s *SportSpace) GetUserByID(ctx context.Context, userID uint) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.GetUserByID")
+	defer span.End()
+
 	return s.store.GetUserByID(ctx, userID)
 }
 
+// UpdUserLanguage меняет язык писем пользователя.
+func (s *SportSpace) UpdUserLanguage(ctx context.Context, userID uint, language string) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.UpdUserLanguage")
+	defer span.End()
+
+	if !slices.Contains(Languages, language) {
+		return nil, fmt.Errorf("%w: %s", ErrLanguageNotSupported, language)
+	}
+
+	user, err := s.store.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed get user: %w", err)
+	}
+
+	user.Language = language
+	user, err = s.store.UpdUser(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed update user: %w", err)
+	}
+	return user, nil
+}
+
 func (s *SportSpace) NewTournament(ctx context.Context, tournament *models.Tournament) (
 	*models.Tournament, error,
 ) {
-	tournament, err := s.store.NewTournament(ctx, tournament)
+	ctx, span := tracer.Start(ctx, "SportSpace.NewTournament")
+	defer span.End()
+
+	err := s.checkUploadURLs(ctx, tournament.UserID, []string{tournament.LogoURL})
+	if err != nil {
+		return nil, err
+	}
+
+	tournament, err = s.store.NewTournament(ctx, tournament)
 	if err != nil {
 		return nil, fmt.Errorf("failed create tournament: %w", err)
 	}
@@ -178,10 +552,16 @@ func (s *SportSpace) NewTournament(ctx context.Context, tournament *models.Tourn
 }
 
 func (s *SportSpace) GetTournaments(ctx context.Context, user *models.User) (*[]models.Tournament, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.GetTournaments")
+	defer span.End()
+
 	return s.store.GetTournaments(ctx, user.ID)
 }
 
 func (s *SportSpace) GetTournamentByID(ctx context.Context, tournamentID uint) (*models.Tournament, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.GetTournamentByID")
+	defer span.End()
+
 	tournament, err := s.store.GetTournamentByID(ctx, tournamentID)
 	if err != nil {
 		if errors.Is(err, errstore.ErrNotFoundData) {
@@ -192,8 +572,27 @@ func (s *SportSpace) GetTournamentByID(ctx context.Context, tournamentID uint) (
 	return tournament, nil
 }
 
+// UpdTournament обновляет турнир версии tournament.Version, 0 - текущей версии.
 func (s *SportSpace) UpdTournament(ctx context.Context, tournament *models.Tournament) (*models.Tournament, error) {
-	tournament, err := s.store.UpdTournamentByUser(ctx, tournament)
+	ctx, span := tracer.Start(ctx, "SportSpace.UpdTournament")
+	defer span.End()
+
+	current, err := s.store.GetTournamentByID(ctx, tournament.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed get tournament: %w", err)
+	}
+	if current.UserID != tournament.UserID {
+		return nil, errstore.ErrNotFoundData
+	}
+	if err = matchVersion(&tournament.Version, current.Version); err != nil {
+		return nil, err
+	}
+	err = s.checkUploadURLs(ctx, tournament.UserID, []string{tournament.LogoURL}, current.LogoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	tournament, err = s.store.UpdTournamentByUser(ctx, tournament)
 	if err != nil {
 		return nil, fmt.Errorf("failed update tournament: %w", err)
 	}
@@ -202,7 +601,15 @@ func (s *SportSpace) UpdTournament(ctx context.Context, tournament *models.Tourn
 }
 
 func (s *SportSpace) NewTeam(ctx context.Context, team *models.Team) (*models.Team, error) {
-	team, err := s.store.NewTeam(ctx, team)
+	ctx, span := tracer.Start(ctx, "SportSpace.NewTeam")
+	defer span.End()
+
+	err := s.checkUploadURLs(ctx, team.UserID, []string{team.LogoURL, team.PhotoURL})
+	if err != nil {
+		return nil, err
+	}
+
+	team, err = s.store.NewTeam(ctx, team)
 	if err != nil {
 		return nil, fmt.Errorf("failed create team: %w", err)
 	}
@@ -211,6 +618,9 @@ func (s *SportSpace) NewTeam(ctx context.Context, team *models.Team) (*models.Te
 }
 
 func (s *SportSpace) GetTeams(ctx context.Context, user *models.User) (*[]models.Team, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.GetTeams")
+	defer span.End()
+
 	teams, err := s.store.GetTeams(ctx, user)
 	if err != nil {
 		return nil, fmt.Errorf("failed get teams: %w", err)
@@ -220,6 +630,9 @@ func (s *SportSpace) GetTeams(ctx context.Context, user *models.User) (*[]models
 }
 
 func (s *SportSpace) GetTeamByID(ctx context.Context, teamID uint) (*models.Team, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.GetTeamByID")
+	defer span.End()
+
 	team, err := s.store.GetTeamByID(ctx, teamID)
 	if err != nil {
 		return nil, fmt.Errorf("failed get team: %w", err)
@@ -227,7 +640,23 @@ func (s *SportSpace) GetTeamByID(ctx context.Context, teamID uint) (*models.Team
 	return team, nil
 }
 
+// UpdTeam обновляет команду версии team.Version, 0 - текущей версии.
 func (s *SportSpace) UpdTeam(ctx context.Context, team *models.Team, playersIDs *[]uint) (*models.Team, *[]models.Player, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.UpdTeam")
+	defer span.End()
+
+	current, err := s.store.GetTeamByID(ctx, team.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed get team: %w", err)
+	}
+	if err = matchVersion(&team.Version, current.Version); err != nil {
+		return nil, nil, err
+	}
+	err = s.checkUploadURLs(ctx, team.UserID, []string{team.LogoURL, team.PhotoURL}, current.LogoURL, current.PhotoURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	team, players, err := s.store.UpdTeam(ctx, team, playersIDs)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed update team: %w", err)
@@ -236,10 +665,20 @@ func (s *SportSpace) UpdTeam(ctx context.Context, team *models.Team, playersIDs
 }
 
 func (s *SportSpace) NewPlayer(ctx context.Context, player *models.Player) (*models.Player, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.NewPlayer")
+	defer span.End()
+
+	err := s.checkUploadURLs(ctx, player.UserID, []string{player.PhotoURL, player.MedicalCertificateURL})
+	if err != nil {
+		return nil, err
+	}
 	return s.store.NewPlayer(ctx, player)
 }
 
 func (s *SportSpace) NewPlayerBatch(ctx context.Context, players *[]models.Player) (*[]models.Player, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.NewPlayerBatch")
+	defer span.End()
+
 	ids := []uint{}
 	for _, p := range *players {
 		if p.ID > 0 {
@@ -262,9 +701,21 @@ func (s *SportSpace) NewPlayerBatch(ctx context.Context, players *[]models.Playe
 				if p.UserID != plyr.UserID {
 					return nil, errsport.ErrConflictData
 				}
+				if err = s.checkUploadURLs(ctx, p.UserID, []string{p.PhotoURL, p.MedicalCertificateURL},
+					plyr.PhotoURL, plyr.MedicalCertificateURL); err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
+	for _, p := range *players {
+		if p.ID > 0 {
+			continue
+		}
+		if err := s.checkUploadURLs(ctx, p.UserID, []string{p.PhotoURL, p.MedicalCertificateURL}); err != nil {
+			return nil, err
+		}
+	}
 	res, err := s.store.NewPlayerBatch(ctx, players)
 	if err != nil {
 		return nil, fmt.Errorf("failed create batch players: %w", err)
@@ -274,147 +725,330 @@ func (s *SportSpace) NewPlayerBatch(ctx context.Context, players *[]models.Playe
 }
 
 func (s *SportSpace) GetPlayers(ctx context.Context, userID uint) (*[]models.Player, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.GetPlayers")
+	defer span.End()
+
 	return s.store.GetPlayers(ctx, userID)
 }
 
 func (s *SportSpace) GetPlayerByID(ctx context.Context, playerID uint) (*models.Player, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.GetPlayerByID")
+	defer span.End()
+
 	return s.store.GetPlayerByID(ctx, playerID)
 }
 
+// UpdPlayer обновляет игрока версии player.Version, 0 - текущей версии.
 func (s *SportSpace) UpdPlayer(ctx context.Context, player *models.Player) (*models.Player, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.UpdPlayer")
+	defer span.End()
+
+	current, err := s.store.GetPlayerByID(ctx, player.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed get player: %w", err)
+	}
+	if current.UserID != player.UserID {
+		return nil, errstore.ErrNotFoundData
+	}
+	if err = matchVersion(&player.Version, current.Version); err != nil {
+		return nil, err
+	}
+	err = s.checkUploadURLs(ctx, player.UserID, []string{player.PhotoURL, player.MedicalCertificateURL},
+		current.PhotoURL, current.MedicalCertificateURL)
+	if err != nil {
+		return nil, err
+	}
 	return s.store.UpdPlayer(ctx, player)
 }
 
+// NewApplicationTeam создает заявку в транзакции под блокировкой турнира: параллельные
+// заявки не пропускают проверку существующей заявки и пересечений составов. Вместе с заявкой
+// возвращаются пересечения составов, допущенные политикой warn.
 func (s *SportSpace) NewApplicationTeam(ctx context.Context, playerIDs *[]uint, tournamentID, teamID, userID uint) (
-	*models.Application, *[]models.Player, error,
+	*models.Application, *[]models.Player, []errsport.RosterConflict, error,
 ) {
-	team, err := s.store.GetTeamByID(ctx, teamID)
-	if err != nil {
-		if errors.Is(err, errstore.ErrNotFoundData) {
-			return nil, nil, fmt.Errorf("not found team: %w", err)
+	ctx, span := tracer.Start(ctx, "SportSpace.NewApplicationTeam")
+	defer span.End()
+
+	var application *models.Application
+	var players *[]models.Player
+	var warnings []errsport.RosterConflict
+	err := s.store.Transaction(ctx, func(ctx context.Context) error {
+		team, err := s.store.GetTeamByID(ctx, teamID)
+		if err != nil {
+			if errors.Is(err, errstore.ErrNotFoundData) {
+				return fmt.Errorf("not found team: %w", err)
+			}
+			return fmt.Errorf("failed get team: %w", err)
 		}
-		return nil, nil, fmt.Errorf("failed get team: %w", err)
-	}
 
-	if team.UserID != userID {
-		return nil, nil, fmt.Errorf("not found team: %w", errstore.ErrNotFoundData)
-	}
+		if team.UserID != userID {
+			return fmt.Errorf("not found team: %w", errstore.ErrNotFoundData)
+		}
 
-	tournament, err := s.store.GetTournamentByID(ctx, tournamentID)
-	if err != nil {
-		if errors.Is(err, errstore.ErrNotFoundData) {
-			return nil, nil, fmt.Errorf("not found tournament: %w", err)
+		tournament, err := s.store.GetTournamentByID(ctx, tournamentID)
+		if err != nil {
+			if errors.Is(err, errstore.ErrNotFoundData) {
+				return fmt.Errorf("not found tournament: %w", err)
+			}
+			return fmt.Errorf("failed get tournament: %w", err)
+		}
+		if err = s.store.LockTournament(ctx, tournament.ID); err != nil {
+			return err
 		}
-		return nil, nil, fmt.Errorf("failed get tournament: %w", err)
-	}
 
-	application, err := s.store.GetApplicationFromTeamTournament(ctx, tournament.ID, team.ID)
-	if err != nil && !errors.Is(err, errstore.ErrNotFoundData) {
-		return nil, nil, fmt.Errorf("failed get application form team and tournament: %w", err)
-	}
-	if application != nil && application.ID != 0 {
-		return nil, nil, errstore.ErrConflictData
-	}
+		application, err = s.store.GetApplicationFromTeamTournament(ctx, tournament.ID, team.ID)
+		if err != nil && !errors.Is(err, errstore.ErrNotFoundData) {
+			return fmt.Errorf("failed get application form team and tournament: %w", err)
+		}
+		if application != nil && application.ID != 0 {
+			return errstore.ErrConflictData
+		}
 
-	players, err := s.store.GetPlayersFromTeam(ctx, team.ID)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed find players: %w", err)
-	}
+		players, err = s.store.GetPlayersFromTeam(ctx, team.ID)
+		if err != nil {
+			return fmt.Errorf("failed find players: %w", err)
+		}
 
-	applicationPlayers := []models.Player{}
-	for _, player := range *players {
-		for _, id := range *playerIDs {
-			if id == player.ID {
-				applicationPlayers = append(applicationPlayers, player)
+		applicationPlayers := []models.Player{}
+		for _, player := range *players {
+			for _, id := range *playerIDs {
+				if id == player.ID {
+					applicationPlayers = append(applicationPlayers, player)
+				}
 			}
 		}
-	}
 
-	application, players, err = s.store.NewApplication(ctx,
-		&models.Application{TeamID: team.ID, TournamentID: tournament.ID, Status: models.Draft},
-		&applicationPlayers,
-	)
+		warnings, err = s.checkRosterConflicts(ctx, tournament.ID, 0, applicationPlayers)
+		if err != nil {
+			return err
+		}
+
+		application, players, err = s.store.NewApplication(ctx,
+			&models.Application{TeamID: team.ID, TournamentID: tournament.ID, Status: models.Draft},
+			&applicationPlayers,
+		)
+		if err != nil {
+			return fmt.Errorf("failed create application: %w", err)
+		}
+		application.TournamentID = tournament.ID
+		application.TeamID = team.ID
+
+		return nil
+	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed create application: %w", err)
+		return nil, nil, nil, err
 	}
-	application.TournamentID = tournament.ID
-	application.TeamID = team.ID
 
-	return application, players, nil
+	return application, players, warnings, nil
 }
 
-func (s *SportSpace) UpdApplicationTeam(ctx context.Context, applicationID uint, playerIDs *[]uint, status models.ApplicationStatus, teamID uint, userID uint) (
-	*models.Application, *[]models.Player, error,
+// UpdApplicationTeam меняет статус и состав заявки версии version (0 - текущей) в транзакции
+// под блокировкой турнира и заявки, письмо организатору отправляется после фиксации изменений.
+// Пересечения составов, допущенные политикой warn, возвращаются вместе с заявкой.
+func (s *SportSpace) UpdApplicationTeam(ctx context.Context, applicationID, version uint, playerIDs *[]uint, status models.ApplicationStatus, teamID uint, userID uint) (
+	*models.Application, *[]models.Player, []errsport.RosterConflict, error,
 ) {
-	team, err := s.store.GetTeamByID(ctx, teamID)
-	if err != nil {
-		if errors.Is(err, errstore.ErrNotFoundData) {
-			return nil, nil, fmt.Errorf("not found team: %w", err)
+	ctx, span := tracer.Start(ctx, "SportSpace.UpdApplicationTeam")
+	defer span.End()
+
+	var application *models.Application
+	var players *[]models.Player
+	var team *models.Team
+	var t *models.Tournament
+	var prevStatus models.ApplicationStatus
+	var warnings []errsport.RosterConflict
+	err := s.store.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		team, err = s.store.GetTeamByID(ctx, teamID)
+		if err != nil {
+			if errors.Is(err, errstore.ErrNotFoundData) {
+				return fmt.Errorf("not found team: %w", err)
+			}
+			return fmt.Errorf("failed get team: %w", err)
 		}
-		return nil, nil, fmt.Errorf("failed get team: %w", err)
+
+		if team.UserID != userID {
+			return fmt.Errorf("not found team: %w", errstore.ErrNotFoundData)
+		}
+
+		application, err = s.lockApplication(ctx, applicationID)
+		if err != nil {
+			return err
+		}
+		if err = matchVersion(&version, application.Version); err != nil {
+			return err
+		}
+
+		t, err = s.store.GetTournamentByID(ctx, application.TournamentID)
+		if err != nil {
+			if errors.Is(err, errstore.ErrNotFoundData) {
+				return fmt.Errorf("not found tournament: %w", err)
+			}
+			return fmt.Errorf("failed get tournament: %w", err)
+		}
+
+		isOpenRegistration := time.Now().After(*t.RegisterStartDate) && time.Now().Before(*t.RegisterEndDate)
+
+		if application.Status == models.Accepted && playerIDs != nil && isRosterFrozen(t) {
+			return errsport.ErrRosterFrozen
+		}
+
+		if !((application.Status == models.Draft && (status == models.Draft || status == models.InProgress)) ||
+			(application.Status == models.Accepted && status == "" && playerIDs != nil) ||
+			(application.Status == models.Canceled && (status == models.Draft || status == models.InProgress)) ||
+			(application.Status == models.InProgress && status == models.Canceled) ||
+			(application.Status == models.Accepted && status == models.Canceled && isOpenRegistration) ||
+			(application.Status == models.Rejected && status == models.Canceled)) {
+			return errstore.ErrForbidden
+		}
+
+		players, err = s.store.GetPlayersFromTeam(ctx, team.ID)
+		if err != nil {
+			return fmt.Errorf("failed find players: %w", err)
+		}
+
+		var applicationPlayers []models.Player
+		if playerIDs != nil {
+			applicationPlayers = []models.Player{}
+			for _, player := range *players {
+				for _, id := range *playerIDs {
+					if id == player.ID {
+						applicationPlayers = append(applicationPlayers, player)
+					}
+				}
+			}
+			players = &applicationPlayers
+		}
+
+		nextStatus := application.Status
+		if status != "" {
+			nextStatus = status
+		}
+		if nextStatus != models.Canceled {
+			rosterPlayers := application.Players
+			if playerIDs != nil {
+				rosterPlayers = applicationPlayers
+			}
+			warnings, err = s.checkRosterConflicts(ctx, application.TournamentID, application.ID, rosterPlayers)
+			if err != nil {
+				return err
+			}
+		}
+
+		prevStatus = application.Status
+		if status != "" {
+			application.Status = status
+			application.StatusDate = time.Now()
+		}
+		application, players, err = s.store.UpdApplication(ctx, application, &applicationPlayers)
+		if err != nil {
+			return fmt.Errorf("failed create application: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	if team.UserID != userID {
-		return nil, nil, fmt.Errorf("not found team: %w", errstore.ErrNotFoundData)
+	if (status == models.InProgress && prevStatus != models.InProgress) ||
+		(status == models.Canceled && prevStatus != models.Draft && prevStatus != models.Canceled) {
+		s.notifyApplicationOrganizer(ctx, application, t, team)
 	}
 
+	return application, players, warnings, nil
+}
+
+// lockApplication блокирует турнир и заявку в этом порядке и перечитывает заявку
+// под блокировкой. Вызывается внутри Transaction.
+func (s *SportSpace) lockApplication(ctx context.Context, applicationID uint) (*models.Application, error) {
 	application, err := s.store.GetApplicationByID(ctx, applicationID)
-	if err != nil && !errors.Is(err, errstore.ErrNotFoundData) {
-		return nil, nil, fmt.Errorf("failed get application form team and tournament: %w", err)
+	if err != nil {
+		if errors.Is(err, errstore.ErrNotFoundData) {
+			return nil, fmt.Errorf("not found application: %w", err)
+		}
+		return nil, fmt.Errorf("failed get application: %w", err)
 	}
-	if application.ID == 0 {
-		return nil, nil, errstore.ErrNotFoundData
+	if err = s.store.LockTournament(ctx, application.TournamentID); err != nil {
+		return nil, err
+	}
+	if err = s.store.LockApplication(ctx, application.ID); err != nil {
+		return nil, err
 	}
 
-	t, err := s.store.GetTournamentByID(ctx, application.TournamentID)
+	application, err = s.store.GetApplicationByID(ctx, applicationID)
 	if err != nil {
-		if errors.Is(err, errstore.ErrNotFoundData) {
-			return nil, nil, fmt.Errorf("not found tournament: %w", err)
-		}
-		return nil, nil, fmt.Errorf("failed get tournament: %w", err)
+		return nil, fmt.Errorf("failed get application: %w", err)
 	}
+	return application, nil
+}
 
-	isOpenRegistration := time.Now().After(*t.RegisterStartDate) && time.Now().Before(*t.RegisterEndDate)
+// matchVersion проверяет, что запись не менялась с версии version, которую видел клиент.
+// 0 - любая версия, тогда version становится текущей.
+func matchVersion(version *uint, current uint) error {
+	if *version == 0 {
+		*version = current
+		return nil
+	}
+	if *version != current {
+		return errstore.ErrVersionConflict
+	}
+	return nil
+}
 
-	if !((application.Status == models.Draft && (status == models.Draft || status == models.InProgress)) ||
-		(application.Status == models.Canceled && (status == models.Draft || status == models.InProgress)) ||
-		(application.Status == models.InProgress && status == models.Canceled) ||
-		(application.Status == models.Accepted && status == models.Canceled && isOpenRegistration) ||
-		(application.Status == models.Rejected && status == models.Canceled)) {
-		return nil, nil, errstore.ErrForbidden
+// checkRosterConflicts проверяет, что игроки не заявлены в другие (не отмененные) заявки турнира.
+// При политике warn пересечения не блокируют изменение и возвращаются как предупреждения.
+func (s *SportSpace) checkRosterConflicts(ctx context.Context, tournamentID, applicationID uint, players []models.Player) (
+	[]errsport.RosterConflict, error,
+) {
+	if s.rosterConflictPolicy == RosterConflictAllow || len(players) == 0 {
+		return nil, nil
 	}
 
-	players, err := s.store.GetPlayersFromTeam(ctx, team.ID)
+	applications, err := s.store.GetActiveApplicationsFromTournament(ctx, tournamentID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed find players: %w", err)
+		if errors.Is(err, errstore.ErrNotFoundData) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed get applications from tournament: %w", err)
 	}
 
-	var applicationPlayers []models.Player
-	if playerIDs != nil {
-		applicationPlayers = []models.Player{}
-		for _, player := range *players {
-			for _, id := range *playerIDs {
-				if id == player.ID {
-					applicationPlayers = append(applicationPlayers, player)
+	conflicts := []errsport.RosterConflict{}
+	for _, a := range *applications {
+		if a.ID == applicationID {
+			continue
+		}
+		for _, applicationPlayer := range a.Players {
+			for _, player := range players {
+				if player.ID == applicationPlayer.ID {
+					conflicts = append(conflicts, errsport.RosterConflict{
+						PlayerID:      player.ID,
+						ApplicationID: a.ID,
+						TeamID:        a.TeamID,
+					})
 				}
 			}
 		}
-		players = &applicationPlayers
 	}
 
-	if status != "" {
-		application.Status = status
-		application.StatusDate = time.Now()
+	if len(conflicts) == 0 {
+		return nil, nil
 	}
-	application, players, err = s.store.UpdApplication(ctx, application, &applicationPlayers)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed create application: %w", err)
+
+	conflictErr := &errsport.RosterConflictError{Conflicts: conflicts}
+	if s.rosterConflictPolicy == RosterConflictWarn {
+		s.log.Warn("roster conflict", zap.Uint("tournamentID", tournamentID),
+			zap.Uint("applicationID", applicationID), zap.Error(conflictErr))
+		return conflicts, nil
 	}
 
-	return application, players, nil
+	return nil, conflictErr
 }
 
 func (s *SportSpace) GetApplicationsTeam(ctx context.Context, teamID uint) (*[]models.Application, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.GetApplicationsTeam")
+	defer span.End()
+
 	applications, err := s.store.GetApplicationsByTeamID(ctx, teamID)
 	if err != nil {
 		return nil, fmt.Errorf("failed get applications: %w", err)
@@ -423,6 +1057,9 @@ func (s *SportSpace) GetApplicationsTeam(ctx context.Context, teamID uint) (*[]m
 	return applications, nil
 }
 func (s *SportSpace) GetApplicationByID(ctx context.Context, applicationID uint) (*models.Application, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.GetApplicationByID")
+	defer span.End()
+
 	application, err := s.store.GetApplicationByID(ctx, applicationID)
 	if err != nil {
 		return nil, fmt.Errorf("failed get application: %w", err)
@@ -431,6 +1068,9 @@ func (s *SportSpace) GetApplicationByID(ctx context.Context, applicationID uint)
 }
 
 func (s *SportSpace) GetPlayersFromApplication(ctx context.Context, applicationID uint) (*[]models.Player, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.GetPlayersFromApplication")
+	defer span.End()
+
 	players, err := s.store.GetPlayersFromApplication(ctx, applicationID)
 	if err != nil {
 		return nil, fmt.Errorf("failed get players from application: %w", err)
@@ -439,6 +1079,9 @@ func (s *SportSpace) GetPlayersFromApplication(ctx context.Context, applicationI
 }
 
 func (s *SportSpace) GetApplicationsFromTournament(ctx context.Context, tournamentID uint) (*[]models.Application, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.GetApplicationsFromTournament")
+	defer span.End()
+
 	applications, err := s.store.GetApplicationsFromTournament(ctx, tournamentID)
 	if err != nil {
 		return nil, fmt.Errorf("failed get applications: %w", err)
@@ -447,35 +1090,368 @@ func (s *SportSpace) GetApplicationsFromTournament(ctx context.Context, tourname
 	return applications, nil
 }
 
-func (s *SportSpace) UpdApplicationTournament(ctx context.Context, applicationID uint, status models.ApplicationStatus, tournamentID uint, userID uint) (
+// UpdApplicationTournament решение организатора по заявке версии version (0 - текущей). Статус
+// проверяется под блокировкой заявки, письмо команде отправляется после фиксации изменений.
+func (s *SportSpace) UpdApplicationTournament(ctx context.Context, applicationID, version uint, status models.ApplicationStatus, tournamentID uint, userID uint) (
 	*models.Application, error,
 ) {
-	tournament, err := s.store.GetTournamentByID(ctx, tournamentID)
+	ctx, span := tracer.Start(ctx, "SportSpace.UpdApplicationTournament")
+	defer span.End()
+
+	var application *models.Application
+	var tournament *models.Tournament
+	err := s.store.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		tournament, err = s.store.GetTournamentByID(ctx, tournamentID)
+		if err != nil {
+			if errors.Is(err, errstore.ErrNotFoundData) {
+				return fmt.Errorf("not found tournament: %w", err)
+			}
+			return fmt.Errorf("failed get tournament: %w", err)
+		}
+
+		application, err = s.lockApplication(ctx, applicationID)
+		if err != nil {
+			return err
+		}
+
+		if application.TournamentID != tournament.ID {
+			return fmt.Errorf("not found application: %w", errstore.ErrNotFoundData)
+		}
+		if err = matchVersion(&version, application.Version); err != nil {
+			return err
+		}
+
+		if application.Status != models.InProgress || time.Now().Before(*tournament.StartDate) {
+			return errstore.ErrForbidden
+		}
+
+		application.Status = status
+		application.StatusDate = time.Now()
+
+		application, err = s.store.UpdApplicationTournament(ctx, application)
+		if err != nil {
+			return fmt.Errorf("failed update application: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		if errors.Is(err, errstore.ErrNotFoundData) {
-			return nil, fmt.Errorf("not found tournament: %w", err)
+		return nil, err
+	}
+
+	s.notifyApplicationStatus(ctx, application, tournament)
+
+	return application, nil
+}
+
+// isRosterFrozen состав заявок турнира заморожен, изменения только через запросы на замену.
+func isRosterFrozen(t *models.Tournament) bool {
+	freezeDate := t.RosterFreezeDate
+	if freezeDate == nil {
+		freezeDate = t.RegisterEndDate
+	}
+	return freezeDate != nil && time.Now().After(*freezeDate)
+}
+
+func (s *SportSpace) getLateSubstitutionsLimit(t *models.Tournament) uint {
+	if t.MaxLateSubstitutions != nil {
+		return *t.MaxLateSubstitutions
+	}
+	return s.lateSubstitutionsLimit
+}
+
+func hasPlayer(players []models.Player, playerID uint) bool {
+	for _, p := range players {
+		if p.ID == playerID {
+			return true
 		}
-		return nil, fmt.Errorf("failed get tournament: %w", err)
 	}
+	return false
+}
 
-	application, err := s.store.GetApplicationByID(ctx, applicationID)
+// NewRosterChange запрос на замену игрока. Лимит замен и пересечения составов
+// проверяются под блокировкой турнира и заявки, пересечения при политике warn возвращаются.
+func (s *SportSpace) NewRosterChange(ctx context.Context, change *models.RosterChange, teamID, userID uint) (
+	*models.RosterChange, []errsport.RosterConflict, error,
+) {
+	ctx, span := tracer.Start(ctx, "SportSpace.NewRosterChange")
+	defer span.End()
+
+	var warnings []errsport.RosterConflict
+	err := s.store.Transaction(ctx, func(ctx context.Context) error {
+		team, err := s.store.GetTeamByID(ctx, teamID)
+		if err != nil {
+			return fmt.Errorf("failed get team: %w", err)
+		}
+		if team.UserID != userID {
+			return fmt.Errorf("not found team: %w", errstore.ErrNotFoundData)
+		}
+
+		application, err := s.lockApplication(ctx, change.ApplicationID)
+		if err != nil {
+			return err
+		}
+		if application.TeamID != team.ID {
+			return fmt.Errorf("not found application: %w", errstore.ErrNotFoundData)
+		}
+		if application.Status != models.Accepted {
+			return errstore.ErrForbidden
+		}
+
+		tournament, err := s.store.GetTournamentByID(ctx, application.TournamentID)
+		if err != nil {
+			return fmt.Errorf("failed get tournament: %w", err)
+		}
+		if !isRosterFrozen(tournament) {
+			return errsport.ErrRosterNotFrozen
+		}
+		if time.Now().After(*tournament.EndDate) {
+			return errstore.ErrForbidden
+		}
+
+		if !hasPlayer(application.Players, change.PlayerOutID) || hasPlayer(application.Players, change.PlayerInID) {
+			return errsport.ErrConflictData
+		}
+		if !hasPlayer(team.Players, change.PlayerInID) {
+			return fmt.Errorf("not found player in team: %w", errstore.ErrNotFoundData)
+		}
+
+		changes, err := s.store.GetRosterChangesByApplicationID(ctx, application.ID)
+		if err != nil {
+			return fmt.Errorf("failed get roster changes: %w", err)
+		}
+		var used uint
+		for _, c := range *changes {
+			if c.Status == models.RosterChangePending || c.Status == models.RosterChangeApproved {
+				used++
+			}
+		}
+		if used >= s.getLateSubstitutionsLimit(tournament) {
+			return errsport.ErrSubstitutionLimit
+		}
+
+		playerIn := []models.Player{{ID: change.PlayerInID}}
+		warnings, err = s.checkRosterConflicts(ctx, tournament.ID, application.ID, playerIn)
+		if err != nil {
+			return err
+		}
+
+		change.Status = models.RosterChangePending
+		change.StatusDate = time.Now()
+		change, err = s.store.NewRosterChange(ctx, change)
+		if err != nil {
+			return fmt.Errorf("failed create roster change: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return change, warnings, nil
+}
+
+func (s *SportSpace) GetRosterChanges(ctx context.Context, applicationID uint) (*[]models.RosterChange, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.GetRosterChanges")
+	defer span.End()
+
+	changes, err := s.store.GetRosterChangesByApplicationID(ctx, applicationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed get roster changes: %w", err)
+	}
+	return changes, nil
+}
+
+// UpdRosterChange решение организатора по замене, состав заявки меняется под
+// блокировкой турнира и заявки. Пересечения при политике warn возвращаются вместе с заменой.
+func (s *SportSpace) UpdRosterChange(ctx context.Context, changeID, applicationID, tournamentID, userID uint,
+	status models.RosterChangeStatus,
+) (*models.RosterChange, []errsport.RosterConflict, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.UpdRosterChange")
+	defer span.End()
+
+	if status != models.RosterChangeApproved && status != models.RosterChangeDeclined {
+		return nil, nil, errstore.ErrForbidden
+	}
+
+	var change *models.RosterChange
+	var warnings []errsport.RosterConflict
+	err := s.store.Transaction(ctx, func(ctx context.Context) error {
+		tournament, err := s.store.GetTournamentByID(ctx, tournamentID)
+		if err != nil {
+			return fmt.Errorf("failed get tournament: %w", err)
+		}
+		if tournament.UserID != userID {
+			return fmt.Errorf("not found tournament: %w", errstore.ErrNotFoundData)
+		}
+
+		application, err := s.lockApplication(ctx, applicationID)
+		if err != nil {
+			return err
+		}
+		if application.TournamentID != tournament.ID {
+			return fmt.Errorf("not found application: %w", errstore.ErrNotFoundData)
+		}
+
+		change, err = s.store.GetRosterChangeByID(ctx, changeID)
+		if err != nil {
+			return fmt.Errorf("failed get roster change: %w", err)
+		}
+		if change.ApplicationID != application.ID {
+			return fmt.Errorf("not found roster change: %w", errstore.ErrNotFoundData)
+		}
+		if change.Status != models.RosterChangePending || application.Status != models.Accepted {
+			return errstore.ErrForbidden
+		}
+
+		var players *[]models.Player
+		if status == models.RosterChangeApproved {
+			if !hasPlayer(application.Players, change.PlayerOutID) || hasPlayer(application.Players, change.PlayerInID) {
+				return errsport.ErrConflictData
+			}
+			warnings, err = s.checkRosterConflicts(ctx, tournament.ID, application.ID, []models.Player{{ID: change.PlayerInID}})
+			if err != nil {
+				return err
+			}
+
+			roster := []models.Player{{ID: change.PlayerInID}}
+			for _, p := range application.Players {
+				if p.ID != change.PlayerOutID {
+					roster = append(roster, p)
+				}
+			}
+			players = &roster
+		}
+
+		change.Status = status
+		change.StatusDate = time.Now()
+		change, err = s.store.UpdRosterChange(ctx, change, players)
+		if err != nil {
+			return fmt.Errorf("failed update roster change: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return change, warnings, nil
+}
+
+func defaultNotificationSettings(userID uint) *models.NotificationSettings {
+	return &models.NotificationSettings{
+		UserID:               userID,
+		ApplicationStatus:    true,
+		ApplicationSubmitted: true,
+		ApplicationCanceled:  true,
+	}
+}
+
+func (s *SportSpace) GetNotificationSettings(ctx context.Context, userID uint) (*models.NotificationSettings, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.GetNotificationSettings")
+	defer span.End()
+
+	settings, err := s.store.GetNotificationSettings(ctx, userID)
 	if err != nil {
 		if errors.Is(err, errstore.ErrNotFoundData) {
-			return nil, fmt.Errorf("not found application: %w", err)
+			return defaultNotificationSettings(userID), nil
 		}
-		return nil, fmt.Errorf("failed get application: %w", err)
+		return nil, fmt.Errorf("failed get notification settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (s *SportSpace) UpdNotificationSettings(ctx context.Context, settings *models.NotificationSettings) (
+	*models.NotificationSettings, error,
+) {
+	ctx, span := tracer.Start(ctx, "SportSpace.UpdNotificationSettings")
+	defer span.End()
+
+	settings, err := s.store.SaveNotificationSettings(ctx, settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed save notification settings: %w", err)
+	}
+	return settings, nil
+}
+
+// notificationRecipient возвращает пользователя и его настройки уведомлений,
+// ошибки только пишутся в лог - уведомления не должны ломать основную операцию.
+func (s *SportSpace) notificationRecipient(ctx context.Context, userID uint) (
+	*models.User, *models.NotificationSettings, bool,
+) {
+	user, err := s.store.GetUserByID(ctx, userID)
+	if err != nil {
+		s.log.Error("failed get notification recipient", zap.Uint("userID", userID), zap.Error(err))
+		return nil, nil, false
+	}
+	if user.Email == "" {
+		return nil, nil, false
 	}
 
-	if application.TournamentID != tournament.ID {
-		return nil, fmt.Errorf("not found application: %w", err)
+	settings, err := s.GetNotificationSettings(ctx, userID)
+	if err != nil {
+		s.log.Error("failed get notification settings", zap.Uint("userID", userID), zap.Error(err))
+		return nil, nil, false
 	}
 
-	if application.Status != models.InProgress || time.Now().Before(*tournament.StartDate) {
-		return nil, errstore.ErrForbidden
+	return user, settings, true
+}
+
+// notifyApplicationStatus уведомляет руководителя команды о решении организатора по заявке.
+func (s *SportSpace) notifyApplicationStatus(ctx context.Context, application *models.Application,
+	tournament *models.Tournament,
+) {
+	team, err := s.store.GetTeamByID(ctx, application.TeamID)
+	if err != nil {
+		s.log.Error("failed get team for notification", zap.Uint("teamID", application.TeamID), zap.Error(err))
+		return
 	}
 
-	application.Status = status
-	application.StatusDate = time.Now()
+	user, settings, ok := s.notificationRecipient(ctx, team.UserID)
+	if !ok || !settings.ApplicationStatus {
+		return
+	}
 
-	return s.store.UpdApplicationTournament(ctx, application)
+	err = s.sender.AddTemplateMail(ctx, user.Email, user.Language, mailApplicationStatus, map[string]any{
+		"TeamTitle":       team.Title,
+		"TournamentTitle": tournament.Title,
+		"Status":          string(application.Status),
+	})
+	if err != nil {
+		s.log.Error("failed notify application status", zap.Uint("applicationID", application.ID), zap.Error(err))
+	}
+}
+
+// notifyApplicationOrganizer уведомляет организатора турнира о подаче или отмене заявки.
+func (s *SportSpace) notifyApplicationOrganizer(ctx context.Context, application *models.Application,
+	tournament *models.Tournament, team *models.Team,
+) {
+	user, settings, ok := s.notificationRecipient(ctx, tournament.UserID)
+	if !ok {
+		return
+	}
+
+	var template string
+	switch application.Status {
+	case models.InProgress:
+		if settings.ApplicationSubmitted {
+			template = mailApplicationSubmitted
+		}
+	case models.Canceled:
+		if settings.ApplicationCanceled {
+			template = mailApplicationCanceled
+		}
+	default:
+	}
+	if template == "" {
+		return
+	}
+
+	err := s.sender.AddTemplateMail(ctx, user.Email, user.Language, template, map[string]any{
+		"TeamTitle":       team.Title,
+		"TournamentTitle": tournament.Title,
+	})
+	if err != nil {
+		s.log.Error("failed notify application organizer", zap.Uint("applicationID", application.ID), zap.Error(err))
+	}
 }
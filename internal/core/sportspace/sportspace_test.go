@@ -0,0 +1,410 @@
+package sportspace
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"sport-space/internal/adapter/errsport"
+	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/storage/errstore"
+	"sport-space/internal/adapter/storage/memory"
+)
+
+func must[T any](v T, err error) func(t *testing.T) T {
+	return func(t *testing.T) T {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return v
+	}
+}
+
+func date(days int) *time.Time {
+	d := time.Now().AddDate(0, 0, days)
+	return &d
+}
+
+// fixture турнир и команда с игроками одного пользователя.
+type fixture struct {
+	store      *memory.Storage
+	ss         *SportSpace
+	user       *models.User
+	tournament *models.Tournament
+	players    []models.Player
+}
+
+func newFixture(t *testing.T, tournament *models.Tournament, options ...option) *fixture {
+	t.Helper()
+	ctx := context.Background()
+	store := memory.New()
+	ss := must(New(store, nil, options...))(t)
+	user := must(store.NewUser(ctx, "", "captain@test.ru", ""))(t)
+
+	if tournament == nil {
+		tournament = &models.Tournament{}
+	}
+	tournament.UserID = user.ID
+	tournament.Title = "cup"
+	for field, def := range map[**time.Time]*time.Time{
+		&tournament.StartDate:         date(10),
+		&tournament.EndDate:           date(20),
+		&tournament.RegisterStartDate: date(-1),
+		&tournament.RegisterEndDate:   date(5),
+	} {
+		if *field == nil {
+			*field = def
+		}
+	}
+
+	f := &fixture{
+		store:      store,
+		ss:         ss,
+		user:       user,
+		tournament: must(store.NewTournament(ctx, tournament))(t),
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		p := must(store.NewPlayer(ctx, &models.Player{UserID: user.ID, FirstName: name, LastName: name}))(t)
+		f.players = append(f.players, *p)
+	}
+	return f
+}
+
+// newTeam команда пользователя из игроков fixture с номерами idx.
+func (f *fixture) newTeam(t *testing.T, title string, idx ...int) *models.Team {
+	t.Helper()
+	ctx := context.Background()
+	team := must(f.store.NewTeam(ctx, &models.Team{UserID: f.user.ID, Title: title}))(t)
+	ids := []uint{}
+	for _, i := range idx {
+		ids = append(ids, f.players[i].ID)
+	}
+	team, _, err := f.store.UpdTeam(ctx, team, &ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return must(f.store.GetTeamByID(ctx, team.ID))(t)
+}
+
+func (f *fixture) ids(idx ...int) *[]uint {
+	ids := []uint{}
+	for _, i := range idx {
+		ids = append(ids, f.players[i].ID)
+	}
+	return &ids
+}
+
+// accept переводит заявку в Accepted в обход проверок сервиса.
+func (f *fixture) accept(t *testing.T, application *models.Application) {
+	t.Helper()
+	_, _, err := f.store.UpdApplication(context.Background(), &models.Application{
+		ID:      application.ID,
+		Version: application.Version,
+		Status:  models.Accepted,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRosterConflictPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	cases := []struct {
+		policy   string
+		err      error
+		warnings int
+	}{
+		{"block", errsport.ErrRosterConflict, 0},
+		{"warn", nil, 1},
+		{"allow", nil, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.policy, func(t *testing.T) {
+			f := newFixture(t, nil, SetRosterConflictPolicy(c.policy))
+			first := f.newTeam(t, "first", 0, 1)
+			second := f.newTeam(t, "second", 0, 2)
+
+			_, _, warnings, err := f.ss.NewApplicationTeam(ctx, f.ids(0, 1), f.tournament.ID, first.ID, f.user.ID)
+			if err != nil || len(warnings) != 0 {
+				t.Fatalf("first application: %v, warnings %v", err, warnings)
+			}
+
+			application, _, warnings, err := f.ss.NewApplicationTeam(ctx, f.ids(0, 2), f.tournament.ID, second.ID, f.user.ID)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected %v, got %v", c.err, err)
+			}
+			if len(warnings) != c.warnings {
+				t.Fatalf("expected %d warnings, got %v", c.warnings, warnings)
+			}
+			if c.err != nil {
+				var conflictErr *errsport.RosterConflictError
+				if !errors.As(err, &conflictErr) || len(conflictErr.Conflicts) != 1 ||
+					conflictErr.Conflicts[0].PlayerID != f.players[0].ID {
+					t.Fatalf("unexpected conflicts %v", err)
+				}
+				return
+			}
+			if application == nil || application.ID == 0 {
+				t.Fatal("application is not created")
+			}
+			if c.warnings > 0 && warnings[0].PlayerID != f.players[0].ID {
+				t.Fatalf("unexpected warning %+v", warnings[0])
+			}
+		})
+	}
+}
+
+func TestNewPolicyNotValid(t *testing.T) {
+	_, err := New(memory.New(), nil, SetRosterConflictPolicy("ignore"))
+	if !errors.Is(err, ErrRosterConflictPolicyNotValid) {
+		t.Fatalf("expected ErrRosterConflictPolicyNotValid, got %v", err)
+	}
+}
+
+func TestRosterFreeze(t *testing.T) {
+	ctx := context.Background()
+	limit := uint(1)
+
+	cases := []struct {
+		name       string
+		tournament models.Tournament
+		frozen     bool
+	}{
+		// без даты заморозки состав замораживается с окончанием регистрации
+		{"register open", models.Tournament{}, false},
+		{"register closed", models.Tournament{RegisterEndDate: date(-1)}, true},
+		{"freeze date ahead", models.Tournament{RegisterEndDate: date(-1), RosterFreezeDate: date(1)}, false},
+		{"freeze date passed", models.Tournament{RosterFreezeDate: date(-1)}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tournament := c.tournament
+			tournament.MaxLateSubstitutions = &limit
+			f := newFixture(t, &tournament)
+			team := f.newTeam(t, "team", 0, 1, 2)
+			application, _, _, err := f.ss.NewApplicationTeam(ctx, f.ids(0, 1), f.tournament.ID, team.ID, f.user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.accept(t, application)
+
+			_, _, _, err = f.ss.UpdApplicationTeam(ctx, application.ID, 0, f.ids(0, 2), "", team.ID, f.user.ID)
+			_, _, changeErr := f.ss.NewRosterChange(ctx, &models.RosterChange{
+				ApplicationID: application.ID,
+				PlayerOutID:   f.players[1].ID,
+				PlayerInID:    f.players[2].ID,
+			}, team.ID, f.user.ID)
+
+			if c.frozen {
+				if !errors.Is(err, errsport.ErrRosterFrozen) {
+					t.Fatalf("expected ErrRosterFrozen, got %v", err)
+				}
+				if changeErr != nil {
+					t.Fatalf("roster change: %v", changeErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("update players: %v", err)
+			}
+			if !errors.Is(changeErr, errsport.ErrRosterNotFrozen) {
+				t.Fatalf("expected ErrRosterNotFrozen, got %v", changeErr)
+			}
+		})
+	}
+}
+
+func TestLateSubstitutionsLimit(t *testing.T) {
+	ctx := context.Background()
+	two := uint(2)
+
+	cases := []struct {
+		name    string
+		limit   *uint
+		options []option
+		allowed int
+	}{
+		{"default", nil, nil, 3},
+		{"option", nil, []option{SetLateSubstitutionsLimit(1)}, 1},
+		{"tournament overrides option", &two, []option{SetLateSubstitutionsLimit(1)}, 2},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f := newFixture(t, &models.Tournament{RosterFreezeDate: date(-1), MaxLateSubstitutions: c.limit},
+				c.options...)
+			team := f.newTeam(t, "team", 0, 1)
+			application, _, _, err := f.ss.NewApplicationTeam(ctx, f.ids(0), f.tournament.ID, team.ID, f.user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.accept(t, application)
+
+			// запросы на замену не меняют состав, пока их не одобрили, поэтому повторяем одну замену
+			change := func() error {
+				_, _, err := f.ss.NewRosterChange(ctx, &models.RosterChange{
+					ApplicationID: application.ID,
+					PlayerOutID:   f.players[0].ID,
+					PlayerInID:    f.players[1].ID,
+				}, team.ID, f.user.ID)
+				return err
+			}
+			for i := range c.allowed {
+				if err = change(); err != nil {
+					t.Fatalf("change %d: %v", i+1, err)
+				}
+			}
+			if err = change(); !errors.Is(err, errsport.ErrSubstitutionLimit) {
+				t.Fatalf("expected ErrSubstitutionLimit, got %v", err)
+			}
+		})
+	}
+}
+
+func TestConcurrentApplications(t *testing.T) {
+	ctx := context.Background()
+	const n = 8
+
+	t.Run("same team", func(t *testing.T) {
+		f := newFixture(t, nil)
+		team := f.newTeam(t, "team", 0)
+
+		errs := submitConcurrently(n, func(int) error {
+			_, _, _, err := f.ss.NewApplicationTeam(ctx, f.ids(0), f.tournament.ID, team.ID, f.user.ID)
+			return err
+		})
+		if ok := countSubmitted(t, errs, errstore.ErrConflictData); ok != 1 {
+			t.Fatalf("expected 1 application, got %d", ok)
+		}
+	})
+
+	t.Run("shared player", func(t *testing.T) {
+		f := newFixture(t, nil)
+		teams := make([]*models.Team, n)
+		for i := range teams {
+			teams[i] = f.newTeam(t, "team", 0)
+		}
+
+		errs := submitConcurrently(n, func(i int) error {
+			_, _, _, err := f.ss.NewApplicationTeam(ctx, f.ids(0), f.tournament.ID, teams[i].ID, f.user.ID)
+			return err
+		})
+		if ok := countSubmitted(t, errs, errsport.ErrRosterConflict); ok != 1 {
+			t.Fatalf("expected 1 application with shared player, got %d", ok)
+		}
+	})
+}
+
+func submitConcurrently(n int, fn func(i int) error) []error {
+	errs := make([]error, n)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = fn(i)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
+
+// countSubmitted число успешных заявок, остальные должны завершиться ошибкой rejected.
+func countSubmitted(t *testing.T, errs []error, rejected error) int {
+	t.Helper()
+	submitted := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			submitted++
+		case !errors.Is(err, rejected):
+			t.Fatalf("expected %v, got %v", rejected, err)
+		}
+	}
+	return submitted
+}
+
+func TestIdempotency(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("replay", func(t *testing.T) {
+		ss := must(New(memory.New(), nil))(t)
+		key := must(ss.BeginIdempotent(ctx, 1, "k", "h"))(t)
+		if key.StatusCode != 0 {
+			t.Fatalf("new key has response %d", key.StatusCode)
+		}
+		key.StatusCode = 201
+		key.Body = []byte(`{"id":1}`)
+		if err := ss.FinishIdempotent(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+
+		replay := must(ss.BeginIdempotent(ctx, 1, "k", "h"))(t)
+		if replay.StatusCode != 201 || string(replay.Body) != `{"id":1}` {
+			t.Fatalf("unexpected replay %+v", replay)
+		}
+		// ключи разных пользователей не пересекаются
+		if other := must(ss.BeginIdempotent(ctx, 2, "k", "h2"))(t); other.StatusCode != 0 {
+			t.Fatalf("key of another user is replayed: %+v", other)
+		}
+	})
+
+	t.Run("reused", func(t *testing.T) {
+		ss := must(New(memory.New(), nil))(t)
+		must(ss.BeginIdempotent(ctx, 1, "k", "h"))(t)
+		if _, err := ss.BeginIdempotent(ctx, 1, "k", "other"); !errors.Is(err, errsport.ErrIdempotencyKeyReused) {
+			t.Fatalf("expected ErrIdempotencyKeyReused, got %v", err)
+		}
+	})
+
+	t.Run("in progress", func(t *testing.T) {
+		ss := must(New(memory.New(), nil))(t)
+		must(ss.BeginIdempotent(ctx, 1, "k", "h"))(t)
+		if _, err := ss.BeginIdempotent(ctx, 1, "k", "h"); !errors.Is(err, errsport.ErrIdempotencyInProgress) {
+			t.Fatalf("expected ErrIdempotencyInProgress, got %v", err)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ss := must(New(memory.New(), nil))(t)
+		key := must(ss.BeginIdempotent(ctx, 1, "k", "h"))(t)
+		if err := ss.CancelIdempotent(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		if key = must(ss.BeginIdempotent(ctx, 1, "k", "other"))(t); key.StatusCode != 0 {
+			t.Fatalf("canceled key is replayed: %+v", key)
+		}
+	})
+
+	t.Run("abandoned", func(t *testing.T) {
+		ss := must(New(memory.New(), nil, SetIdempotencyLease(10*time.Millisecond)))(t)
+		must(ss.BeginIdempotent(ctx, 1, "k", "h"))(t)
+		time.Sleep(20 * time.Millisecond)
+		// запрос упал, не сохранив ответ, повтор занимает ключ заново
+		if key := must(ss.BeginIdempotent(ctx, 1, "k", "h"))(t); key.StatusCode != 0 {
+			t.Fatalf("abandoned key is replayed: %+v", key)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		ss := must(New(memory.New(), nil, SetIdempotencyTTL(10*time.Millisecond)))(t)
+		key := must(ss.BeginIdempotent(ctx, 1, "k", "h"))(t)
+		key.StatusCode = 200
+		if err := ss.FinishIdempotent(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if key = must(ss.BeginIdempotent(ctx, 1, "k", "other"))(t); key.StatusCode != 0 {
+			t.Fatalf("expired key is replayed: %+v", key)
+		}
+		if n := must(ss.PurgeIdempotencyKeys(ctx))(t); n != 0 {
+			t.Fatalf("fresh key is purged: %d", n)
+		}
+	})
+}
@@ -0,0 +1,290 @@
+package sportspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"sport-space/internal/adapter/errsport"
+	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/storage/errstore"
+
+	"go.uber.org/zap"
+)
+
+// Trash записи пользователя в корзине, последние удаленные первыми.
+type Trash struct {
+	Tournaments *[]models.Tournament
+	Teams       *[]models.Team
+	Players     *[]models.Player
+}
+
+// isRunning турнир начался и еще не закончился.
+func isRunning(t *models.Tournament) bool {
+	now := time.Now()
+	return t.StartDate != nil && t.EndDate != nil && now.After(*t.StartDate) && now.Before(*t.EndDate)
+}
+
+// PurgeAt когда запись, удаленная в deletedAt, будет удалена окончательно.
+func (s *SportSpace) PurgeAt(deletedAt time.Time) time.Time {
+	return deletedAt.Add(s.trashRetention)
+}
+
+// checkRunningApplications запрещает удаление, если среди заявок есть принятая в идущий турнир.
+func (s *SportSpace) checkRunningApplications(ctx context.Context, applications *[]models.Application) error {
+	for _, a := range *applications {
+		if a.Status != models.Accepted {
+			continue
+		}
+		t, err := s.store.GetTournamentByID(ctx, a.TournamentID)
+		if err != nil {
+			return fmt.Errorf("failed get tournament: %w", err)
+		}
+		if isRunning(t) {
+			return errsport.ErrRunningApplication
+		}
+	}
+	return nil
+}
+
+// lockedApplications заявки get, турниры которых заблокированы до конца транзакции, как
+// при принятии заявки в UpdApplicationTournament, поэтому их статус уже не изменится.
+// Турниры блокируются по возрастанию id, заявки перечитываются после блокировок, пока
+// среди них не останется заявок в незаблокированные турниры.
+func (s *SportSpace) lockedApplications(ctx context.Context,
+	get func(ctx context.Context) (*[]models.Application, error),
+) (*[]models.Application, error) {
+	locked := map[uint]bool{}
+	for {
+		applications, err := get(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed get applications: %w", err)
+		}
+
+		var ids []uint
+		for _, a := range *applications {
+			if !locked[a.TournamentID] && !slices.Contains(ids, a.TournamentID) {
+				ids = append(ids, a.TournamentID)
+			}
+		}
+		if len(ids) == 0 {
+			return applications, nil
+		}
+		slices.Sort(ids)
+		for _, id := range ids {
+			// удаленный турнир удален вместе с заявками, блокировать нечего
+			err = s.store.LockTournament(ctx, id)
+			if err != nil && !errors.Is(err, errstore.ErrNotFoundData) {
+				return nil, err
+			}
+			locked[id] = true
+		}
+	}
+}
+
+// DelTournament удаляет турнир пользователя в корзину вместе с заявками. Идущий турнир
+// с принятыми заявками удалить нельзя.
+func (s *SportSpace) DelTournament(ctx context.Context, tournamentID, userID uint) (*models.Tournament, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.DelTournament")
+	defer span.End()
+
+	var tournament *models.Tournament
+	err := s.store.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		tournament, err = s.store.GetTournamentByID(ctx, tournamentID)
+		if err != nil {
+			return fmt.Errorf("failed get tournament: %w", err)
+		}
+		if tournament.UserID != userID {
+			return fmt.Errorf("not found tournament: %w", errstore.ErrNotFoundData)
+		}
+		if err = s.store.LockTournament(ctx, tournament.ID); err != nil {
+			return err
+		}
+
+		applications, err := s.store.GetActiveApplicationsFromTournament(ctx, tournament.ID)
+		if err != nil {
+			return fmt.Errorf("failed get applications: %w", err)
+		}
+		if err = s.checkRunningApplications(ctx, applications); err != nil {
+			return err
+		}
+
+		tournament.DeletedAt.Time, tournament.DeletedAt.Valid = time.Now(), true
+		return s.store.DelTournament(ctx, tournament.ID, tournament.DeletedAt.Time)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tournament, nil
+}
+
+// DelTeam удаляет команду пользователя в корзину вместе с заявками. Команду с принятой
+// заявкой в идущий турнир удалить нельзя.
+func (s *SportSpace) DelTeam(ctx context.Context, teamID, userID uint) (*models.Team, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.DelTeam")
+	defer span.End()
+
+	var team *models.Team
+	err := s.store.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		team, err = s.store.GetTeamByID(ctx, teamID)
+		if err != nil {
+			return fmt.Errorf("failed get team: %w", err)
+		}
+		if team.UserID != userID {
+			return fmt.Errorf("not found team: %w", errstore.ErrNotFoundData)
+		}
+
+		applications, err := s.lockedApplications(ctx, func(ctx context.Context) (*[]models.Application, error) {
+			return s.store.GetApplicationsByTeamID(ctx, team.ID)
+		})
+		if err != nil {
+			return err
+		}
+		if err = s.checkRunningApplications(ctx, applications); err != nil {
+			return err
+		}
+
+		team.DeletedAt.Time, team.DeletedAt.Valid = time.Now(), true
+		return s.store.DelTeam(ctx, team.ID, team.DeletedAt.Time)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// DelPlayer удаляет игрока пользователя в корзину. Игрока из принятой заявки в идущий
+// турнир удалить нельзя.
+func (s *SportSpace) DelPlayer(ctx context.Context, playerID, userID uint) (*models.Player, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.DelPlayer")
+	defer span.End()
+
+	var player *models.Player
+	err := s.store.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		player, err = s.store.GetPlayerByID(ctx, playerID)
+		if err != nil {
+			return fmt.Errorf("failed get player: %w", err)
+		}
+		if player.UserID != userID {
+			return fmt.Errorf("not found player: %w", errstore.ErrNotFoundData)
+		}
+
+		applications, err := s.lockedApplications(ctx, func(ctx context.Context) (*[]models.Application, error) {
+			return s.store.GetApplicationsByPlayerID(ctx, player.ID)
+		})
+		if err != nil {
+			return err
+		}
+		if err = s.checkRunningApplications(ctx, applications); err != nil {
+			return err
+		}
+
+		player.DeletedAt.Time, player.DeletedAt.Valid = time.Now(), true
+		return s.store.DelPlayer(ctx, player.ID, player.DeletedAt.Time)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return player, nil
+}
+
+// GetTrash записи пользователя, которые еще можно восстановить.
+func (s *SportSpace) GetTrash(ctx context.Context, userID uint) (*Trash, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.GetTrash")
+	defer span.End()
+
+	after := time.Now().Add(-s.trashRetention)
+
+	tournaments, err := s.store.GetDeletedTournaments(ctx, userID, after)
+	if err != nil {
+		return nil, fmt.Errorf("failed get deleted tournaments: %w", err)
+	}
+	teams, err := s.store.GetDeletedTeams(ctx, userID, after)
+	if err != nil {
+		return nil, fmt.Errorf("failed get deleted teams: %w", err)
+	}
+	players, err := s.store.GetDeletedPlayers(ctx, userID, after)
+	if err != nil {
+		return nil, fmt.Errorf("failed get deleted players: %w", err)
+	}
+	return &Trash{Tournaments: tournaments, Teams: teams, Players: players}, nil
+}
+
+// RestoreTournament возвращает турнир из корзины вместе с заявками, удаленными с ним.
+func (s *SportSpace) RestoreTournament(ctx context.Context, tournamentID, userID uint) (*models.Tournament, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.RestoreTournament")
+	defer span.End()
+
+	err := s.store.RestoreTournament(ctx, userID, tournamentID, time.Now().Add(-s.trashRetention))
+	if err != nil {
+		return nil, fmt.Errorf("failed restore tournament: %w", err)
+	}
+	return s.GetTournamentByID(ctx, tournamentID)
+}
+
+// RestoreTeam возвращает команду из корзины вместе с заявками, удаленными с ней.
+func (s *SportSpace) RestoreTeam(ctx context.Context, teamID, userID uint) (*models.Team, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.RestoreTeam")
+	defer span.End()
+
+	err := s.store.RestoreTeam(ctx, userID, teamID, time.Now().Add(-s.trashRetention))
+	if err != nil {
+		return nil, fmt.Errorf("failed restore team: %w", err)
+	}
+	return s.GetTeamByID(ctx, teamID)
+}
+
+// RestorePlayer возвращает игрока из корзины.
+func (s *SportSpace) RestorePlayer(ctx context.Context, playerID, userID uint) (*models.Player, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.RestorePlayer")
+	defer span.End()
+
+	err := s.store.RestorePlayer(ctx, userID, playerID, time.Now().Add(-s.trashRetention))
+	if err != nil {
+		return nil, fmt.Errorf("failed restore player: %w", err)
+	}
+	return s.store.GetPlayerByID(ctx, playerID)
+}
+
+// PurgeTrash окончательно удаляет записи, которые лежат в корзине дольше срока хранения.
+// Файлы этих записей затем удаляет RemoveOrphanUploads.
+func (s *SportSpace) PurgeTrash(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.PurgeTrash")
+	defer span.End()
+
+	purged, err := s.store.PurgeDeleted(ctx, time.Now().Add(-s.trashRetention))
+	if err != nil {
+		return 0, fmt.Errorf("failed purge trash: %w", err)
+	}
+	return purged, nil
+}
+
+// RunTrashPurge периодически очищает корзину до отмены ctx.
+func (s *SportSpace) RunTrashPurge(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, err := s.PurgeTrash(ctx)
+		if err != nil {
+			s.log.Error("failed purge trash", zap.Error(err))
+		}
+		if purged > 0 {
+			s.log.Info("trash purged", zap.Int("count", purged))
+		}
+	}
+}
@@ -0,0 +1,475 @@
+package sportspace
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"sport-space/internal/adapter/errsport"
+	"sport-space/internal/adapter/models"
+	"sport-space/internal/adapter/storage/errstore"
+	"sport-space/pkg/imaging"
+	"sport-space/pkg/tools"
+
+	"go.uber.org/zap"
+)
+
+const (
+	uploadOriginal = "original"
+	uploadGCBatch  = 100
+	// sniffLen сколько байт первой части нужно для определения формата документа.
+	sniffLen = 512
+)
+
+// documentTypes допустимые форматы документов и расширения их файлов.
+var documentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+type blob interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// NewUpload перекодирует изображение в стандартные варианты, проверяет квоту пользователя
+// и сохраняет файлы в каталог `<userID>/<случайное имя>/<вариант>.<ext>`.
+func (s *SportSpace) NewUpload(ctx context.Context, userID uint, file io.Reader) (*models.Upload, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.NewUpload")
+	defer span.End()
+
+	if s.blob == nil {
+		return nil, ErrUploadsDisabled
+	}
+
+	images, err := imaging.Process(file, imaging.DefaultVariants)
+	if err != nil {
+		return nil, fmt.Errorf("failed process image: %w", err)
+	}
+
+	var size int64
+	for _, img := range images {
+		size += int64(len(img.Data))
+	}
+	if err = s.checkUploadQuota(ctx, userID, size); err != nil {
+		return nil, err
+	}
+
+	upload := &models.Upload{
+		UserID:   userID,
+		Kind:     models.UploadImage,
+		Size:     size,
+		Keys:     map[string]string{},
+		Variants: map[string]string{},
+	}
+	dir := fmt.Sprintf("%d/%s", userID, tools.RandomString(20))
+	for _, img := range images {
+		key := dir + "/" + img.Variant + img.Ext
+		err = s.blob.Put(ctx, key, bytes.NewReader(img.Data), int64(len(img.Data)), img.ContentType)
+		if err != nil {
+			s.removeUploadFiles(ctx, upload)
+			return nil, fmt.Errorf("failed save %s: %w", img.Variant, err)
+		}
+		upload.Keys[img.Variant] = key
+		upload.Variants[img.Variant] = s.blob.URL(key)
+		if img.Variant == uploadOriginal {
+			upload.URL = s.blob.URL(key)
+			upload.MIMEType = img.ContentType
+		}
+	}
+
+	upload, err = s.store.NewUpload(ctx, upload)
+	if err != nil {
+		s.removeUploadFiles(ctx, upload)
+		return nil, fmt.Errorf("failed save upload: %w", err)
+	}
+	return upload, nil
+}
+
+func (s *SportSpace) checkUploadQuota(ctx context.Context, userID uint, size int64) error {
+	if s.uploadQuota <= 0 {
+		return nil
+	}
+	used, err := s.store.GetUploadsSize(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed get used space: %w", err)
+	}
+	if used+size > s.uploadQuota {
+		return fmt.Errorf("%w: used %d of %d bytes", errsport.ErrUploadQuota, used, s.uploadQuota)
+	}
+	return nil
+}
+
+func (s *SportSpace) removeUploadFiles(ctx context.Context, upload *models.Upload) {
+	for _, key := range upload.Keys {
+		if err := s.blob.Delete(ctx, key); err != nil {
+			s.log.Error("failed remove upload file", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+// checkUploadURLs проверяет, что ссылки на файлы ведут на загрузки пользователя userID.
+// Ссылки из previous - текущие значения записи - не проверяются, чтобы старые записи можно было сохранить.
+func (s *SportSpace) checkUploadURLs(ctx context.Context, userID uint, urls []string, previous ...string) error {
+	for _, url := range urls {
+		if url == "" || slices.Contains(previous, url) {
+			continue
+		}
+		upload, err := s.store.GetUploadByURL(ctx, url)
+		if err != nil {
+			if errors.Is(err, errstore.ErrNotFoundData) {
+				return fmt.Errorf("%w: %s", errsport.ErrUploadNotOwned, url)
+			}
+			return fmt.Errorf("failed get upload: %w", err)
+		}
+		if upload.UserID != userID {
+			return fmt.Errorf("%w: %s", errsport.ErrUploadNotOwned, url)
+		}
+	}
+	return nil
+}
+
+// RemoveOrphanUploads удаляет загрузки старше grace, на которые не ссылается ни одна запись.
+func (s *SportSpace) RemoveOrphanUploads(ctx context.Context, grace time.Duration) (int, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.RemoveOrphanUploads")
+	defer span.End()
+
+	if s.blob == nil {
+		return 0, nil
+	}
+
+	removed := 0
+	before := time.Now().Add(-grace)
+	for {
+		uploads, err := s.store.GetOrphanUploads(ctx, before, uploadGCBatch)
+		if err != nil {
+			return removed, fmt.Errorf("failed get orphan uploads: %w", err)
+		}
+
+		for _, upload := range *uploads {
+			s.removeUploadFiles(ctx, &upload)
+			if err = s.store.RemoveUpload(ctx, upload.ID); err != nil {
+				return removed, fmt.Errorf("failed remove upload: %w", err)
+			}
+			removed++
+		}
+		if len(*uploads) < uploadGCBatch {
+			return removed, nil
+		}
+	}
+}
+
+// NewUploadSession начинает загрузку документа размером size байт частями.
+func (s *SportSpace) NewUploadSession(ctx context.Context, userID uint, filename string, size int64) (
+	*models.UploadSession, error,
+) {
+	ctx, span := tracer.Start(ctx, "SportSpace.NewUploadSession")
+	defer span.End()
+
+	if s.blob == nil {
+		return nil, ErrUploadsDisabled
+	}
+	if err := s.checkUploadQuota(ctx, userID, size); err != nil {
+		return nil, err
+	}
+
+	session, err := s.store.NewUploadSession(ctx, &models.UploadSession{
+		ID:        tools.RandomString(32),
+		UserID:    userID,
+		Filename:  filepath.Base(filepath.Clean("/" + filename)),
+		Size:      size,
+		Chunks:    []string{},
+		ExpiresAt: time.Now().Add(s.uploadSessionTTL),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed create upload session: %w", err)
+	}
+	return session, nil
+}
+
+// GetUploadSession сессия загрузки пользователя userID, чужие сессии не находятся.
+func (s *SportSpace) GetUploadSession(ctx context.Context, sessionID string, userID uint) (
+	*models.UploadSession, error,
+) {
+	ctx, span := tracer.Start(ctx, "SportSpace.GetUploadSession")
+	defer span.End()
+
+	session, err := s.store.GetUploadSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, errstore.ErrNotFoundData) {
+			return nil, errsport.ErrNotFoundData
+		}
+		return nil, fmt.Errorf("failed get upload session: %w", err)
+	}
+	if session.UserID != userID {
+		return nil, errsport.ErrNotFoundData
+	}
+	return session, nil
+}
+
+// AppendUploadChunk дописывает часть размером size, начинающуюся с offset. Часть должна продолжать
+// уже полученные данные, иначе возвращается ErrUploadOffset вместе с текущей сессией, чтобы клиент
+// продолжил с session.Received. После последней части документ собирается в Upload.
+func (s *SportSpace) AppendUploadChunk(ctx context.Context, sessionID string, userID uint,
+	offset, size int64, chunk io.Reader,
+) (*models.UploadSession, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.AppendUploadChunk")
+	defer span.End()
+
+	session, err := s.GetUploadSession(ctx, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Received == session.Size {
+		// повтор последней части или сборки после ошибки
+		if session.UploadID != nil {
+			return session, nil
+		}
+		return s.completeUploadSession(ctx, session)
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return nil, errsport.ErrUploadExpired
+	}
+	if offset != session.Received || size <= 0 || offset+size > session.Size {
+		return session, fmt.Errorf("%w: expected offset %d", errsport.ErrUploadOffset, session.Received)
+	}
+
+	if offset == 0 {
+		br := bufio.NewReaderSize(chunk, sniffLen)
+		head, _ := br.Peek(sniffLen)
+		session.MIMEType = http.DetectContentType(head)
+		if _, ok := documentTypes[session.MIMEType]; !ok {
+			return nil, fmt.Errorf("%w: %s", errsport.ErrDocumentFormat, session.MIMEType)
+		}
+		chunk = br
+	}
+
+	// случайный суффикс, чтобы параллельные запросы с одним offset не затирали части друг друга
+	key := fmt.Sprintf("%d/sessions/%s/%012d-%s", userID, session.ID, offset, tools.RandomString(8))
+	counter := &countingReader{r: io.LimitReader(chunk, size)}
+	if err = s.blob.Put(ctx, key, counter, size, "application/octet-stream"); err != nil {
+		return nil, fmt.Errorf("failed save chunk: %w", err)
+	}
+	if counter.n != size {
+		s.removeFiles(ctx, []string{key})
+		return nil, fmt.Errorf("%w: got %d of %d bytes", errsport.ErrUploadChunkSize, counter.n, size)
+	}
+
+	received := session.Received
+	session.Received += size
+	session.Chunks = append(session.Chunks, key)
+	if err = s.store.UpdUploadSession(ctx, session, received); err != nil {
+		s.removeFiles(ctx, []string{key})
+		if errors.Is(err, errstore.ErrConflictData) {
+			current, gerr := s.GetUploadSession(ctx, sessionID, userID)
+			if gerr != nil {
+				return nil, gerr
+			}
+			return current, fmt.Errorf("%w: expected offset %d", errsport.ErrUploadOffset, current.Received)
+		}
+		return nil, fmt.Errorf("failed update upload session: %w", err)
+	}
+
+	if session.Received < session.Size {
+		return session, nil
+	}
+	return s.completeUploadSession(ctx, session)
+}
+
+// completeUploadSession склеивает части в файл `<userID>/<случайное имя>/original.<ext>`.
+func (s *SportSpace) completeUploadSession(ctx context.Context, session *models.UploadSession) (
+	*models.UploadSession, error,
+) {
+	if err := s.checkUploadQuota(ctx, session.UserID, session.Size); err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%d/%s/%s%s", session.UserID, tools.RandomString(20), uploadOriginal,
+		documentTypes[session.MIMEType])
+	chunks := &chunksReader{ctx: ctx, blob: s.blob, keys: session.Chunks}
+	err := s.blob.Put(ctx, key, chunks, session.Size, session.MIMEType)
+	if cerr := chunks.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed assemble document: %w", err)
+	}
+
+	upload := &models.Upload{
+		UserID:   session.UserID,
+		Kind:     models.UploadDocument,
+		URL:      s.blob.URL(key),
+		Filename: session.Filename,
+		MIMEType: session.MIMEType,
+		Size:     session.Size,
+		Keys:     map[string]string{uploadOriginal: key},
+		Variants: map[string]string{uploadOriginal: s.blob.URL(key)},
+	}
+	upload, err = s.store.NewUpload(ctx, upload)
+	if err != nil {
+		s.removeFiles(ctx, []string{key})
+		return nil, fmt.Errorf("failed save upload: %w", err)
+	}
+
+	chunkKeys := session.Chunks
+	session.Chunks = []string{}
+	session.UploadID = &upload.ID
+	session.Upload = upload
+	if err = s.store.UpdUploadSession(ctx, session, session.Received); err != nil {
+		return nil, fmt.Errorf("failed update upload session: %w", err)
+	}
+	s.removeFiles(ctx, chunkKeys)
+	return session, nil
+}
+
+// AbortUploadSession отменяет загрузку и удаляет полученные части.
+func (s *SportSpace) AbortUploadSession(ctx context.Context, sessionID string, userID uint) error {
+	ctx, span := tracer.Start(ctx, "SportSpace.AbortUploadSession")
+	defer span.End()
+
+	session, err := s.GetUploadSession(ctx, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	s.removeFiles(ctx, session.Chunks)
+	if err = s.store.RemoveUploadSession(ctx, session.ID); err != nil {
+		return fmt.Errorf("failed remove upload session: %w", err)
+	}
+	return nil
+}
+
+// RemoveExpiredUploadSessions удаляет истекшие сессии загрузки вместе с частями.
+// Собранные документы остаются, их удаляет RemoveOrphanUploads.
+func (s *SportSpace) RemoveExpiredUploadSessions(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "SportSpace.RemoveExpiredUploadSessions")
+	defer span.End()
+
+	if s.blob == nil {
+		return 0, nil
+	}
+
+	removed := 0
+	for {
+		sessions, err := s.store.GetExpiredUploadSessions(ctx, time.Now(), uploadGCBatch)
+		if err != nil {
+			return removed, fmt.Errorf("failed get expired upload sessions: %w", err)
+		}
+
+		for _, session := range *sessions {
+			s.removeFiles(ctx, session.Chunks)
+			if err = s.store.RemoveUploadSession(ctx, session.ID); err != nil {
+				return removed, fmt.Errorf("failed remove upload session: %w", err)
+			}
+			removed++
+		}
+		if len(*sessions) < uploadGCBatch {
+			return removed, nil
+		}
+	}
+}
+
+func (s *SportSpace) removeFiles(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.blob.Delete(ctx, key); err != nil {
+			s.log.Error("failed remove upload chunk", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// chunksReader читает части загрузки подряд, открывая следующую по мере чтения.
+type chunksReader struct {
+	ctx  context.Context
+	blob blob
+	keys []string
+	cur  io.ReadCloser
+}
+
+func (c *chunksReader) Read(p []byte) (int, error) {
+	for {
+		if c.cur == nil {
+			if len(c.keys) == 0 {
+				return 0, io.EOF
+			}
+			rc, err := c.blob.Open(c.ctx, c.keys[0])
+			if err != nil {
+				return 0, fmt.Errorf("failed open chunk: %w", err)
+			}
+			c.cur, c.keys = rc, c.keys[1:]
+		}
+
+		n, err := c.cur.Read(p)
+		if errors.Is(err, io.EOF) {
+			err = c.cur.Close()
+			c.cur = nil
+			if err != nil {
+				return n, fmt.Errorf("failed close chunk: %w", err)
+			}
+			if n == 0 {
+				continue
+			}
+			return n, nil
+		}
+		return n, err
+	}
+}
+
+func (c *chunksReader) Close() error {
+	if c.cur == nil {
+		return nil
+	}
+	return c.cur.Close()
+}
+
+// RunUploadGC периодически удаляет неиспользуемые загрузки до отмены ctx.
+func (s *SportSpace) RunUploadGC(ctx context.Context, interval, grace time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		expired, err := s.RemoveExpiredUploadSessions(ctx)
+		if err != nil {
+			s.log.Error("failed remove expired upload sessions", zap.Error(err))
+		}
+		if expired > 0 {
+			s.log.Info("expired upload sessions removed", zap.Int("count", expired))
+		}
+
+		removed, err := s.RemoveOrphanUploads(ctx, grace)
+		if err != nil {
+			s.log.Error("failed remove orphan uploads", zap.Error(err))
+		}
+		if removed > 0 {
+			s.log.Info("orphan uploads removed", zap.Int("count", removed))
+		}
+	}
+}
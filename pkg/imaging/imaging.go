@@ -0,0 +1,168 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrTooLarge          = errors.New("image dimensions are too large")
+)
+
+// MaxPixels ограничение размера изображения до декодирования, защита от "zip бомб".
+const MaxPixels = 40_000_000
+
+const jpegQuality = 85
+
+// Variant размер изображения: Size - максимальная сторона в пикселях, 0 - без изменения размера.
+type Variant struct {
+	Name string
+	Size int
+}
+
+// DefaultVariants варианты для логотипов и фото: миниатюра для списков, средний и исходный размер.
+var DefaultVariants = []Variant{
+	{Name: "thumbnail", Size: 160},
+	{Name: "medium", Size: 640},
+	{Name: "original", Size: 2048},
+}
+
+type Result struct {
+	Variant     string
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+// Process определяет формат по содержимому, поворачивает изображение по EXIF
+// и перекодирует его во все варианты. Метаданные при перекодировании не сохраняются.
+// JPEG остается JPEG, остальные форматы сохраняются в PNG.
+func Process(r io.Reader, variants []Variant) ([]Result, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed read image: %w", err)
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedFormat, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedFormat, err)
+	}
+
+	img := toNRGBA(src)
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	res := make([]Result, 0, len(variants))
+	for _, v := range variants {
+		resized := fit(img, v.Size)
+		item := Result{
+			Variant: v.Name,
+			Width:   resized.Bounds().Dx(),
+			Height:  resized.Bounds().Dy(),
+		}
+
+		buf := &bytes.Buffer{}
+		if format == "jpeg" {
+			err = jpeg.Encode(buf, resized, &jpeg.Options{Quality: jpegQuality})
+			item.ContentType, item.Ext = "image/jpeg", ".jpg"
+		} else {
+			err = png.Encode(buf, resized)
+			item.ContentType, item.Ext = "image/png", ".png"
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed encode %s: %w", v.Name, err)
+		}
+		item.Data = buf.Bytes()
+		res = append(res, item)
+	}
+
+	return res, nil
+}
+
+func toNRGBA(src image.Image) *image.NRGBA {
+	// для GIF декодируется первый кадр, палитра переводится в полноцветное изображение
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
+
+// fit уменьшает изображение, чтобы большая сторона была не больше size, увеличение не выполняется.
+func fit(img *image.NRGBA, size int) *image.NRGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if size <= 0 || (w <= size && h <= size) {
+		return img
+	}
+
+	if w >= h {
+		h = max(1, h*size/w)
+		w = size
+	} else {
+		w = max(1, w*size/h)
+		h = size
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return dst
+}
+
+// orient приводит изображение к нормальной ориентации по значению EXIF тега Orientation.
+func orient(img *image.NRGBA, orientation int) *image.NRGBA {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := range h {
+		for x := range w {
+			var dx, dy int
+			switch orientation {
+			case 2: // отражение по горизонтали
+				dx, dy = w-1-x, y
+			case 3: // поворот на 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // отражение по вертикали
+				dx, dy = x, h-1-y
+			case 5: // транспонирование
+				dx, dy = y, x
+			case 6: // поворот на 90 по часовой
+				dx, dy = h-1-y, x
+			case 7: // транспонирование по второй диагонали
+				dx, dy = h-1-y, w-1-x
+			case 8: // поворот на 90 против часовой
+				dx, dy = y, w-1-x
+			}
+			si := img.PixOffset(x, y)
+			di := dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], img.Pix[si:si+4])
+		}
+	}
+	return dst
+}
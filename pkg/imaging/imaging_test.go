@@ -0,0 +1,163 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Фикстуры в testdata:
+//   - exif_orientation6_gps.jpg 800x400 с EXIF: Orientation=6 (повернуть на 90 по часовой) и GPS,
+//     левый верхний угол красный;
+//   - exif_gps.png 300x200 с чанками eXIf (GPS) и tEXt, левый верхний угол красный;
+//   - not_image.jpg текст с расширением .jpg;
+//   - fake_header.png сигнатура PNG, за которой мусор.
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+type size struct{ w, h int }
+
+func TestProcess(t *testing.T) {
+	cases := []struct {
+		fixture     string
+		contentType string
+		decode      func(data []byte) (image.Image, error)
+		// sizes размеры вариантов DefaultVariants
+		sizes []size
+		// red угол, в котором после поворота оказался красный угол исходника
+		red func(w, h int) (int, int)
+	}{
+		{
+			fixture:     "exif_orientation6_gps.jpg",
+			contentType: "image/jpeg",
+			decode:      func(data []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(data)) },
+			// 800x400 повернуто в 400x800
+			sizes: []size{{80, 160}, {320, 640}, {400, 800}},
+			red:   func(w, h int) (int, int) { return w - w/16, h / 16 },
+		},
+		{
+			fixture:     "exif_gps.png",
+			contentType: "image/png",
+			decode:      func(data []byte) (image.Image, error) { return png.Decode(bytes.NewReader(data)) },
+			sizes:       []size{{160, 106}, {300, 200}, {300, 200}},
+			red:         func(w, h int) (int, int) { return w / 16, h / 16 },
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.fixture, func(t *testing.T) {
+			data := readFixture(t, c.fixture)
+			if !hasMetadata(data) {
+				t.Fatal("fixture has no EXIF")
+			}
+
+			res, err := Process(bytes.NewReader(data), DefaultVariants)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res) != len(DefaultVariants) {
+				t.Fatalf("expected %d variants, got %d", len(DefaultVariants), len(res))
+			}
+
+			for i, r := range res {
+				want := c.sizes[i]
+				if r.Variant != DefaultVariants[i].Name || r.ContentType != c.contentType {
+					t.Fatalf("unexpected variant %s %s", r.Variant, r.ContentType)
+				}
+				if r.Width != want.w || r.Height != want.h {
+					t.Fatalf("%s: expected %dx%d, got %dx%d", r.Variant, want.w, want.h, r.Width, r.Height)
+				}
+				if hasMetadata(r.Data) {
+					t.Fatalf("%s keeps metadata", r.Variant)
+				}
+
+				img, err := c.decode(r.Data)
+				if err != nil {
+					t.Fatalf("%s is not decoded: %v", r.Variant, err)
+				}
+				if b := img.Bounds(); b.Dx() != want.w || b.Dy() != want.h {
+					t.Fatalf("%s: encoded %dx%d, expected %dx%d", r.Variant, b.Dx(), b.Dy(), want.w, want.h)
+				}
+				x, y := c.red(want.w, want.h)
+				if cr, cg, cb, _ := img.At(x, y).RGBA(); cr>>8 < 200 || cg>>8 > 60 || cb>>8 > 60 {
+					t.Fatalf("%s is not oriented: pixel %d,%d is %d,%d,%d", r.Variant, x, y, cr>>8, cg>>8, cb>>8)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessRejectsNonImage(t *testing.T) {
+	for _, name := range []string{"not_image.jpg", "fake_header.png"} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Process(bytes.NewReader(readFixture(t, name)), DefaultVariants)
+			if !errors.Is(err, ErrUnsupportedFormat) {
+				t.Fatalf("expected ErrUnsupportedFormat, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProcessTooLarge(t *testing.T) {
+	// заголовок PNG с размерами сверх MaxPixels, данные не декодируются
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:4], 10_000)
+	binary.BigEndian.PutUint32(ihdr[4:8], 10_000)
+	ihdr[8], ihdr[9] = 8, 6
+	chunk := append([]byte("IHDR"), ihdr...)
+	data := append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0d"), chunk...)
+	data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(chunk))
+
+	if _, err := Process(bytes.NewReader(data), DefaultVariants); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	if o := jpegOrientation(readFixture(t, "exif_orientation6_gps.jpg")); o != 6 {
+		t.Fatalf("expected orientation 6, got %d", o)
+	}
+	if o := jpegOrientation([]byte("not a jpeg")); o != 1 {
+		t.Fatalf("expected orientation 1, got %d", o)
+	}
+}
+
+// hasMetadata есть ли в JPEG сегмент APP1 (EXIF, XMP), а в PNG - чанки eXIf или текста.
+func hasMetadata(data []byte) bool {
+	if bytes.HasPrefix(data, []byte("\x89PNG")) {
+		for pos := 8; pos+8 <= len(data); {
+			n := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+			switch string(data[pos+4 : pos+8]) {
+			case "eXIf", "tEXt", "zTXt", "iTXt":
+				return true
+			}
+			pos += 12 + n
+		}
+		return false
+	}
+
+	for pos := 2; pos+4 <= len(data) && data[pos] == 0xFF; {
+		marker := data[pos+1]
+		if marker == 0xDA {
+			break
+		}
+		if marker == 0xE1 {
+			return true
+		}
+		pos += 2 + int(binary.BigEndian.Uint16(data[pos+2:pos+4]))
+	}
+	return bytes.Contains(data, []byte("Exif\x00\x00"))
+}
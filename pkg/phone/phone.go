@@ -0,0 +1,37 @@
+package phone
+
+import (
+	"regexp"
+	"strings"
+)
+
+var reE164 = regexp.MustCompile(`^\+[1-9][0-9]{9,14}$`)
+
+type Phone string
+
+func (p Phone) String() string {
+	return string(p)
+}
+
+// Normalize приводит номер к формату E.164 (+79991234567), российские номера с 8 заменяются на +7.
+func (p Phone) Normalize() Phone {
+	s := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '.':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(p.String()))
+
+	if len(s) == 11 && strings.HasPrefix(s, "8") {
+		s = "+7" + s[1:]
+	}
+	if s != "" && !strings.HasPrefix(s, "+") {
+		s = "+" + s
+	}
+	return Phone(s)
+}
+
+func (p Phone) IsValid() bool {
+	return reE164.MatchString(p.Normalize().String())
+}